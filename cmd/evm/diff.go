@@ -0,0 +1,84 @@
+// Copyright 2016 The go-vector Authors
+// This file is part of go-vector.
+//
+// go-vector is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-vector is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-vector. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/codegangsta/cli"
+	"github.com/vector/go-vector/cmd/utils"
+	"github.com/vector/go-vector/common"
+	"github.com/vector/go-vector/core/vm/runtime"
+)
+
+// diff runs code on both the interpreter and the JIT, reporting the minimised
+// divergence if they disagree. The code is taken from --code, from the corpus
+// files given as arguments (hex encoded) or, without either, generated
+// randomly until a divergence is found.
+func diff(ctx *cli.Context) {
+	cfg := &runtime.Config{
+		GasPrice: common.Big(ctx.GlobalString(PriceFlag.Name)),
+		Value:    common.Big(ctx.GlobalString(ValueFlag.Name)),
+	}
+	if ctx.GlobalIsSet(GasFlag.Name) {
+		cfg.GasLimit = common.Big(ctx.GlobalString(GasFlag.Name))
+	}
+	input := common.Hex2Bytes(ctx.GlobalString(InputFlag.Name))
+
+	switch {
+	case ctx.GlobalString(CodeFlag.Name) != "":
+		checkDiff(common.Hex2Bytes(ctx.GlobalString(CodeFlag.Name)), input, cfg)
+		fmt.Println("engines agree")
+
+	case len(ctx.Args()) > 0:
+		for _, path := range ctx.Args() {
+			blob, err := ioutil.ReadFile(path)
+			if err != nil {
+				utils.Fatalf("Failed to read %s: %v", path, err)
+			}
+			checkDiff(common.FromHex(strings.TrimSpace(string(blob))), input, cfg)
+		}
+		fmt.Printf("engines agree on %d programs\n", len(ctx.Args()))
+
+	default:
+		seed := time.Now().UnixNano()
+		fmt.Printf("fuzzing with seed %d\n", seed)
+
+		r := rand.New(rand.NewSource(seed))
+		for i := 1; ; i++ {
+			checkDiff(runtime.RandomCode(r, 1+r.Intn(256)), input, cfg)
+			if i%1000 == 0 {
+				fmt.Printf("engines agree on %d programs\n", i)
+			}
+		}
+	}
+}
+
+// checkDiff executes the code on both engines, exiting with the minimised
+// report if they diverge.
+func checkDiff(code, input []byte, cfg *runtime.Config) {
+	if d := runtime.Diff(code, input, cfg); d != nil {
+		fmt.Printf("engines diverge on %x\n", code)
+		fmt.Print(runtime.Minimise(d, cfg))
+		os.Exit(1)
+	}
+}
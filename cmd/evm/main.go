@@ -18,6 +18,7 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"os"
@@ -41,6 +42,26 @@ var (
 		Name:  "debug",
 		Usage: "output full trace logs",
 	}
+	CallTraceFlag = cli.BoolFlag{
+		Name:  "calltrace",
+		Usage: "output the call tree of the execution as JSON",
+	}
+	PrestateFlag = cli.BoolFlag{
+		Name:  "prestate",
+		Usage: "output the state accessed by the execution, prior to it, as JSON",
+	}
+	PrestateDiffFlag = cli.BoolFlag{
+		Name:  "prestatediff",
+		Usage: "output the state changes instead of the prestate (with --prestate)",
+	}
+	DiffFlag = cli.BoolFlag{
+		Name:  "diff",
+		Usage: "compare the interpreter and the JIT on --code, on the given corpus files or on random code",
+	}
+	ProfileFlag = cli.StringFlag{
+		Name:  "profile",
+		Usage: "output the gas usage per opcode and program counter, writing folded stacks for flame graphs to the given file",
+	}
 	ForceJitFlag = cli.BoolFlag{
 		Name:  "forcejit",
 		Usage: "forces jit compilation",
@@ -90,6 +111,11 @@ func init() {
 	app = utils.NewApp("0.2", "the evm command line interface")
 	app.Flags = []cli.Flag{
 		DebugFlag,
+		CallTraceFlag,
+		PrestateFlag,
+		PrestateDiffFlag,
+		DiffFlag,
+		ProfileFlag,
 		VerbosityFlag,
 		ForceJitFlag,
 		DisableJitFlag,
@@ -102,9 +128,16 @@ func init() {
 		InputFlag,
 	}
 	app.Action = run
+	app.Commands = []cli.Command{
+		transitionCommand,
+	}
 }
 
 func run(ctx *cli.Context) {
+	if ctx.GlobalBool(DiffFlag.Name) {
+		diff(ctx)
+		return
+	}
 	vm.Debug = ctx.GlobalBool(DebugFlag.Name)
 	vm.ForceJit = ctx.GlobalBool(ForceJitFlag.Name)
 	vm.EnableJit = !ctx.GlobalBool(DisableJitFlag.Name)
@@ -118,7 +151,31 @@ func run(ctx *cli.Context) {
 	receiver := statedb.CreateAccount(common.StringToAddress("receiver"))
 	receiver.SetCode(common.Hex2Bytes(ctx.GlobalString(CodeFlag.Name)))
 
-	vmenv := NewEnv(statedb, common.StringToAddress("evmuser"), common.Big(ctx.GlobalString(ValueFlag.Name)))
+	var tracers []vm.Tracer
+	if ctx.GlobalBool(DebugFlag.Name) {
+		tracers = append(tracers, vm.NewStructLogger(nil))
+	}
+	if ctx.GlobalBool(CallTraceFlag.Name) {
+		tracers = append(tracers, vm.NewCallTracer())
+	}
+	if ctx.GlobalBool(PrestateFlag.Name) {
+		prestate := state.NewPrestateTracer(ctx.GlobalBool(PrestateDiffFlag.Name))
+		statedb.SetAccessHook(prestate)
+		tracers = append(tracers, prestate)
+	}
+	if ctx.GlobalString(ProfileFlag.Name) != "" {
+		// The JIT doesn't trace the opcodes it optimises
+		vm.EnableJit = false
+		tracers = append(tracers, NewProfiler())
+	}
+	if len(tracers) > 1 {
+		utils.Fatalf("--%s, --%s, --%s and --%s are mutually exclusive", DebugFlag.Name, CallTraceFlag.Name, PrestateFlag.Name, ProfileFlag.Name)
+	}
+	var tracer vm.Tracer
+	if len(tracers) > 0 {
+		tracer = tracers[0]
+	}
+	vmenv := NewEnv(statedb, common.StringToAddress("evmuser"), common.Big(ctx.GlobalString(ValueFlag.Name)), tracer)
 
 	tstart := time.Now()
 	ret, e := vmenv.Call(
@@ -131,10 +188,41 @@ func run(ctx *cli.Context) {
 	)
 	vmdone := time.Since(tstart)
 
+	statedb.SetAccessHook(nil)
+	if prestate, ok := tracer.(*state.PrestateTracer); ok {
+		prestate.Finalise(statedb)
+	}
 	if ctx.GlobalBool(DumpFlag.Name) {
 		fmt.Println(string(statedb.Dump()))
 	}
-	vm.StdErrFormat(vmenv.StructLogs())
+	switch tracer := tracer.(type) {
+	case *vm.StructLogger:
+		vm.StdErrFormat(tracer.StructLogs())
+	case *vm.CallTracer:
+		calls, err := json.MarshalIndent(tracer.Result(), "", "  ")
+		if err != nil {
+			utils.Fatalf("Failed to encode call tree: %v", err)
+		}
+		fmt.Println(string(calls))
+	case *state.PrestateTracer:
+		accounts, err := json.MarshalIndent(tracer.Result(), "", "  ")
+		if err != nil {
+			utils.Fatalf("Failed to encode state: %v", err)
+		}
+		fmt.Println(string(accounts))
+	case *Profiler:
+		if err := tracer.WriteReport(os.Stdout); err != nil {
+			utils.Fatalf("Failed to write profile: %v", err)
+		}
+		out, err := os.Create(ctx.GlobalString(ProfileFlag.Name))
+		if err != nil {
+			utils.Fatalf("Failed to create folded stack file: %v", err)
+		}
+		if err := tracer.WriteFolded(out); err != nil {
+			utils.Fatalf("Failed to write folded stacks: %v", err)
+		}
+		out.Close()
+	}
 
 	if ctx.GlobalBool(SysStatFlag.Name) {
 		var mem runtime.MemStats
@@ -164,27 +252,31 @@ func main() {
 }
 
 type VMEnv struct {
-	state *state.StateDB
-	block *types.Block
+	ruleSet vm.RuleSet
+	state   *state.StateDB
+	block   *types.Block
 
 	transactor *common.Address
 	value      *big.Int
 
-	depth int
-	Gas   *big.Int
-	time  *big.Int
-	logs  []vm.StructLog
+	depth  int
+	Gas    *big.Int
+	time   *big.Int
+	tracer vm.Tracer
 }
 
-func NewEnv(state *state.StateDB, transactor common.Address, value *big.Int) *VMEnv {
+func NewEnv(state *state.StateDB, transactor common.Address, value *big.Int, tracer vm.Tracer) *VMEnv {
 	return &VMEnv{
+		ruleSet:    core.MainNetChainConfig,
 		state:      state,
 		transactor: &transactor,
 		value:      value,
 		time:       big.NewInt(time.Now().Unix()),
+		tracer:     tracer,
 	}
 }
 
+func (self *VMEnv) RuleSet() vm.RuleSet        { return self.ruleSet }
 func (self *VMEnv) Db() vm.Database            { return self.state }
 func (self *VMEnv) MakeSnapshot() vm.Database  { return self.state.Copy() }
 func (self *VMEnv) SetSnapshot(db vm.Database) { self.state.Set(db.(*state.StateDB)) }
@@ -197,20 +289,15 @@ func (self *VMEnv) BlockHash() []byte          { return make([]byte, 32) }
 func (self *VMEnv) Value() *big.Int            { return self.value }
 func (self *VMEnv) GasLimit() *big.Int         { return big.NewInt(1000000000) }
 func (self *VMEnv) VmType() vm.Type            { return vm.StdVmTy }
-func (self *VMEnv) Depth() int                 { return 0 }
+func (self *VMEnv) Depth() int                 { return self.depth }
 func (self *VMEnv) SetDepth(i int)             { self.depth = i }
+func (self *VMEnv) Tracer() vm.Tracer          { return self.tracer }
 func (self *VMEnv) GetHash(n uint64) common.Hash {
 	if self.block.Number().Cmp(big.NewInt(int64(n))) == 0 {
 		return self.block.Hash()
 	}
 	return common.Hash{}
 }
-func (self *VMEnv) AddStructLog(log vm.StructLog) {
-	self.logs = append(self.logs, log)
-}
-func (self *VMEnv) StructLogs() []vm.StructLog {
-	return self.logs
-}
 func (self *VMEnv) AddLog(log *vm.Log) {
 	self.state.AddLog(log)
 }
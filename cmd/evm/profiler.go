@@ -0,0 +1,277 @@
+// Copyright 2016 The go-vector Authors
+// This file is part of go-vector.
+//
+// go-vector is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-vector is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-vector. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/vector/go-vector/common"
+	"github.com/vector/go-vector/core/vm"
+)
+
+// opStat is the aggregated execution count and gas usage of an opcode.
+type opStat struct {
+	op    vm.OpCode
+	count uint64
+	gas   uint64
+}
+
+// pcKey identifies an instruction of a contract.
+type pcKey struct {
+	addr common.Address
+	pc   uint64
+}
+
+// profiledOp is an executed opcode whose gas usage isn't known until the next
+// opcode of its frame starts or the frame ends.
+type profiledOp struct {
+	pc    uint64
+	op    vm.OpCode
+	gas   uint64 // Gas available before the opcode was charged
+	cost  uint64 // Gas charged up front for the opcode
+	calls uint64 // Gas used by the sub-calls the opcode made
+}
+
+// profiledFrame is a message call or contract creation being profiled.
+type profiledFrame struct {
+	addr    common.Address // Address of the executed code
+	stack   string         // Folded stack of the frame, i.e. the call chain
+	pending *profiledOp    // Last executed opcode of the frame
+	ops     int            // Number of opcodes executed by the frame
+}
+
+// Profiler is a vm.Tracer aggregating the gas usage and the execution count of
+// every opcode, both per opcode and per program counter of each contract. The
+// gas of an opcode only includes the gas it used itself, the gas used by its
+// sub-calls is accounted for by the opcodes of those.
+//
+// Opcodes optimised by the JIT aren't traced individually, the interpreter has
+// to be used to get an accurate profile.
+type Profiler struct {
+	ops    map[vm.OpCode]*opStat
+	pcs    map[pcKey]*opStat
+	folded map[string]uint64 // Gas used per folded stack
+
+	frames []*profiledFrame // Frames entered but not yet exited, innermost last
+}
+
+// NewProfiler returns a new gas profiler.
+func NewProfiler() *Profiler {
+	return &Profiler{
+		ops:    make(map[vm.OpCode]*opStat),
+		pcs:    make(map[pcKey]*opStat),
+		folded: make(map[string]uint64),
+	}
+}
+
+// CaptureStart implements vm.Tracer, entering the outermost frame.
+func (p *Profiler) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas, value *big.Int) {
+	p.frames = []*profiledFrame{{addr: to, stack: to.Hex()}}
+}
+
+// CaptureState implements vm.Tracer, accounting the previous opcode of the
+// frame, whose gas usage is known by now.
+func (p *Profiler) CaptureState(env vm.Environment, pc uint64, op vm.OpCode, gas, cost *big.Int, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) {
+	if len(p.frames) == 0 {
+		return
+	}
+	frame := p.frames[len(p.frames)-1]
+
+	// A failing opcode burns all the remaining gas of the frame. It might have
+	// been charged for already if it failed during its execution.
+	if err != nil {
+		if frame.pending == nil || frame.pending.pc != pc {
+			p.settle(frame, frame.pending.used(gas.Uint64()))
+			frame.pending = &profiledOp{pc: pc, op: op}
+			frame.ops++
+		}
+		p.settle(frame, frame.pending.cost+gas.Uint64())
+		return
+	}
+	available := gas.Uint64() + cost.Uint64()
+	if frame.pending != nil {
+		p.settle(frame, frame.pending.used(available))
+	}
+	frame.pending = &profiledOp{pc: pc, op: op, gas: available, cost: cost.Uint64()}
+	frame.ops++
+}
+
+// CaptureEnter implements vm.Tracer, entering a nested frame.
+func (p *Profiler) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas, value *big.Int) {
+	if len(p.frames) == 0 {
+		return
+	}
+	parent := p.frames[len(p.frames)-1]
+	p.frames = append(p.frames, &profiledFrame{addr: to, stack: parent.stack + ";" + to.Hex()})
+}
+
+// CaptureExit implements vm.Tracer, leaving a nested frame and charging the gas
+// it used to the calling opcode of its parent.
+func (p *Profiler) CaptureExit(output []byte, gasUsed *big.Int, err error) {
+	if len(p.frames) < 2 {
+		return
+	}
+	p.exit(gasUsed.Uint64())
+
+	if parent := p.frames[len(p.frames)-1]; parent.pending != nil {
+		parent.pending.calls += gasUsed.Uint64()
+	}
+}
+
+// CaptureEnd implements vm.Tracer, leaving the outermost frame.
+func (p *Profiler) CaptureEnd(output []byte, gasUsed *big.Int, t time.Duration, err error) {
+	if len(p.frames) == 1 {
+		p.exit(gasUsed.Uint64())
+	}
+	p.frames = nil
+}
+
+// exit leaves the innermost frame. The last opcode of the frame only used the
+// gas charged for it up front. Frames without code, like the precompiled
+// contracts, are accounted as a whole.
+func (p *Profiler) exit(gasUsed uint64) {
+	frame := p.frames[len(p.frames)-1]
+	if frame.pending != nil {
+		p.settle(frame, frame.pending.cost)
+	}
+	if frame.ops == 0 && gasUsed > 0 {
+		p.folded[frame.stack] += gasUsed
+	}
+	p.frames = p.frames[:len(p.frames)-1]
+}
+
+// used returns the gas the opcode used itself, given the gas available to the
+// frame after it executed.
+func (op *profiledOp) used(available uint64) uint64 {
+	if op == nil || op.gas < available+op.calls {
+		return 0
+	}
+	return op.gas - available - op.calls
+}
+
+// settle accounts the pending opcode of a frame with the given gas usage.
+func (p *Profiler) settle(frame *profiledFrame, gas uint64) {
+	op := frame.pending
+	if op == nil {
+		return
+	}
+	frame.pending = nil
+
+	stat := p.ops[op.op]
+	if stat == nil {
+		stat = &opStat{op: op.op}
+		p.ops[op.op] = stat
+	}
+	stat.count++
+	stat.gas += gas
+
+	key := pcKey{frame.addr, op.pc}
+	if stat = p.pcs[key]; stat == nil {
+		stat = &opStat{op: op.op}
+		p.pcs[key] = stat
+	}
+	stat.count++
+	stat.gas += gas
+
+	p.folded[frame.stack+";"+op.op.String()] += gas
+}
+
+// WriteReport writes the per opcode and per program counter profiles as text
+// tables, ordered by descending gas usage.
+func (p *Profiler) WriteReport(w io.Writer) error {
+	ops := make([]*opStat, 0, len(p.ops))
+	for _, stat := range p.ops {
+		ops = append(ops, stat)
+	}
+	sort.Sort(opStatsByGas(ops))
+
+	keys := make([]pcKey, 0, len(p.pcs))
+	for key := range p.pcs {
+		keys = append(keys, key)
+	}
+	sort.Sort(pcKeysByGas{keys, p.pcs})
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "OPCODE\tCOUNT\tGAS\t")
+	for _, stat := range ops {
+		fmt.Fprintf(tw, "%v\t%d\t%d\t\n", stat.op, stat.count, stat.gas)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintln(w)
+
+	tw = tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "CONTRACT\tPC\tOPCODE\tCOUNT\tGAS\t")
+	for _, key := range keys {
+		stat := p.pcs[key]
+		fmt.Fprintf(tw, "%s\t%d\t%v\t%d\t%d\t\n", key.addr.Hex(), key.pc, stat.op, stat.count, stat.gas)
+	}
+	return tw.Flush()
+}
+
+// WriteFolded writes the gas usage per call chain and opcode in the folded
+// stack format understood by flame graph tools, e.g. flamegraph.pl.
+func (p *Profiler) WriteFolded(w io.Writer) error {
+	stacks := make([]string, 0, len(p.folded))
+	for stack := range p.folded {
+		stacks = append(stacks, stack)
+	}
+	sort.Strings(stacks)
+
+	for _, stack := range stacks {
+		if _, err := fmt.Fprintf(w, "%s %d\n", strings.Replace(stack, " ", "_", -1), p.folded[stack]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type opStatsByGas []*opStat
+
+func (s opStatsByGas) Len() int      { return len(s) }
+func (s opStatsByGas) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s opStatsByGas) Less(i, j int) bool {
+	if s[i].gas != s[j].gas {
+		return s[i].gas > s[j].gas
+	}
+	return s[i].op < s[j].op
+}
+
+type pcKeysByGas struct {
+	keys  []pcKey
+	stats map[pcKey]*opStat
+}
+
+func (s pcKeysByGas) Len() int      { return len(s.keys) }
+func (s pcKeysByGas) Swap(i, j int) { s.keys[i], s.keys[j] = s.keys[j], s.keys[i] }
+func (s pcKeysByGas) Less(i, j int) bool {
+	a, b := s.stats[s.keys[i]], s.stats[s.keys[j]]
+	if a.gas != b.gas {
+		return a.gas > b.gas
+	}
+	if s.keys[i].addr != s.keys[j].addr {
+		return s.keys[i].addr.Hex() < s.keys[j].addr.Hex()
+	}
+	return s.keys[i].pc < s.keys[j].pc
+}
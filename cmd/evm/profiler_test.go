@@ -0,0 +1,79 @@
+// Copyright 2016 The go-vector Authors
+// This file is part of go-vector.
+//
+// go-vector is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-vector is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-vector. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/vector/go-vector/common"
+	"github.com/vector/go-vector/core/vm"
+	"github.com/vector/go-vector/core/vm/runtime"
+)
+
+// Tests that the profiler attributes the gas of sub-calls to the called
+// contracts and that the folded stacks add up to the total gas used.
+func TestProfiler(t *testing.T) {
+	// Store 1 into slot 0, then call the identity precompile and stop
+	code := []byte{
+		byte(vm.PUSH1), 1, byte(vm.PUSH1), 0, byte(vm.SSTORE),
+		byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0,
+		byte(vm.PUSH1), 4, byte(vm.PUSH2), 0xff, 0xff, byte(vm.CALL), byte(vm.STOP),
+	}
+	profiler := NewProfiler()
+	gas := big.NewInt(1000000)
+	if _, _, err := runtime.Execute(code, nil, &runtime.Config{GasLimit: gas, DisableJit: true, Tracer: profiler}); err != nil {
+		t.Fatalf("execution failed: %v", err)
+	}
+	contract := common.StringToAddress("contract")
+
+	if stat := profiler.ops[vm.SSTORE]; stat == nil || stat.count != 1 || stat.gas != 20000 {
+		t.Errorf("SSTORE profile mismatch: have %+v, want 1 execution using 20000 gas", stat)
+	}
+	if stat := profiler.ops[vm.PUSH1]; stat == nil || stat.count != 8 || stat.gas != 24 {
+		t.Errorf("PUSH1 profile mismatch: have %+v, want 8 executions using 24 gas", stat)
+	}
+	if stat := profiler.pcs[pcKey{contract, 4}]; stat == nil || stat.op != vm.SSTORE {
+		t.Errorf("program counter profile mismatch: have %+v", stat)
+	}
+	// The gas of the precompile must not be charged to the CALL
+	precompile := contract.Hex() + ";" + common.BytesToAddress([]byte{4}).Hex()
+	if profiler.folded[precompile] == 0 {
+		t.Errorf("precompile gas not profiled")
+	}
+	var total uint64
+	for _, gas := range profiler.folded {
+		total += gas
+	}
+	buf := new(bytes.Buffer)
+	if err := profiler.WriteFolded(buf); err != nil {
+		t.Fatalf("failed to write folded stacks: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != len(profiler.folded) {
+		t.Errorf("folded stack count mismatch: have %d, want %d", len(lines), len(profiler.folded))
+	}
+	// Execute once more without profiling to learn the gas used
+	gas = big.NewInt(1000000)
+	if _, _, err := runtime.Execute(code, nil, &runtime.Config{GasLimit: gas, DisableJit: true}); err != nil {
+		t.Fatalf("execution failed: %v", err)
+	}
+	if used := 1000000 - gas.Uint64(); total != used {
+		t.Errorf("profiled gas mismatch: have %d, want %d", total, used)
+	}
+}
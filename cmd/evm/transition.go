@@ -0,0 +1,257 @@
+// Copyright 2016 The go-vector Authors
+// This file is part of go-vector.
+//
+// go-vector is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-vector is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-vector. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+
+	"github.com/codegangsta/cli"
+	"github.com/vector/go-vector/cmd/utils"
+	"github.com/vector/go-vector/common"
+	"github.com/vector/go-vector/core"
+	"github.com/vector/go-vector/core/state"
+	"github.com/vector/go-vector/core/types"
+	"github.com/vector/go-vector/core/vm"
+	"github.com/vector/go-vector/rlp"
+	"github.com/vector/go-vector/vecdb"
+)
+
+var (
+	AllocFlag = cli.StringFlag{
+		Name:  "alloc",
+		Usage: "JSON file with the prestate allocation",
+	}
+	EnvFlag = cli.StringFlag{
+		Name:  "env",
+		Usage: "JSON file with the block environment",
+	}
+	TxsFlag = cli.StringFlag{
+		Name:  "txs",
+		Usage: "JSON file with the list of hex encoded RLP signed transactions",
+	}
+	RewardFlag = cli.BoolFlag{
+		Name:  "reward",
+		Usage: "credit the coinbase with the block reward after the transactions",
+	}
+
+	transitionCommand = cli.Command{
+		Action: transition,
+		Name:   "transition",
+		Usage:  "apply transactions to a prestate and output the poststate",
+		Description: `
+The transition command loads a prestate allocation and a block environment,
+applies the given signed transactions to it using the consensus rules and
+outputs the state root, the receipts, the logs bloom and the poststate as JSON.
+
+The allocation maps addresses to accounts with the fields balance, nonce, code
+and storage. The environment is given with the keys of the state tests, i.e.
+currentCoinbase, currentNumber, currentDifficulty, currentTimestamp,
+currentGasLimit and previousHash, plus an optional chain config. Transactions
+failing the consensus checks (e.g. a bad nonce) are rejected and reported.
+`,
+		Flags: []cli.Flag{
+			AllocFlag,
+			EnvFlag,
+			TxsFlag,
+			RewardFlag,
+		},
+	}
+)
+
+// transitionAccount is the JSON specification of an account of the prestate.
+type transitionAccount struct {
+	Balance string            `json:"balance"`
+	Nonce   string            `json:"nonce"`
+	Code    string            `json:"code"`
+	Storage map[string]string `json:"storage"`
+}
+
+// transitionEnv is the JSON specification of the block the transactions are
+// applied in.
+type transitionEnv struct {
+	Config     *core.ChainConfig `json:"config"`
+	Coinbase   string            `json:"currentCoinbase"`
+	Number     string            `json:"currentNumber"`
+	Difficulty string            `json:"currentDifficulty"`
+	Timestamp  string            `json:"currentTimestamp"`
+	GasLimit   string            `json:"currentGasLimit"`
+	ParentHash string            `json:"previousHash"`
+}
+
+// rejectedTx is a transaction that failed the consensus checks.
+type rejectedTx struct {
+	Index int    `json:"index"`
+	Hash  string `json:"hash"`
+	Error string `json:"error"`
+}
+
+// transitionResult is the outcome of a state transition.
+type transitionResult struct {
+	StateRoot string                   `json:"stateRoot"`
+	LogsBloom string                   `json:"logsBloom"`
+	GasUsed   string                   `json:"gasUsed"`
+	Receipts  []map[string]interface{} `json:"receipts"`
+	Rejected  []rejectedTx             `json:"rejected"`
+	Alloc     map[string]state.Account `json:"alloc"`
+}
+
+func transition(ctx *cli.Context) {
+	var (
+		alloc map[string]transitionAccount
+		env   transitionEnv
+		raw   []string
+	)
+	for _, file := range []struct {
+		flag string
+		v    interface{}
+	}{{AllocFlag.Name, &alloc}, {EnvFlag.Name, &env}, {TxsFlag.Name, &raw}} {
+		path := ctx.String(file.flag)
+		if path == "" {
+			utils.Fatalf("--%s is required", file.flag)
+		}
+		blob, err := ioutil.ReadFile(path)
+		if err != nil {
+			utils.Fatalf("Failed to read %s: %v", path, err)
+		}
+		if err := json.Unmarshal(blob, file.v); err != nil {
+			utils.Fatalf("Failed to decode %s: %v", path, err)
+		}
+	}
+	txs := make([]*types.Transaction, len(raw))
+	for i, hex := range raw {
+		txs[i] = new(types.Transaction)
+		if err := rlp.DecodeBytes(common.FromHex(hex), txs[i]); err != nil {
+			utils.Fatalf("Failed to decode transaction %d: %v", i, err)
+		}
+	}
+	result, err := applyTransition(alloc, &env, txs, ctx.Bool(RewardFlag.Name))
+	if err != nil {
+		utils.Fatalf("State transition failed: %v", err)
+	}
+	out, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		utils.Fatalf("Failed to encode result: %v", err)
+	}
+	fmt.Println(string(out))
+}
+
+// applyTransition applies the transactions to the prestate allocation within
+// the given block environment, as the block processor would, except that
+// transactions failing the consensus checks are skipped instead of
+// invalidating the block. Without a chain, BLOCKHASH only knows the parent.
+func applyTransition(alloc map[string]transitionAccount, env *transitionEnv, txs []*types.Transaction, reward bool) (*transitionResult, error) {
+	db, _ := vecdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, db)
+	for addr, account := range alloc {
+		address := common.HexToAddress(addr)
+		statedb.AddBalance(address, common.String2Big(account.Balance))
+		statedb.SetNonce(address, common.String2Big(account.Nonce).Uint64())
+		statedb.SetCode(address, common.FromHex(account.Code))
+		for key, value := range account.Storage {
+			statedb.SetState(address, common.HexToHash(key), common.HexToHash(value))
+		}
+	}
+	config := env.Config
+	if config == nil {
+		config = core.MainNetChainConfig
+	}
+	header := &types.Header{
+		ParentHash: common.HexToHash(env.ParentHash),
+		Coinbase:   common.HexToAddress(env.Coinbase),
+		Number:     common.String2Big(env.Number),
+		Difficulty: common.String2Big(env.Difficulty),
+		Time:       common.String2Big(env.Timestamp),
+		GasLimit:   common.String2Big(env.GasLimit),
+	}
+	var (
+		gp       = new(core.GasPool).AddGas(header.GasLimit)
+		usedGas  = new(big.Int)
+		receipts types.Receipts
+		result   = &transitionResult{
+			Receipts: []map[string]interface{}{},
+			Rejected: []rejectedTx{},
+		}
+	)
+	for i, tx := range txs {
+		// Revert the side effects of rejected transactions, e.g. creating their sender
+		snapshot := statedb.Copy()
+		statedb.StartRecord(tx.Hash(), common.Hash{}, len(receipts))
+		receipt, _, _, err := core.ApplyTransaction(config, nil, gp, statedb, header, tx, usedGas)
+		if err != nil {
+			statedb.Set(snapshot)
+			result.Rejected = append(result.Rejected, rejectedTx{Index: i, Hash: tx.Hash().Hex(), Error: err.Error()})
+			continue
+		}
+		receipts = append(receipts, receipt)
+		result.Receipts = append(result.Receipts, newReceiptResult(receipt))
+	}
+	if reward {
+		core.AccumulateRewards(statedb, header, nil)
+	}
+	root, err := statedb.Commit()
+	if err != nil {
+		return nil, err
+	}
+	poststate, err := state.New(root, db)
+	if err != nil {
+		return nil, err
+	}
+	result.StateRoot = root.Hex()
+	result.GasUsed = fmt.Sprintf("0x%x", usedGas)
+	result.LogsBloom = fmt.Sprintf("0x%x", types.CreateBloom(receipts).Bytes())
+	result.Alloc = poststate.RawDump().Accounts
+
+	return result, nil
+}
+
+// newReceiptResult converts a receipt into its JSON representation.
+func newReceiptResult(receipt *types.Receipt) map[string]interface{} {
+	logs := make([]map[string]interface{}, len(receipt.Logs))
+	for i, log := range receipt.Logs {
+		logs[i] = newLogResult(log)
+	}
+	result := map[string]interface{}{
+		"transactionHash":   receipt.TxHash.Hex(),
+		"root":              fmt.Sprintf("0x%x", receipt.PostState),
+		"cumulativeGasUsed": fmt.Sprintf("0x%x", receipt.CumulativeGasUsed),
+		"gasUsed":           fmt.Sprintf("0x%x", receipt.GasUsed),
+		"logsBloom":         fmt.Sprintf("0x%x", receipt.Bloom.Bytes()),
+		"logs":              logs,
+	}
+	if (receipt.ContractAddress != common.Address{}) {
+		result["contractAddress"] = receipt.ContractAddress.Hex()
+	}
+	return result
+}
+
+// newLogResult converts a log into its JSON representation.
+func newLogResult(log *vm.Log) map[string]interface{} {
+	topics := make([]string, len(log.Topics))
+	for i, topic := range log.Topics {
+		topics[i] = topic.Hex()
+	}
+	return map[string]interface{}{
+		"address":          log.Address.Hex(),
+		"topics":           topics,
+		"data":             fmt.Sprintf("0x%x", log.Data),
+		"transactionIndex": log.TxIndex,
+		"logIndex":         log.Index,
+	}
+}
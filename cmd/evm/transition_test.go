@@ -0,0 +1,122 @@
+// Copyright 2016 The go-vector Authors
+// This file is part of go-vector.
+//
+// go-vector is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-vector is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-vector. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/vector/go-vector/common"
+	"github.com/vector/go-vector/core/types"
+	"github.com/vector/go-vector/core/vm"
+	"github.com/vector/go-vector/crypto"
+)
+
+// Tests that transactions are applied on top of the prestate, and that the
+// ones failing the consensus checks are rejected.
+func TestTransition(t *testing.T) {
+	key, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	contract := common.HexToAddress("0xc0de00000000000000000000000000000000c0de")
+
+	// Store 1 into slot 0, then emit an empty log and stop
+	code := []byte{
+		byte(vm.PUSH1), 1, byte(vm.PUSH1), 0, byte(vm.SSTORE),
+		byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.LOG0), byte(vm.STOP),
+	}
+	alloc := map[string]transitionAccount{
+		sender.Hex():   {Balance: "1000000000000000000"},
+		contract.Hex(): {Balance: "0", Code: fmt.Sprintf("0x%x", code)},
+	}
+	env := &transitionEnv{
+		Coinbase:   "0x00000000000000000000000000000000000000cb",
+		Number:     "1",
+		Difficulty: "131072",
+		Timestamp:  "1000",
+		GasLimit:   "3141592",
+	}
+	valid, _ := types.NewTransaction(0, contract, big.NewInt(1), big.NewInt(100000), big.NewInt(1), nil).SignECDSA(key)
+	replayed, _ := types.NewTransaction(0, contract, big.NewInt(1), big.NewInt(100000), big.NewInt(1), nil).SignECDSA(key)
+
+	result, err := applyTransition(alloc, env, []*types.Transaction{valid, replayed}, false)
+	if err != nil {
+		t.Fatalf("transition failed: %v", err)
+	}
+	if len(result.Receipts) != 1 {
+		t.Fatalf("receipt count mismatch: have %d, want 1", len(result.Receipts))
+	}
+	if len(result.Rejected) != 1 || result.Rejected[0].Index != 1 {
+		t.Fatalf("rejected transactions mismatch: have %+v, want the second one", result.Rejected)
+	}
+	if root := result.Receipts[0]["root"]; root != result.StateRoot {
+		t.Errorf("state root mismatch: have %v, receipt has %v", result.StateRoot, root)
+	}
+	if logs := result.Receipts[0]["logs"].([]map[string]interface{}); len(logs) != 1 || logs[0]["address"] != contract.Hex() {
+		t.Errorf("logs mismatch: have %v", logs)
+	}
+	if bloom := types.BytesToBloom(common.FromHex(result.LogsBloom)); !bloom.TestBytes(contract.Bytes()) {
+		t.Errorf("logs bloom doesn't contain the contract address")
+	}
+	account, ok := result.Alloc[common.Bytes2Hex(contract.Bytes())]
+	if !ok {
+		t.Fatalf("contract missing from the poststate")
+	}
+	if account.Balance != "1" || len(account.Storage) != 1 {
+		t.Errorf("contract poststate mismatch: have balance %s and %d storage slots, want 1 and 1", account.Balance, len(account.Storage))
+	}
+	if account := result.Alloc[common.Bytes2Hex(sender.Bytes())]; account.Nonce != 1 {
+		t.Errorf("sender nonce mismatch: have %d, want 1", account.Nonce)
+	}
+}
+
+// Tests that rejected transactions leave no trace in the poststate, not even
+// their unknown sender.
+func TestTransitionRejectedSender(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+
+	alloc := map[string]transitionAccount{
+		"0x00000000000000000000000000000000000000aa": {Balance: "1"},
+	}
+	env := &transitionEnv{
+		Coinbase:   "0x00000000000000000000000000000000000000cb",
+		Number:     "1",
+		Difficulty: "131072",
+		Timestamp:  "1000",
+		GasLimit:   "3141592",
+	}
+	unfunded, _ := types.NewTransaction(0, common.Address{}, big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil).SignECDSA(key)
+
+	result, err := applyTransition(alloc, env, []*types.Transaction{unfunded}, false)
+	if err != nil {
+		t.Fatalf("transition failed: %v", err)
+	}
+	if len(result.Rejected) != 1 {
+		t.Fatalf("rejected transactions mismatch: have %+v, want the unfunded one", result.Rejected)
+	}
+	if _, ok := result.Alloc[common.Bytes2Hex(sender.Bytes())]; ok {
+		t.Errorf("rejected sender created in the poststate")
+	}
+	empty, err := applyTransition(alloc, env, nil, false)
+	if err != nil {
+		t.Fatalf("empty transition failed: %v", err)
+	}
+	if result.StateRoot != empty.StateRoot {
+		t.Errorf("state root mismatch: have %v, want %v", result.StateRoot, empty.StateRoot)
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2016 The go-vector Authors
+// This file is part of go-vector.
+//
+// go-vector is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-vector is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-vector. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/codegangsta/cli"
+	"github.com/vector/go-vector/cmd/utils"
+	"github.com/vector/go-vector/common"
+	"github.com/vector/go-vector/core"
+	"github.com/vector/go-vector/core/state"
+	"github.com/vector/go-vector/core/vm"
+)
+
+var badBlocksCommand = cli.Command{
+	Name:  "badblocks",
+	Usage: "Inspect and replay the blocks rejected during import",
+	Description: `
+The most recently rejected blocks are retained in the chain database together
+with the peer they were received from and the reason of their rejection.
+`,
+	Subcommands: []cli.Command{
+		{
+			Action: listBadBlocks,
+			Name:   "list",
+			Usage:  "List the retained bad blocks, most recent first",
+		},
+		{
+			Action: replayBadBlock,
+			Name:   "replay",
+			Usage:  "Reprocess a bad block on top of its parent with VM tracing",
+			Description: `
+The argument is interpreted as the index of the bad block in the list or as
+its hash. The execution trace of every transaction is printed to stderr.
+`,
+		},
+	},
+}
+
+// listBadBlocks prints a summary of the rejected blocks in the chain database.
+func listBadBlocks(ctx *cli.Context) {
+	db := utils.MakeChainDatabase(ctx)
+	defer db.Close()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "INDEX\tNUMBER\tHASH\tORIGIN\tTIME\tERROR")
+	for i, bad := range core.GetBadBlocks(db) {
+		origin := bad.Origin
+		if origin == "" {
+			origin = "unknown"
+		}
+		fmt.Fprintf(w, "%d\t%v\t%x\t%s\t%v\t%s\n", i, bad.Block.Number(), bad.Block.Hash(), origin, time.Unix(int64(bad.Time), 0), bad.Error)
+	}
+	w.Flush()
+}
+
+// replayBadBlock validates and processes a rejected block again, tracing the
+// execution of all its transactions.
+func replayBadBlock(ctx *cli.Context) {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	chain, chainDb := utils.MakeChain(ctx)
+	defer chainDb.Close()
+
+	// Look up the requested bad block by index or hash
+	var (
+		arg = ctx.Args().First()
+		bad *core.BadBlock
+	)
+	blocks := core.GetBadBlocks(chainDb)
+	if index, err := strconv.Atoi(arg); err == nil && len(arg) < 2*len(common.Hash{}) {
+		if index < 0 || index >= len(blocks) {
+			utils.Fatalf("Bad block index %d out of range, %d retained", index, len(blocks))
+		}
+		bad = blocks[index]
+	} else {
+		hash := common.HexToHash(arg)
+		for _, entry := range blocks {
+			if entry.Block.Hash() == hash {
+				bad = entry
+				break
+			}
+		}
+		if bad == nil {
+			utils.Fatalf("Bad block %x not found", hash)
+		}
+	}
+	block := bad.Block
+	fmt.Printf("Replaying block #%v [%x], originally rejected with: %s\n", block.Number(), block.Hash(), bad.Error)
+
+	parent := chain.GetBlock(block.ParentHash())
+	if parent == nil {
+		utils.Fatalf("Parent block %x not available", block.ParentHash())
+	}
+	statedb, err := state.New(parent.Root(), chainDb)
+	if err != nil {
+		utils.Fatalf("Parent state %x not available: %v", parent.Root(), err)
+	}
+	// Validate the block, but execute it regardless to trace the transactions
+	if err := chain.Validator().ValidateBlock(block); err != nil {
+		fmt.Printf("Block validation failed: %v\n", err)
+	}
+	vm.Debug = true
+	receipts, _, usedGas, err := chain.Processor().Process(block, statedb)
+	vm.Debug = false
+	if err != nil {
+		utils.Fatalf("Block processing failed: %v", err)
+	}
+	if err := chain.Validator().ValidateState(block, parent, statedb, receipts, usedGas); err != nil {
+		utils.Fatalf("State validation failed: %v", err)
+	}
+	fmt.Println("Block replayed successfully")
+}
@@ -30,10 +30,32 @@ import (
 	"github.com/vector/go-vector/core/state"
 	"github.com/vector/go-vector/core/types"
 	"github.com/vector/go-vector/vecdb"
+	"github.com/vector/go-vector/logger"
 	"github.com/vector/go-vector/logger/glog"
 )
 
 var (
+	initCommand = cli.Command{
+		Action: initGenesis,
+		Name:   "init",
+		Usage:  "Bootstrap and initialize a new genesis block",
+		Description: `
+The init command initializes a new genesis block and the chain configuration
+it defines into the data directory. It requires the path of a genesis JSON file
+as its argument and refuses to touch a data directory holding a different
+genesis block.
+`,
+	}
+	dumpGenesisCommand = cli.Command{
+		Action: dumpGenesis,
+		Name:   "dumpgenesis",
+		Usage:  "Dump the genesis block JSON configuration to stdout",
+		Description: `
+The dumpgenesis command prints the genesis block stored in the data directory,
+together with its chain configuration and allocations, in the JSON format
+accepted by the init command.
+`,
+	}
 	importCommand = cli.Command{
 		Action: importChain,
 		Name:   "import",
@@ -71,6 +93,40 @@ Use "vector dump 0" to dump the genesis block.
 	}
 )
 
+// initGenesis writes the genesis block and chain configuration of a JSON
+// genesis specification into a fresh data directory.
+func initGenesis(ctx *cli.Context) {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires the path of a genesis file as its argument.")
+	}
+	file, err := os.Open(ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("Failed to read genesis file: %v", err)
+	}
+	defer file.Close()
+
+	chainDb := utils.MakeChainDatabase(ctx)
+	defer chainDb.Close()
+
+	block, err := core.InitGenesisBlock(chainDb, file)
+	if err != nil {
+		utils.Fatalf("Failed to write genesis block: %v", err)
+	}
+	glog.V(logger.Info).Infof("Successfully wrote genesis block and chain config: %x", block.Hash())
+}
+
+// dumpGenesis prints the JSON specification of the stored genesis block.
+func dumpGenesis(ctx *cli.Context) {
+	chainDb := utils.MakeChainDatabase(ctx)
+	defer chainDb.Close()
+
+	genesis, err := core.DumpGenesisBlock(chainDb)
+	if err != nil {
+		utils.Fatalf("Failed to dump genesis block: %v", err)
+	}
+	fmt.Println(string(genesis))
+}
+
 func importChain(ctx *cli.Context) {
 	if len(ctx.Args()) != 1 {
 		utils.Fatalf("This command requires an argument.")
@@ -78,6 +134,7 @@ func importChain(ctx *cli.Context) {
 	chain, chainDb := utils.MakeChain(ctx)
 	start := time.Now()
 	err := utils.ImportChain(chain, ctx.Args().First())
+	chain.Stop()
 	chainDb.Close()
 	if err != nil {
 		utils.Fatalf("Import error: %v", err)
@@ -126,6 +183,7 @@ func removeDB(ctx *cli.Context) {
 		start := time.Now()
 
 		os.RemoveAll(filepath.Join(ctx.GlobalString(utils.DataDirFlag.Name), "chaindata"))
+		os.RemoveAll(filepath.Join(ctx.GlobalString(utils.DataDirFlag.Name), "ancient"))
 
 		fmt.Printf("Removed in %v\n", time.Since(start))
 	} else {
@@ -151,6 +209,7 @@ func upgradeDB(ctx *cli.Context) {
 	}
 	chainDb.Close()
 	os.RemoveAll(filepath.Join(ctx.GlobalString(utils.DataDirFlag.Name), "chaindata"))
+	os.RemoveAll(filepath.Join(ctx.GlobalString(utils.DataDirFlag.Name), "ancient"))
 
 	// Import the chain file.
 	chain, chainDb = utils.MakeChain(ctx)
@@ -0,0 +1,274 @@
+// Copyright 2016 The go-vector Authors
+// This file is part of go-vector.
+//
+// go-vector is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-vector is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-vector. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/codegangsta/cli"
+	"github.com/vector/go-vector/cmd/utils"
+	"github.com/vector/go-vector/common"
+	"github.com/vector/go-vector/core"
+	"github.com/vector/go-vector/core/types"
+	"github.com/vector/go-vector/vecdb"
+)
+
+var dbCommand = cli.Command{
+	Name:  "db",
+	Usage: "Low level chain database operations",
+	Description: `
+The db commands give raw access to the chain database for inspecting, verifying
+and repairing it. The node must not be running while they are executed. Blocks
+are never moved into the ancient store by these commands, whatever the
+--ancientdepth setting.
+`,
+	Subcommands: []cli.Command{
+		{
+			Action: inspectDB,
+			Name:   "inspect",
+			Usage:  "Report the number and size of the entries for each kind of data",
+		},
+		{
+			Action: verifyDB,
+			Name:   "verify",
+			Usage:  "Check the integrity of the canonical chain",
+			Description: `
+Walks the canonical chain from the genesis block to the current head, checking
+that every header, body, receipt set and total difficulty is present and
+consistent with its predecessor, and reporting any state roots not available.
+`,
+		},
+		{
+			Action: getDB,
+			Name:   "get",
+			Usage:  "Print the value stored at a hex encoded key",
+		},
+		{
+			Action: putDB,
+			Name:   "put",
+			Usage:  "Store a hex encoded value at a hex encoded key",
+		},
+		{
+			Action: deleteDB,
+			Name:   "delete",
+			Usage:  "Remove the entry stored at a hex encoded key",
+		},
+		{
+			Action: compactDB,
+			Name:   "compact",
+			Usage:  "Compact the entire chain database",
+		},
+	},
+}
+
+// inspectDB iterates over the whole chain database and prints the entry count
+// and storage size of each data category.
+func inspectDB(ctx *cli.Context) {
+	db := utils.MakeChainDatabaseNoFreezing(ctx)
+	defer db.Close()
+
+	start := time.Now()
+	stats, err := core.InspectDatabase(db)
+	if err != nil {
+		utils.Fatalf("Inspection failed: %v", err)
+	}
+	var (
+		count uint64
+		size  common.StorageSize
+	)
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "CATEGORY\tCOUNT\tSIZE")
+	for _, stat := range stats {
+		fmt.Fprintf(w, "%s\t%d\t%v\n", stat.Name, stat.Count, stat.Size)
+		count += stat.Count
+		size += stat.Size
+	}
+	fmt.Fprintf(w, "Total\t%d\t%v\n", count, size)
+	w.Flush()
+
+	fmt.Printf("Inspection done in %v\n", time.Since(start))
+}
+
+// verifyDB walks the canonical chain and checks the consistency of all the
+// data stored for each block.
+func verifyDB(ctx *cli.Context) {
+	db := utils.MakeChainDatabaseNoFreezing(ctx)
+
+	head := core.GetHeader(db, core.GetHeadBlockHash(db))
+	if head == nil {
+		db.Close()
+		utils.Fatalf("Head block not found")
+	}
+	var (
+		start     = time.Now()
+		failures  int
+		stateless int
+		parent    *types.Header
+		parentTd  *big.Int
+	)
+	fail := func(number uint64, format string, args ...interface{}) {
+		fmt.Printf("block #%d: %s\n", number, fmt.Sprintf(format, args...))
+		failures++
+	}
+	for number := uint64(0); number <= head.Number.Uint64(); number++ {
+		hash := core.GetCanonicalHash(db, number)
+		if (hash == common.Hash{}) {
+			fail(number, "canonical hash missing")
+			parent, parentTd = nil, nil
+			continue
+		}
+		// Check the header and its linkage to the parent
+		header := core.GetHeader(db, hash)
+		if header == nil {
+			fail(number, "header %x missing", hash)
+			parent, parentTd = nil, nil
+			continue
+		}
+		if header.Hash() != hash {
+			fail(number, "header hash mismatch: have %x, want %x", header.Hash(), hash)
+		}
+		if header.Number.Uint64() != number {
+			fail(number, "header number mismatch: have %v", header.Number)
+		}
+		if parent != nil && header.ParentHash != parent.Hash() {
+			fail(number, "parent hash mismatch: have %x, want %x", header.ParentHash, parent.Hash())
+		}
+		// Check the body against the header
+		if body := core.GetBody(db, hash); body == nil {
+			fail(number, "body missing")
+		} else {
+			if root := types.DeriveSha(types.Transactions(body.Transactions)); root != header.TxHash {
+				fail(number, "transaction root mismatch: have %x, want %x", root, header.TxHash)
+			}
+			if uncles := types.CalcUncleHash(body.Uncles); uncles != header.UncleHash {
+				fail(number, "uncle hash mismatch: have %x, want %x", uncles, header.UncleHash)
+			}
+		}
+		// Check the receipts against the header (empty blocks have none stored)
+		receipts := core.GetBlockReceipts(db, hash)
+		if root := types.DeriveSha(receipts); root != header.ReceiptHash {
+			if receipts == nil {
+				fail(number, "receipts missing")
+			} else {
+				fail(number, "receipt root mismatch: have %x, want %x", root, header.ReceiptHash)
+			}
+		}
+		// Check the total difficulty against the parent's
+		td := core.GetTd(db, hash)
+		if td == nil {
+			fail(number, "total difficulty missing")
+		} else if parentTd != nil {
+			if want := new(big.Int).Add(parentTd, header.Difficulty); td.Cmp(want) != 0 {
+				fail(number, "total difficulty mismatch: have %v, want %v", td, want)
+			}
+		}
+		// Check state availability, only the head state is mandatory
+		if blob, _ := db.Get(header.Root[:]); len(blob) == 0 {
+			if number == head.Number.Uint64() {
+				fail(number, "head state root %x missing", header.Root)
+			}
+			stateless++
+		}
+		parent, parentTd = header, td
+
+		if number%10000 == 0 && number > 0 {
+			fmt.Printf("verified %d blocks, %d failures\n", number, failures)
+		}
+	}
+	db.Close()
+
+	fmt.Printf("Verified %d blocks in %v, %d without state\n", head.Number.Uint64()+1, time.Since(start), stateless)
+	if failures > 0 {
+		utils.Fatalf("Verification failed with %d errors", failures)
+	}
+}
+
+// parseHexArgs decodes the required number of hex encoded command arguments.
+func parseHexArgs(ctx *cli.Context, names ...string) [][]byte {
+	if len(ctx.Args()) != len(names) {
+		utils.Fatalf("This command requires %d arguments: %v", len(names), names)
+	}
+	args := make([][]byte, len(names))
+	for i, arg := range ctx.Args() {
+		blob, err := hex.DecodeString(strings.TrimPrefix(arg, "0x"))
+		if err != nil {
+			utils.Fatalf("Invalid hex %s %q: %v", names[i], arg, err)
+		}
+		args[i] = blob
+	}
+	return args
+}
+
+// getDB prints the value stored at a raw database key.
+func getDB(ctx *cli.Context) {
+	args := parseHexArgs(ctx, "key")
+
+	db := utils.MakeChainDatabaseNoFreezing(ctx)
+	defer db.Close()
+
+	value, err := db.Get(args[0])
+	if err != nil {
+		utils.Fatalf("Failed to retrieve %x: %v", args[0], err)
+	}
+	fmt.Printf("%x\n", value)
+}
+
+// putDB stores a value at a raw database key.
+func putDB(ctx *cli.Context) {
+	args := parseHexArgs(ctx, "key", "value")
+
+	db := utils.MakeChainDatabaseNoFreezing(ctx)
+	defer db.Close()
+
+	if err := db.Put(args[0], args[1]); err != nil {
+		utils.Fatalf("Failed to store %x: %v", args[0], err)
+	}
+}
+
+// deleteDB removes a raw database key.
+func deleteDB(ctx *cli.Context) {
+	args := parseHexArgs(ctx, "key")
+
+	db := utils.MakeChainDatabaseNoFreezing(ctx)
+	defer db.Close()
+
+	if err := db.Delete(args[0]); err != nil {
+		utils.Fatalf("Failed to delete %x: %v", args[0], err)
+	}
+}
+
+// compactDB flattens the entire LevelDB chain database.
+func compactDB(ctx *cli.Context) {
+	db, err := vecdb.NewLDBDatabase(filepath.Join(utils.MustDataDir(ctx), "chaindata"), ctx.GlobalInt(utils.CacheFlag.Name))
+	if err != nil {
+		utils.Fatalf("Could not open database: %v", err)
+	}
+	defer db.Close()
+
+	start := time.Now()
+	if err := db.Compact(nil, nil); err != nil {
+		utils.Fatalf("Compaction failed: %v", err)
+	}
+	fmt.Printf("Compaction done in %v\n", time.Since(start))
+}
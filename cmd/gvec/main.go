@@ -84,12 +84,17 @@ recover <hex> recovers by hash
 `,
 		},
 		blocktestCommand,
+		initCommand,
+		dumpGenesisCommand,
 		importCommand,
 		exportCommand,
 		upgradedbCommand,
 		removedbCommand,
 		dumpCommand,
+		dbCommand,
+		badBlocksCommand,
 		monitorCommand,
+		replayCommand,
 		{
 			Action: makedag,
 			Name:   "makedag",
@@ -305,6 +310,9 @@ JavaScript API. See https://github.com/vectordev1/go-vector/wiki/Javascipt-Conso
 		utils.OlympicFlag,
 		utils.FastSyncFlag,
 		utils.CacheFlag,
+		utils.AncientDepthFlag,
+		utils.GCModeFlag,
+		utils.AddrTxIndexFlag,
 		utils.LightKDFFlag,
 		utils.JSpathFlag,
 		utils.ListenPortFlag,
@@ -312,6 +320,14 @@ JavaScript API. See https://github.com/vectordev1/go-vector/wiki/Javascipt-Conso
 		utils.MaxPendingPeersFlag,
 		utils.VecbaseFlag,
 		utils.GasPriceFlag,
+		utils.TxPoolAccountSlotsFlag,
+		utils.TxPoolGlobalSlotsFlag,
+		utils.TxPoolAccountQueueFlag,
+		utils.TxPoolGlobalQueueFlag,
+		utils.TxPoolPriceBumpFlag,
+		utils.TxPoolJournalFlag,
+		utils.TxPoolRejournalFlag,
+		utils.TxPoolLifetimeFlag,
 		utils.MinerThreadsFlag,
 		utils.MiningEnabledFlag,
 		utils.MiningGPUFlag,
@@ -0,0 +1,118 @@
+// Copyright 2016 The go-vector Authors
+// This file is part of go-vector.
+//
+// go-vector is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-vector is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-vector. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime/pprof"
+	"time"
+
+	"github.com/codegangsta/cli"
+	"github.com/vector/go-vector/cmd/utils"
+	"github.com/vector/go-vector/core"
+)
+
+var (
+	replayCommandFromFlag = cli.IntFlag{
+		Name:  "from",
+		Value: 1,
+		Usage: "Number of the first block to replay",
+	}
+	replayCommandToFlag = cli.IntFlag{
+		Name:  "to",
+		Value: -1,
+		Usage: "Number of the last block to replay (-1 = current head)",
+	}
+	replayCommandCPUProfileFlag = cli.StringFlag{
+		Name:  "cpuprofile",
+		Usage: "Write a CPU profile of the replay to the given file",
+	}
+	replayCommand = cli.Command{
+		Action: replay,
+		Name:   "replay",
+		Usage:  "Re-execute stored canonical blocks and report timings",
+		Description: `
+The replay command re-executes a range of canonical blocks from the local
+database on top of their parent states, verifying the resulting state and
+receipt roots. Nothing is written to the database. For every block the time
+spent on sender recovery, EVM execution, trie hashing and state commit is
+reported, allowing VM and trie changes to be benchmarked on real data.
+
+The parent states of the replayed blocks must be available, so blocks older
+than the recent ones retained by --gcmode full need an archive node.
+`,
+		Flags: []cli.Flag{
+			replayCommandFromFlag,
+			replayCommandToFlag,
+			replayCommandCPUProfileFlag,
+		},
+	}
+)
+
+// replay re-executes the requested range of canonical blocks, failing on the
+// first one whose outcome diverges from the stored header.
+func replay(ctx *cli.Context) {
+	chain, chainDb := utils.MakeChain(ctx)
+	defer chainDb.Close()
+	defer chain.Stop()
+
+	from, to := ctx.Int(replayCommandFromFlag.Name), ctx.Int(replayCommandToFlag.Name)
+	if to < 0 {
+		to = int(chain.CurrentBlock().NumberU64())
+	}
+	if from < 1 || from > to {
+		utils.Fatalf("Invalid block range #%d - #%d", from, to)
+	}
+	if path := ctx.String(replayCommandCPUProfileFlag.Name); path != "" {
+		file, err := os.Create(path)
+		if err != nil {
+			utils.Fatalf("Failed to create CPU profile: %v", err)
+		}
+		defer file.Close()
+
+		if err := pprof.StartCPUProfile(file); err != nil {
+			utils.Fatalf("Failed to start CPU profile: %v", err)
+		}
+		defer pprof.StopCPUProfile()
+	}
+	var (
+		total core.ReplayStats
+		txs   int
+		start = time.Now()
+	)
+	for number := from; number <= to; number++ {
+		block := chain.GetBlockByNumber(uint64(number))
+		if block == nil {
+			utils.Fatalf("Block #%d not found", number)
+		}
+		stats, err := chain.ReplayBlock(block)
+		if err != nil {
+			utils.Fatalf("Block #%d [%x…] diverged: %v", number, block.Hash().Bytes()[:4], err)
+		}
+		fmt.Printf("#%d [%x…] txs=%d senders=%v execution=%v hashing=%v commit=%v total=%v\n",
+			number, block.Hash().Bytes()[:4], len(block.Transactions()), stats.Senders, stats.Execution, stats.Hashing, stats.Commit, stats.Total())
+
+		total.Senders += stats.Senders
+		total.Execution += stats.Execution
+		total.Hashing += stats.Hashing
+		total.Commit += stats.Commit
+		txs += len(block.Transactions())
+	}
+	fmt.Printf("Replayed %d blocks (%d txs) in %v: senders=%v execution=%v hashing=%v commit=%v\n",
+		to-from+1, txs, time.Since(start), total.Senders, total.Execution, total.Hashing, total.Commit)
+}
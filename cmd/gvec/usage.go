@@ -71,6 +71,9 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.FastSyncFlag,
 			utils.LightKDFFlag,
 			utils.CacheFlag,
+			utils.AncientDepthFlag,
+			utils.GCModeFlag,
+			utils.AddrTxIndexFlag,
 			utils.BlockchainVersionFlag,
 		},
 	},
@@ -109,6 +112,19 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.NodeKeyHexFlag,
 		},
 	},
+	{
+		Name: "TRANSACTION POOL",
+		Flags: []cli.Flag{
+			utils.TxPoolAccountSlotsFlag,
+			utils.TxPoolGlobalSlotsFlag,
+			utils.TxPoolAccountQueueFlag,
+			utils.TxPoolGlobalQueueFlag,
+			utils.TxPoolPriceBumpFlag,
+			utils.TxPoolJournalFlag,
+			utils.TxPoolRejournalFlag,
+			utils.TxPoolLifetimeFlag,
+		},
+	},
 	{
 		Name: "MINER",
 		Flags: []cli.Flag{
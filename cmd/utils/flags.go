@@ -143,6 +143,20 @@ var (
 		Usage: "Megabytes of memory allocated to internal caching (min 16MB / database forced)",
 		Value: 0,
 	}
+	AncientDepthFlag = cli.IntFlag{
+		Name:  "ancientdepth",
+		Usage: "Number of recent blocks to keep in the database, older ones move to the ancient store (0 = disabled)",
+		Value: 0,
+	}
+	GCModeFlag = cli.StringFlag{
+		Name:  "gcmode",
+		Usage: `Blockchain garbage collection mode ("archive", "full")`,
+		Value: "archive",
+	}
+	AddrTxIndexFlag = cli.BoolFlag{
+		Name:  "addrtxindex",
+		Usage: "Index the transactions of imported blocks by sender and recipient address",
+	}
 	BlockchainVersionFlag = cli.IntFlag{
 		Name:  "blockchainversion",
 		Usage: "Blockchain version (integer)",
@@ -189,6 +203,47 @@ var (
 		Name:  "extradata",
 		Usage: "Block extra data set by the miner (default = client version)",
 	}
+	// Transaction pool settings
+	TxPoolAccountSlotsFlag = cli.IntFlag{
+		Name:  "txpoolaccountslots",
+		Usage: "Number of executable transaction slots guaranteed per account",
+		Value: int(core.DefaultTxPoolConfig.AccountSlots),
+	}
+	TxPoolGlobalSlotsFlag = cli.IntFlag{
+		Name:  "txpoolglobalslots",
+		Usage: "Maximum number of executable transaction slots for all accounts",
+		Value: int(core.DefaultTxPoolConfig.GlobalSlots),
+	}
+	TxPoolAccountQueueFlag = cli.IntFlag{
+		Name:  "txpoolaccountqueue",
+		Usage: "Maximum number of non-executable transaction slots permitted per account",
+		Value: int(core.DefaultTxPoolConfig.AccountQueue),
+	}
+	TxPoolGlobalQueueFlag = cli.IntFlag{
+		Name:  "txpoolglobalqueue",
+		Usage: "Maximum number of non-executable transaction slots for all accounts",
+		Value: int(core.DefaultTxPoolConfig.GlobalQueue),
+	}
+	TxPoolPriceBumpFlag = cli.IntFlag{
+		Name:  "txpoolpricebump",
+		Usage: "Price bump percentage to replace an already existing transaction",
+		Value: int(core.DefaultTxPoolConfig.PriceBump),
+	}
+	TxPoolJournalFlag = cli.StringFlag{
+		Name:  "txpooljournal",
+		Usage: "Disk journal for local transactions to survive node restarts, relative to the data dir (empty = disabled)",
+		Value: "transactions.rlp",
+	}
+	TxPoolRejournalFlag = cli.DurationFlag{
+		Name:  "txpoolrejournal",
+		Usage: "Time interval to regenerate the local transaction journal",
+		Value: core.DefaultTxPoolConfig.Rejournal,
+	}
+	TxPoolLifetimeFlag = cli.DurationFlag{
+		Name:  "txpoollifetime",
+		Usage: "Maximum time remote non-executable transactions are queued without any of them getting executable",
+		Value: core.DefaultTxPoolConfig.Lifetime,
+	}
 	// Account settings
 	UnlockedAccountFlag = cli.StringFlag{
 		Name:  "unlock",
@@ -413,6 +468,42 @@ func MakeNodeKey(ctx *cli.Context) (key *ecdsa.PrivateKey) {
 	return key
 }
 
+// MakeArchiveMode checks the requested garbage collection mode, returning whether
+// all state is to be retained on disk instead of only the recent states.
+func MakeArchiveMode(ctx *cli.Context) bool {
+	switch mode := ctx.GlobalString(GCModeFlag.Name); mode {
+	case "full":
+		return false
+	case "archive":
+		return true
+	default:
+		Fatalf("Option %q: unknown garbage collection mode %q, want \"archive\" or \"full\"", GCModeFlag.Name, mode)
+	}
+	return false
+}
+
+// MakeTxPoolConfig creates the transaction pool capacity limits, replacement
+// rules and journaling options from set command line flags.
+func MakeTxPoolConfig(ctx *cli.Context) core.TxPoolConfig {
+	limit := func(flag cli.IntFlag) uint64 {
+		value := ctx.GlobalInt(flag.Name)
+		if value <= 0 {
+			Fatalf("Option %q: must be positive, have %d", flag.Name, value)
+		}
+		return uint64(value)
+	}
+	return core.TxPoolConfig{
+		AccountSlots: limit(TxPoolAccountSlotsFlag),
+		GlobalSlots:  limit(TxPoolGlobalSlotsFlag),
+		AccountQueue: limit(TxPoolAccountQueueFlag),
+		GlobalQueue:  limit(TxPoolGlobalQueueFlag),
+		PriceBump:    limit(TxPoolPriceBumpFlag),
+		Journal:      ctx.GlobalString(TxPoolJournalFlag.Name),
+		Rejournal:    ctx.GlobalDuration(TxPoolRejournalFlag.Name),
+		Lifetime:     ctx.GlobalDuration(TxPoolLifetimeFlag.Name),
+	}
+}
+
 // MakeEthConfig creates vector options from set command line flags.
 func MakeEthConfig(clientID, version string, ctx *cli.Context) *vec.Config {
 	customName := ctx.GlobalString(IdentityFlag.Name)
@@ -432,6 +523,9 @@ func MakeEthConfig(clientID, version string, ctx *cli.Context) *vec.Config {
 		FastSync:                ctx.GlobalBool(FastSyncFlag.Name),
 		BlockChainVersion:       ctx.GlobalInt(BlockchainVersionFlag.Name),
 		DatabaseCache:           ctx.GlobalInt(CacheFlag.Name),
+		AncientDepth:            uint64(ctx.GlobalInt(AncientDepthFlag.Name)),
+		Pruning:                 !MakeArchiveMode(ctx),
+		AddrTxIndex:             ctx.GlobalBool(AddrTxIndexFlag.Name),
 		SkipBcVersionCheck:      false,
 		NetworkId:               ctx.GlobalInt(NetworkIdFlag.Name),
 		LogFile:                 ctx.GlobalString(LogFileFlag.Name),
@@ -461,6 +555,7 @@ func MakeEthConfig(clientID, version string, ctx *cli.Context) *vec.Config {
 		GpobaseCorrectionFactor: ctx.GlobalInt(GpobaseCorrectionFactorFlag.Name),
 		SolcPath:                ctx.GlobalString(SolcPathFlag.Name),
 		AutoDAG:                 ctx.GlobalBool(AutoDAGFlag.Name) || ctx.GlobalBool(MiningEnabledFlag.Name),
+		TxPool:                  MakeTxPoolConfig(ctx),
 	}
 
 	if ctx.GlobalBool(DevModeFlag.Name) && ctx.GlobalBool(TestNetFlag.Name) {
@@ -472,8 +567,6 @@ func MakeEthConfig(clientID, version string, ctx *cli.Context) *vec.Config {
 		cfg.DataDir += "/testnet"
 		cfg.NetworkId = 2
 		cfg.TestNet = true
-		// overwrite homestead block
-		params.HomesteadBlock = params.TestNetHomesteadBlock
 	}
 
 	if ctx.GlobalBool(VMEnableJitFlag.Name) {
@@ -535,15 +628,39 @@ func SetupVM(ctx *cli.Context) {
 	vm.SetJITCacheSize(ctx.GlobalInt(VMJitCacheFlag.Name))
 }
 
-// MakeChain creates a chain manager from set command line flags.
-func MakeChain(ctx *cli.Context) (chain *core.BlockChain, chainDb vecdb.Database) {
+// MakeChainDatabase opens the chain database, along with its ancient store if
+// present, from set command line flags.
+func MakeChainDatabase(ctx *cli.Context) vecdb.Database {
+	return openChainDatabase(ctx, uint64(ctx.GlobalInt(AncientDepthFlag.Name)))
+}
+
+// MakeChainDatabaseNoFreezing opens the chain database like MakeChainDatabase,
+// but never moves blocks into the ancient store, irrespective of --ancientdepth.
+// Frozen blocks are still readable. It is meant for the maintenance commands.
+func MakeChainDatabaseNoFreezing(ctx *cli.Context) vecdb.Database {
+	return openChainDatabase(ctx, 0)
+}
+
+func openChainDatabase(ctx *cli.Context, depth uint64) vecdb.Database {
 	datadir := MustDataDir(ctx)
 	cache := ctx.GlobalInt(CacheFlag.Name)
 
-	var err error
-	if chainDb, err = vecdb.NewLDBDatabase(filepath.Join(datadir, "chaindata"), cache); err != nil {
+	chainDb, err := vecdb.NewLDBDatabase(filepath.Join(datadir, "chaindata"), cache)
+	if err != nil {
 		Fatalf("Could not open database: %v", err)
 	}
+	db, err := core.OpenAncientDatabase(chainDb, filepath.Join(datadir, "ancient"), depth)
+	if err != nil {
+		Fatalf("Could not open ancient store: %v", err)
+	}
+	return db
+}
+
+// MakeChain creates a chain manager from set command line flags.
+func MakeChain(ctx *cli.Context) (chain *core.BlockChain, chainDb vecdb.Database) {
+	var err error
+	chainDb = MakeChainDatabase(ctx)
+
 	if ctx.GlobalBool(OlympicFlag.Name) {
 		_, err := core.WriteTestNetGenesisBlock(chainDb, 42)
 		if err != nil {
@@ -554,14 +671,29 @@ func MakeChain(ctx *cli.Context) (chain *core.BlockChain, chainDb vecdb.Database
 	eventMux := new(event.TypeMux)
 	pow := vecash.New()
 	//genesis := core.GenesisBlock(uint64(ctx.GlobalInt(GenesisNonceFlag.Name)), blockDB)
-	chain, err = core.NewBlockChain(chainDb, pow, eventMux)
+	chain, err = core.NewBlockChain(chainDb, MakeChainConfig(ctx, chainDb), pow, eventMux)
 	if err != nil {
 		Fatalf("Could not start chainmanager: %v", err)
 	}
+	chain.SetAddrTxIndexing(ctx.GlobalBool(AddrTxIndexFlag.Name))
 
 	return chain, chainDb
 }
 
+// MakeChainConfig reads the chain configuration stored along the genesis block
+// of the database, defaulting to the one of the selected network if none.
+func MakeChainConfig(ctx *cli.Context, db vecdb.Database) *core.ChainConfig {
+	def := core.MainNetChainConfig
+	if ctx.GlobalBool(TestNetFlag.Name) {
+		def = core.TestNetChainConfig
+	}
+	config, err := core.LoadChainConfig(db, def)
+	if err != nil {
+		Fatalf("Could not load chain config: %v", err)
+	}
+	return config
+}
+
 // MakeChain creates an account manager from set command line flags.
 func MakeAccountManager(ctx *cli.Context) *accounts.Manager {
 	dataDir := MustDataDir(ctx)
@@ -28,6 +28,7 @@ import (
 	"github.com/codegangsta/cli"
 	"github.com/vector/go-vector/core/vm"
 	"github.com/vector/go-vector/logger/glog"
+	"github.com/vector/go-vector/params"
 	"github.com/vector/go-vector/tests"
 )
 
@@ -74,9 +75,9 @@ func runTestWithReader(test string, r io.Reader) error {
 	var err error
 	switch strings.ToLower(test) {
 	case "bk", "block", "blocktest", "blockchaintest", "blocktests", "blockchaintests":
-		err = tests.RunBlockTestWithReader(r, skipTests)
+		err = tests.RunBlockTestWithReader(params.MainNetHomesteadBlock, r, skipTests)
 	case "st", "state", "statetest", "statetests":
-		err = tests.RunStateTestWithReader(r, skipTests)
+		err = tests.RunStateTestWithReader(tests.RuleSet{HomesteadBlock: params.MainNetHomesteadBlock}, r, skipTests)
 	case "tx", "transactiontest", "transactiontests":
 		err = tests.RunTransactionTestsWithReader(r, skipTests)
 	case "vm", "vmtest", "vmtests":
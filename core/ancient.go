@@ -0,0 +1,220 @@
+// Copyright 2016 The go-vector Authors
+// This file is part of the go-vector library.
+//
+// The go-vector library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vector library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vector library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/vector/go-vector/common"
+	"github.com/vector/go-vector/logger"
+	"github.com/vector/go-vector/logger/glog"
+	"github.com/vector/go-vector/vecdb"
+)
+
+const (
+	ancientRecheckInterval = time.Minute // Frequency to check the chain head for blocks to freeze
+	ancientBatchLimit      = 30000       // Maximum number of blocks to freeze in one go
+)
+
+// ancientSuffix marks the database entry mapping a frozen block's hash to its
+// number, allowing hash based lookups to be served from the freezer.
+var ancientSuffix = []byte("-ancient")
+
+// AncientDatabase is a chain database wrapper moving finalized block headers,
+// bodies, receipts and total difficulties out of the key-value store into an
+// append-only freezer once they are buried deep enough below the chain head.
+//
+// Reads of frozen data are served transparently through Get, so all the chain
+// accessors keep working unmodified. Frozen data is immutable: deletions do not
+// reach the freezer, and iteration only covers the key-value store.
+type AncientDatabase struct {
+	vecdb.Database
+
+	freezer *freezer
+	depth   uint64 // Number of blocks to keep in the key-value store, 0 = never freeze
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewAncientDatabase wraps a chain database with a freezer located in dir. If
+// depth is non zero, canonical blocks more than depth blocks below the chain
+// head are periodically migrated into the freezer.
+func NewAncientDatabase(db vecdb.Database, dir string, depth uint64) (*AncientDatabase, error) {
+	freezer, err := newFreezer(dir)
+	if err != nil {
+		return nil, err
+	}
+	adb := &AncientDatabase{
+		Database: db,
+		freezer:  freezer,
+		depth:    depth,
+		quit:     make(chan struct{}),
+	}
+	if depth > 0 {
+		adb.wg.Add(1)
+		go adb.loop()
+	}
+	return adb, nil
+}
+
+// OpenAncientDatabase wraps db with the freezer located in dir if freezing is
+// requested or a freezer already exists there. Otherwise db is returned as is.
+func OpenAncientDatabase(db vecdb.Database, dir string, depth uint64) (vecdb.Database, error) {
+	if depth == 0 && !common.FileExist(dir) {
+		return db, nil
+	}
+	return NewAncientDatabase(db, dir, depth)
+}
+
+// Ancients returns the number of blocks moved into the freezer.
+func (db *AncientDatabase) Ancients() uint64 {
+	return db.freezer.ancients()
+}
+
+// Get retrieves the given key from the key-value store, falling back to the
+// freezer for frozen block data.
+func (db *AncientDatabase) Get(key []byte) ([]byte, error) {
+	data, err := db.Database.Get(key)
+	if err == nil {
+		return data, nil
+	}
+	kind, hash, ok := ancientKey(key)
+	if !ok {
+		return nil, err
+	}
+	enc, _ := db.Database.Get(append(append(blockPrefix, hash...), ancientSuffix...))
+	if len(enc) != 8 {
+		return nil, err
+	}
+	blob, ferr := db.freezer.retrieve(kind, binary.BigEndian.Uint64(enc))
+	if ferr != nil || len(blob) == 0 {
+		return nil, err
+	}
+	return blob, nil
+}
+
+// Close stops the freezing process and closes both the freezer and the
+// underlying key-value store.
+func (db *AncientDatabase) Close() {
+	close(db.quit)
+	db.wg.Wait()
+
+	if err := db.freezer.close(); err != nil {
+		glog.V(logger.Error).Infof("failed to close ancient store: %v", err)
+	}
+	db.Database.Close()
+}
+
+// loop periodically moves the blocks that became old enough into the freezer.
+func (db *AncientDatabase) loop() {
+	defer db.wg.Done()
+
+	for {
+		if err := db.Freeze(); err != nil {
+			glog.V(logger.Error).Infof("failed to freeze ancient blocks: %v", err)
+		}
+		select {
+		case <-db.quit:
+			return
+		case <-time.After(ancientRecheckInterval):
+		}
+	}
+}
+
+// Freeze moves the canonical blocks buried at least depth blocks below the
+// current head from the key-value store into the freezer.
+func (db *AncientDatabase) Freeze() error {
+	head := GetHeader(db, GetHeadBlockHash(db))
+	if head == nil || head.Number.Uint64() < db.depth {
+		return nil
+	}
+	limit := head.Number.Uint64() - db.depth
+	if first := db.freezer.ancients(); limit >= first+ancientBatchLimit {
+		limit = first + ancientBatchLimit - 1
+	}
+	var (
+		start  = time.Now()
+		hashes []common.Hash
+	)
+	for number := db.freezer.ancients(); number <= limit; number++ {
+		// Retrieve all the components of the canonical block from the key-value store
+		hash := GetCanonicalHash(db, number)
+		if (hash == common.Hash{}) {
+			break
+		}
+		header, _ := db.Database.Get(append(append(blockPrefix, hash[:]...), headerSuffix...))
+		body, _ := db.Database.Get(append(append(blockPrefix, hash[:]...), bodySuffix...))
+		td, _ := db.Database.Get(append(append(blockPrefix, hash[:]...), tdSuffix...))
+		if len(header) == 0 || len(body) == 0 || len(td) == 0 {
+			break
+		}
+		receipts, _ := db.Database.Get(append(blockReceiptsPrefix, hash[:]...))
+
+		// Map the hash to the number before freezing, the key-value data still serves reads
+		enc := make([]byte, 8)
+		binary.BigEndian.PutUint64(enc, number)
+		if err := db.Database.Put(append(append(blockPrefix, hash[:]...), ancientSuffix...), enc); err != nil {
+			return err
+		}
+		if err := db.freezer.append(number, header, body, receipts, td); err != nil {
+			return err
+		}
+		hashes = append(hashes, hash)
+	}
+	if len(hashes) == 0 {
+		return nil
+	}
+	// Persist the freezer before dropping anything from the key-value store
+	if err := db.freezer.sync(); err != nil {
+		return err
+	}
+	for _, hash := range hashes {
+		DeleteHeader(db.Database, hash)
+		DeleteBody(db.Database, hash)
+		DeleteTd(db.Database, hash)
+		DeleteBlockReceipts(db.Database, hash)
+	}
+	glog.V(logger.Info).Infof("froze %d ancient blocks in %v, %d total", len(hashes), time.Since(start), db.freezer.ancients())
+	return nil
+}
+
+// ancientKey checks whether a database key references block data that may be
+// stored in the freezer, returning the freezer table and the block hash.
+func ancientKey(key []byte) (string, []byte, bool) {
+	hashLen := len(common.Hash{})
+
+	if len(key) == len(blockReceiptsPrefix)+hashLen && bytes.HasPrefix(key, blockReceiptsPrefix) {
+		return freezerReceiptTable, key[len(blockReceiptsPrefix):], true
+	}
+	if len(key) <= len(blockPrefix)+hashLen || !bytes.HasPrefix(key, blockPrefix) {
+		return "", nil, false
+	}
+	hash, suffix := key[len(blockPrefix):len(blockPrefix)+hashLen], key[len(blockPrefix)+hashLen:]
+	switch {
+	case bytes.Equal(suffix, headerSuffix):
+		return freezerHeaderTable, hash, true
+	case bytes.Equal(suffix, bodySuffix):
+		return freezerBodiesTable, hash, true
+	case bytes.Equal(suffix, tdSuffix):
+		return freezerDifficultyTable, hash, true
+	}
+	return "", nil, false
+}
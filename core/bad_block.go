@@ -21,14 +21,83 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/vector/go-vector/common"
 	"github.com/vector/go-vector/core/types"
+	"github.com/vector/go-vector/vecdb"
 	"github.com/vector/go-vector/logger"
 	"github.com/vector/go-vector/logger/glog"
 	"github.com/vector/go-vector/rlp"
 )
 
+// badBlockLimit is the maximum number of rejected blocks retained locally.
+const badBlockLimit = 16
+
+var (
+	badBlocksKey  = []byte("BadBlocks")
+	badBlocksLock sync.Mutex // Serializes updates to the bad block ring
+)
+
+// BadBlock is a block rejected during import, retained locally together with
+// the details of its rejection for later inspection.
+type BadBlock struct {
+	Block  *types.Block
+	Origin string // Identifier of the peer the block was received from, empty if unknown
+	Error  string // Reason the block was rejected for
+	Time   uint64 // Unix timestamp of the rejection
+}
+
+// GetBadBlocks retrieves the rejected blocks retained in the database, the most
+// recent one first.
+func GetBadBlocks(db vecdb.Database) []*BadBlock {
+	data, _ := db.Get(badBlocksKey)
+	if len(data) == 0 {
+		return nil
+	}
+	var blocks []*BadBlock
+	if err := rlp.DecodeBytes(data, &blocks); err != nil {
+		glog.V(logger.Error).Infof("invalid bad block ring RLP: %v", err)
+		return nil
+	}
+	return blocks
+}
+
+// WriteBadBlock inserts a rejected block into the bad block ring stored in the
+// database, evicting the oldest entry if the ring is full. Blocks already in the
+// ring are not inserted again.
+func WriteBadBlock(db vecdb.Database, block *types.Block, origin string, reason error) error {
+	badBlocksLock.Lock()
+	defer badBlocksLock.Unlock()
+
+	blocks := GetBadBlocks(db)
+	for _, bad := range blocks {
+		if bad.Block.Hash() == block.Hash() {
+			return nil
+		}
+	}
+	bad := &BadBlock{
+		Block:  block,
+		Origin: origin,
+		Error:  reason.Error(),
+		Time:   uint64(time.Now().Unix()),
+	}
+	blocks = append([]*BadBlock{bad}, blocks...)
+	if len(blocks) > badBlockLimit {
+		blocks = blocks[:badBlockLimit]
+	}
+	data, err := rlp.EncodeToBytes(blocks)
+	if err != nil {
+		return err
+	}
+	if err := db.Put(badBlocksKey, data); err != nil {
+		glog.V(logger.Error).Infof("failed to store bad block #%v [%x…]: %v", block.Number(), block.Hash().Bytes()[:4], err)
+		return err
+	}
+	return nil
+}
+
 // DisabledBadBlockReporting can be set to prevent blocks being reported.
 var DisableBadBlockReporting = true
 
@@ -44,6 +44,7 @@ import (
 	"github.com/vector/go-vector/rlp"
 	"github.com/vector/go-vector/trie"
 	"github.com/hashicorp/golang-lru"
+	"gopkg.in/karalabe/cookiejar.v2/collections/prque"
 )
 
 var (
@@ -62,6 +63,10 @@ const (
 	blockCacheLimit     = 256
 	maxFutureBlocks     = 256
 	maxTimeFutureBlocks = 30
+
+	triesInMemory      = 128               // Number of recent block states a pruning node keeps in memory
+	stateFlushInterval = 4096              // Block number interval at which a pruning node flushes state to disk
+	stateCacheLimit    = 256 * 1024 * 1024 // Trie node cache size above which state is flushed to disk early
 	// must be bumped when consensus algorithm is changed, this forces the upgradedb
 	// command to be run (forces the blocks to be imported again using the new algorithm)
 	BlockChainVersion = 3
@@ -103,12 +108,17 @@ type BlockChain struct {
 	blockCache   *lru.Cache // Cache for the most recent entire blocks
 	futureBlocks *lru.Cache // future blocks are blocks added for later processing
 
+	stateCache *trie.NodeCache // Trie node cache of a pruning node (nil if archiving)
+	stateRoots *prque.Prque    // State roots referenced in the cache, ordered by block number
+
 	quit    chan struct{}
 	running int32 // running must be called automically
 	// procInterrupt must be atomically called
 	procInterrupt int32 // interrupt signaler for block processing
 	wg            sync.WaitGroup
 
+	config    *ChainConfig // chain & network configuration
+	addrIndex bool         // whether transactions are indexed by address
 	pow       pow.PoW
 	rand      *mrand.Rand
 	processor Processor
@@ -117,8 +127,8 @@ type BlockChain struct {
 
 // NewBlockChain returns a fully initialised block chain using information
 // available in the database. It initialiser the default Vector Validator and
-// Processor.
-func NewBlockChain(chainDb vecdb.Database, pow pow.PoW, mux *event.TypeMux) (*BlockChain, error) {
+// Processor. The chain configuration determines the fork rules of the chain.
+func NewBlockChain(chainDb vecdb.Database, config *ChainConfig, pow pow.PoW, mux *event.TypeMux) (*BlockChain, error) {
 	headerCache, _ := lru.New(headerCacheLimit)
 	bodyCache, _ := lru.New(bodyCacheLimit)
 	bodyRLPCache, _ := lru.New(bodyCacheLimit)
@@ -128,6 +138,7 @@ func NewBlockChain(chainDb vecdb.Database, pow pow.PoW, mux *event.TypeMux) (*Bl
 
 	bc := &BlockChain{
 		chainDb:      chainDb,
+		config:       config,
 		eventMux:     mux,
 		quit:         make(chan struct{}),
 		headerCache:  headerCache,
@@ -138,14 +149,18 @@ func NewBlockChain(chainDb vecdb.Database, pow pow.PoW, mux *event.TypeMux) (*Bl
 		futureBlocks: futureBlocks,
 		pow:          pow,
 	}
+	// Enable state garbage collection if the database caches the trie nodes
+	if cache, ok := chainDb.(*trie.NodeCache); ok {
+		bc.stateCache, bc.stateRoots = cache, prque.New()
+	}
 	// Seed a fast but crypto originating random generator
 	seed, err := crand.Int(crand.Reader, big.NewInt(math.MaxInt64))
 	if err != nil {
 		return nil, err
 	}
 	bc.rand = mrand.New(mrand.NewSource(seed.Int64()))
-	bc.SetValidator(NewBlockValidator(bc, pow))
-	bc.SetProcessor(NewStateProcessor(bc))
+	bc.SetValidator(NewBlockValidator(config, bc, pow))
+	bc.SetProcessor(NewStateProcessor(config, bc))
 
 	bc.genesisBlock = bc.GetBlockByNumber(0)
 	if bc.genesisBlock == nil {
@@ -192,6 +207,41 @@ func (self *BlockChain) loadLastState() error {
 			self.Reset()
 		}
 	}
+	// Rewind the head block to the most recent one with available state, as a
+	// pruning node only persists its state periodically and at shutdown
+	if _, err := state.New(self.currentBlock.Root(), self.chainDb); err != nil {
+		head := self.currentBlock
+		for self.currentBlock.NumberU64() > 0 {
+			parent := self.GetBlock(self.currentBlock.ParentHash())
+			if parent == nil {
+				break
+			}
+			self.currentBlock = parent
+			if _, err := state.New(self.currentBlock.Root(), self.chainDb); err == nil {
+				break
+			}
+		}
+		glog.V(logger.Info).Infof("Head state missing, rewound head block from #%d [%x…] to #%d [%x…]", head.Number(), head.Hash().Bytes()[:4], self.currentBlock.Number(), self.currentBlock.Hash().Bytes()[:4])
+
+		// Roll back the canonical chain numbering and the header and fast block
+		// heads too, the blocks past the new head will be reprocessed
+		height := head.NumberU64()
+		if header := self.GetHeader(GetHeadHeaderHash(self.chainDb)); header != nil && header.Number.Uint64() > height {
+			height = header.Number.Uint64()
+		}
+		for i := height; i > self.currentBlock.NumberU64(); i-- {
+			DeleteCanonicalHash(self.chainDb, i)
+		}
+		if err := WriteHeadBlockHash(self.chainDb, self.currentBlock.Hash()); err != nil {
+			glog.Fatalf("failed to update head block hash: %v", err)
+		}
+		if err := WriteHeadHeaderHash(self.chainDb, self.currentBlock.Hash()); err != nil {
+			glog.Fatalf("failed to update head header hash: %v", err)
+		}
+		if err := WriteHeadFastBlockHash(self.chainDb, self.currentBlock.Hash()); err != nil {
+			glog.Fatalf("failed to update head fast block hash: %v", err)
+		}
+	}
 	// Restore the last known head header
 	self.currentHeader = self.currentBlock.Header()
 	if head := GetHeadHeaderHash(self.chainDb); head != (common.Hash{}) {
@@ -221,7 +271,8 @@ func (self *BlockChain) loadLastState() error {
 // SetHead rewinds the local chain to a new head. In the case of headers, everything
 // above the new head will be deleted and the new one set. In the case of blocks
 // though, the head may be further rewound if block bodies are missing (non-archive
-// nodes after a fast sync).
+// nodes after a fast sync) or if the state of the new head was already discarded
+// (pruning nodes), in which case the most recent block with retained state wins.
 func (bc *BlockChain) SetHead(head uint64) {
 	bc.mu.Lock()
 	defer bc.mu.Unlock()
@@ -262,8 +313,12 @@ func (bc *BlockChain) SetHead(head uint64) {
 	for i := height; i > head; i-- {
 		DeleteCanonicalHash(bc.chainDb, i)
 	}
-	// Delete everything found by the above rewind
+	// Delete everything found by the above rewind, unwinding the address index
+	// of the dropped blocks (whether indexing is currently enabled or not)
 	for hash, _ := range drop {
+		if block := bc.GetBlock(hash); block != nil {
+			DeleteAddrTxIndex(bc.chainDb, block)
+		}
 		DeleteHeader(bc.chainDb, hash)
 		DeleteBody(bc.chainDb, hash)
 		DeleteTd(bc.chainDb, hash)
@@ -383,6 +438,44 @@ func (self *BlockChain) SetValidator(validator Validator) {
 	self.validator = validator
 }
 
+// SetAddrTxIndexing enables or disables maintaining the per-address transaction
+// index for blocks becoming canonical from now on.
+func (self *BlockChain) SetAddrTxIndexing(enabled bool) {
+	self.procmu.Lock()
+	defer self.procmu.Unlock()
+	self.addrIndex = enabled
+}
+
+// WriteCanonicalIndexes stores the lookup data of a block that became the
+// canonical head: the transactions and receipts by hash, the mipmap log blooms
+// and, if enabled, the per-address transaction index. Blocks inserted through
+// InsertChain are handled internally, locally sealed ones must be written with
+// this method after WriteBlock.
+func (self *BlockChain) WriteCanonicalIndexes(block *types.Block, receipts types.Receipts) error {
+	// This puts transactions in a extra db for rpc
+	if err := WriteTransactions(self.chainDb, block); err != nil {
+		return err
+	}
+	if self.addrTxIndexing() {
+		if err := WriteAddrTxIndex(self.chainDb, block); err != nil {
+			return err
+		}
+	}
+	// store the receipts
+	if err := WriteReceipts(self.chainDb, receipts); err != nil {
+		return err
+	}
+	// Write map map bloom filters
+	return WriteMipmapBloom(self.chainDb, block.NumberU64(), receipts)
+}
+
+// addrTxIndexing reports whether the per-address transaction index is maintained.
+func (self *BlockChain) addrTxIndexing() bool {
+	self.procmu.RLock()
+	defer self.procmu.RUnlock()
+	return self.addrIndex
+}
+
 // Validator returns the current validator.
 func (self *BlockChain) Validator() Validator {
 	self.procmu.RLock()
@@ -397,6 +490,9 @@ func (self *BlockChain) Processor() Processor {
 	return self.processor
 }
 
+// Config retrieves the blockchain's chain configuration.
+func (self *BlockChain) Config() *ChainConfig { return self.config }
+
 // AuxValidator returns the auxiliary validator (Proof of work atm)
 func (self *BlockChain) AuxValidator() pow.PoW { return self.pow }
 
@@ -687,6 +783,12 @@ func (bc *BlockChain) Stop() {
 
 	bc.wg.Wait()
 
+	// Persist the state of the head block, the rest of the cached state is lost
+	if bc.stateCache != nil {
+		if err := bc.stateCache.Flush(bc.CurrentBlock().Root()); err != nil {
+			glog.V(logger.Error).Infof("Failed to flush head state: %v", err)
+		}
+	}
 	glog.V(logger.Info).Infoln("Chain manager stopped")
 }
 
@@ -1001,6 +1103,14 @@ func (self *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain
 				glog.Fatal(errs[index])
 				return
 			}
+			if self.addrTxIndexing() {
+				if err := WriteAddrTxIndex(self.chainDb, block); err != nil {
+					errs[index] = fmt.Errorf("failed to write address index: %v", err)
+					atomic.AddInt32(&failed, 1)
+					glog.Fatal(errs[index])
+					return
+				}
+			}
 			atomic.AddInt32(&stats.processed, 1)
 		}
 	}
@@ -1051,6 +1161,14 @@ func (self *BlockChain) WriteBlock(block *types.Block) (status writeStatus, err
 	self.wg.Add(1)
 	defer self.wg.Done()
 
+	// Release the already committed state of a block failing to be written
+	if self.stateCache != nil {
+		defer func() {
+			if err != nil {
+				self.stateCache.Discard(block.Root())
+			}
+		}()
+	}
 	// Calculate the total difficulty of the block
 	ptd := self.GetTd(block.ParentHash())
 	if ptd == nil {
@@ -1089,9 +1207,42 @@ func (self *BlockChain) WriteBlock(block *types.Block) (status writeStatus, err
 	}
 	self.futureBlocks.Remove(block.Hash())
 
+	if self.stateCache != nil {
+		self.collectState(block)
+	}
 	return
 }
 
+// collectState retains the state of a newly written block in the trie node
+// cache and releases the states of the blocks fallen too far below it. These are
+// flushed to disk first at regular block intervals, or if the cache grew too big.
+//
+// This method assumes that the chain manager mutex is held.
+func (self *BlockChain) collectState(block *types.Block) {
+	self.stateCache.Reference(block.Root())
+	self.stateRoots.Push(block.Root(), -float32(block.NumberU64()))
+
+	if block.NumberU64() < triesInMemory {
+		return
+	}
+	limit := block.NumberU64() - triesInMemory
+	for !self.stateRoots.Empty() {
+		root, prio := self.stateRoots.Pop()
+		if number := uint64(-prio); number > limit {
+			self.stateRoots.Push(root, prio)
+			break
+		} else if number%stateFlushInterval == 0 || self.stateCache.Size() > stateCacheLimit {
+			start := time.Now()
+			if err := self.stateCache.Flush(root.(common.Hash)); err != nil {
+				glog.V(logger.Error).Infof("Failed to flush state of block #%d: %v", number, err)
+			} else {
+				glog.V(logger.Debug).Infof("Flushed state of block #%d in %v, %v still cached", number, time.Since(start), self.stateCache.Size())
+			}
+		}
+		self.stateCache.Dereference(root.(common.Hash))
+	}
+}
+
 // InsertChain will attempt to insert the given chain in to the canonical chain or, otherwise, create a fork. It an error is returned
 // it will return the index number of the failing block as well an error describing what went wrong (for possible errors see core/errors.go).
 func (self *BlockChain) InsertChain(chain types.Blocks) (int, error) {
@@ -1138,7 +1289,7 @@ func (self *BlockChain) InsertChain(chain types.Blocks) (int, error) {
 
 		if BadHashes[block.Hash()] {
 			err := BadHashError(block.Hash())
-			reportBlock(block, err)
+			self.reportBlock(block, err)
 			return i, err
 		}
 		// Stage 1 validation of the block using the chain's validator
@@ -1170,7 +1321,7 @@ func (self *BlockChain) InsertChain(chain types.Blocks) (int, error) {
 				continue
 			}
 
-			reportBlock(block, err)
+			self.reportBlock(block, err)
 
 			return i, err
 		}
@@ -1179,19 +1330,19 @@ func (self *BlockChain) InsertChain(chain types.Blocks) (int, error) {
 		// error if it fails.
 		statedb, err := state.New(self.GetBlock(block.ParentHash()).Root(), self.chainDb)
 		if err != nil {
-			reportBlock(block, err)
+			self.reportBlock(block, err)
 			return i, err
 		}
 		// Process block using the parent state as reference point.
 		receipts, logs, usedGas, err := self.processor.Process(block, statedb)
 		if err != nil {
-			reportBlock(block, err)
+			self.reportBlock(block, err)
 			return i, err
 		}
 		// Validate the state using the default validator
 		err = self.Validator().ValidateState(block, self.GetBlock(block.ParentHash()), statedb, receipts, usedGas)
 		if err != nil {
-			reportBlock(block, err)
+			self.reportBlock(block, err)
 			return i, err
 		}
 		// Write state changes to database
@@ -1204,6 +1355,9 @@ func (self *BlockChain) InsertChain(chain types.Blocks) (int, error) {
 		coalescedLogs = append(coalescedLogs, logs...)
 
 		if err := WriteBlockReceipts(self.chainDb, block.Hash(), receipts); err != nil {
+			if self.stateCache != nil {
+				self.stateCache.Discard(block.Root())
+			}
 			return i, err
 		}
 
@@ -1221,16 +1375,7 @@ func (self *BlockChain) InsertChain(chain types.Blocks) (int, error) {
 			}
 			events = append(events, ChainEvent{block, block.Hash(), logs})
 
-			// This puts transactions in a extra db for rpc
-			if err := WriteTransactions(self.chainDb, block); err != nil {
-				return i, err
-			}
-			// store the receipts
-			if err := WriteReceipts(self.chainDb, receipts); err != nil {
-				return i, err
-			}
-			// Write map map bloom filters
-			if err := WriteMipmapBloom(self.chainDb, block.NumberU64(), receipts); err != nil {
+			if err := self.WriteCanonicalIndexes(block, receipts); err != nil {
 				return i, err
 			}
 		case SideStatTy:
@@ -1261,17 +1406,33 @@ func (self *BlockChain) InsertChain(chain types.Blocks) (int, error) {
 func (self *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 	var (
 		newChain    types.Blocks
+		oldChain    types.Blocks
 		commonBlock *types.Block
 		oldStart    = oldBlock
 		newStart    = newBlock
 		deletedTxs  types.Transactions
+		deletedLogs vm.Logs
+		// collectLogs collects the logs that were generated during the
+		// processing of the block that corresponds with the given hash.
+		// These logs are later announced as deleted.
+		collectLogs = func(h common.Hash) {
+			for _, receipt := range GetBlockReceipts(self.chainDb, h) {
+				for _, log := range receipt.Logs {
+					removed := *log
+					removed.Removed = true
+					deletedLogs = append(deletedLogs, &removed)
+				}
+			}
+		}
 	)
 
 	// first reduce whoever is higher bound
 	if oldBlock.NumberU64() > newBlock.NumberU64() {
 		// reduce old chain
 		for oldBlock = oldBlock; oldBlock != nil && oldBlock.NumberU64() != newBlock.NumberU64(); oldBlock = self.GetBlock(oldBlock.ParentHash()) {
+			oldChain = append(oldChain, oldBlock)
 			deletedTxs = append(deletedTxs, oldBlock.Transactions()...)
+			collectLogs(oldBlock.Hash())
 		}
 	} else {
 		// reduce new chain and append new chain blocks for inserting later on
@@ -1292,8 +1453,10 @@ func (self *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 			commonBlock = oldBlock
 			break
 		}
+		oldChain = append(oldChain, oldBlock)
 		newChain = append(newChain, newBlock)
 		deletedTxs = append(deletedTxs, oldBlock.Transactions()...)
+		collectLogs(oldBlock.Hash())
 
 		oldBlock, newBlock = self.GetBlock(oldBlock.ParentHash()), self.GetBlock(newBlock.ParentHash())
 		if oldBlock == nil {
@@ -1309,6 +1472,14 @@ func (self *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 		glog.Infof("Chain split detected @ %x. Reorganising chain from #%v %x to %x", commonHash[:4], numSplit, oldStart.Hash().Bytes()[:4], newStart.Hash().Bytes()[:4])
 	}
 
+	// unwind the address index of the dropped blocks before the new chain
+	// takes over their positions
+	indexAddrs := self.addrTxIndexing()
+	if indexAddrs {
+		for _, block := range oldChain {
+			DeleteAddrTxIndex(self.chainDb, block)
+		}
+	}
 	var addedTxs types.Transactions
 	// insert blocks. Order does not matter. Last block will be written in ImportChain itself which creates the new head properly
 	for _, block := range newChain {
@@ -1318,6 +1489,11 @@ func (self *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 		if err := WriteTransactions(self.chainDb, block); err != nil {
 			return err
 		}
+		if indexAddrs {
+			if err := WriteAddrTxIndex(self.chainDb, block); err != nil {
+				return err
+			}
+		}
 		receipts := GetBlockReceipts(self.chainDb, block.Hash())
 		// write receipts
 		if err := WriteReceipts(self.chainDb, receipts); err != nil {
@@ -1342,6 +1518,9 @@ func (self *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 	// Must be posted in a goroutine because of the transaction pool trying
 	// to acquire the chain manager lock
 	go self.eventMux.Post(RemovedTransactionEvent{diff})
+	if len(deletedLogs) > 0 {
+		go self.eventMux.Post(RemovedLogsEvent{deletedLogs})
+	}
 
 	return nil
 }
@@ -1376,13 +1555,19 @@ func (self *BlockChain) update() {
 	}
 }
 
-// reportBlock reports the given block and error using the canonical block
-// reporting tool. Reporting the block to the service is handled in a separate
-// goroutine.
-func reportBlock(block *types.Block, err error) {
+// reportBlock retains the given block and error in the local bad block store and
+// reports them using the canonical block reporting tool. Reporting the block to
+// the service is handled in a separate goroutine.
+func (self *BlockChain) reportBlock(block *types.Block, err error) {
 	if glog.V(logger.Error) {
 		glog.Errorf("Bad block #%v (%s)\n", block.Number(), block.Hash().Hex())
 		glog.Errorf("    %v", err)
 	}
+	var origin string
+	if block.ReceivedFrom != nil {
+		origin = fmt.Sprint(block.ReceivedFrom)
+	}
+	WriteBadBlock(self.chainDb, block, origin, err)
+
 	go ReportBlock(block, err)
 }
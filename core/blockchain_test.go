@@ -22,9 +22,11 @@ import (
 	"math/rand"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/vector/vecash"
 	"github.com/vector/go-vector/common"
@@ -52,7 +54,7 @@ func thePow() pow.PoW {
 func theBlockChain(db vecdb.Database, t *testing.T) *BlockChain {
 	var eventMux event.TypeMux
 	WriteTestNetGenesisBlock(db, 0)
-	blockchain, err := NewBlockChain(db, thePow(), &eventMux)
+	blockchain, err := NewBlockChain(db, MainNetChainConfig, thePow(), &eventMux)
 	if err != nil {
 		t.Error("failed creating blockchain:", err)
 		t.FailNow()
@@ -142,12 +144,12 @@ func testBlockChainImport(chain types.Blocks, blockchain *BlockChain) error {
 		}
 		receipts, _, usedGas, err := blockchain.Processor().Process(block, statedb)
 		if err != nil {
-			reportBlock(block, err)
+			blockchain.reportBlock(block, err)
 			return err
 		}
 		err = blockchain.Validator().ValidateState(block, blockchain.GetBlock(block.ParentHash()), statedb, receipts, usedGas)
 		if err != nil {
-			reportBlock(block, err)
+			blockchain.reportBlock(block, err)
 			return err
 		}
 		blockchain.mu.Lock()
@@ -607,7 +609,7 @@ func testReorgBadHashes(t *testing.T, full bool) {
 		defer func() { delete(BadHashes, headers[3].Hash()) }()
 	}
 	// Create a new chain manager and check it rolled back the state
-	ncm, err := NewBlockChain(db, FakePow{}, new(event.TypeMux))
+	ncm, err := NewBlockChain(db, MainNetChainConfig, FakePow{}, new(event.TypeMux))
 	if err != nil {
 		t.Fatalf("failed to create new chain manager: %v", err)
 	}
@@ -661,7 +663,7 @@ func testInsertNonceError(t *testing.T, full bool) {
 			failHash = headers[failAt].Hash()
 
 			blockchain.pow = failPow{failNum}
-			blockchain.validator = NewBlockValidator(blockchain, failPow{failNum})
+			blockchain.validator = NewBlockValidator(MainNetChainConfig, blockchain, failPow{failNum})
 
 			failRes, err = blockchain.InsertHeaderChain(headers, 1)
 		}
@@ -696,6 +698,77 @@ func testInsertNonceError(t *testing.T, full bool) {
 
 // Tests that fast importing a block chain produces the same chain data as the
 // classical full block processing.
+// Tests that the address transaction index covers locally sealed blocks, which
+// are written by the miner through WriteBlock and WriteCanonicalIndexes.
+func TestAddrTxIndexMined(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		gendb   = func() vecdb.Database { db, _ := vecdb.NewMemDatabase(); return db }()
+		db, _   = vecdb.NewMemDatabase()
+		genesis = GenesisBlockForTesting(gendb, addr, big.NewInt(1000000))
+	)
+	WriteGenesisBlockForTesting(db, GenesisAccount{addr, big.NewInt(1000000)})
+	blockchain, _ := NewBlockChain(db, MainNetChainConfig, FakePow{}, &event.TypeMux{})
+	blockchain.SetAddrTxIndexing(true)
+
+	blocks, receipts := GenerateChain(MainNetChainConfig, genesis, gendb, 1, func(i int, gen *BlockGen) {
+		tx, _ := types.NewTransaction(gen.TxNonce(addr), common.Address{0x01}, big.NewInt(1000), params.TxGas, nil, nil).SignECDSA(key)
+		gen.AddTx(tx)
+	})
+	// Mimic the miner, which writes the sealed block directly
+	status, err := blockchain.WriteBlock(blocks[0])
+	if err != nil || status != CanonStatTy {
+		t.Fatalf("failed to write block: status %v, err %v", status, err)
+	}
+	if err := blockchain.WriteCanonicalIndexes(blocks[0], receipts[0]); err != nil {
+		t.Fatalf("failed to write canonical indexes: %v", err)
+	}
+	want := []common.Hash{blocks[0].Transactions()[0].Hash()}
+	if have := GetAddrTransactions(db, addr, 0, 10); !reflect.DeepEqual(have, want) {
+		t.Errorf("indexed transactions mismatch: have %x, want %x", have, want)
+	}
+}
+
+// Tests that the address transaction index is written for fast synced blocks
+// and unwound when the chain head is rewound.
+func TestAddrTxIndexFastSync(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		gendb   = func() vecdb.Database { db, _ := vecdb.NewMemDatabase(); return db }()
+		db, _   = vecdb.NewMemDatabase()
+		genesis = GenesisBlockForTesting(gendb, addr, big.NewInt(1000000))
+	)
+	WriteGenesisBlockForTesting(db, GenesisAccount{addr, big.NewInt(1000000)})
+	blockchain, _ := NewBlockChain(db, MainNetChainConfig, FakePow{}, &event.TypeMux{})
+	blockchain.SetAddrTxIndexing(true)
+
+	blocks, receipts := GenerateChain(MainNetChainConfig, genesis, gendb, 2, func(i int, gen *BlockGen) {
+		tx, _ := types.NewTransaction(gen.TxNonce(addr), common.Address{0x01}, big.NewInt(1000), params.TxGas, nil, nil).SignECDSA(key)
+		gen.AddTx(tx)
+	})
+	headers := make([]*types.Header, len(blocks))
+	for i, block := range blocks {
+		headers[i] = block.Header()
+	}
+	if n, err := blockchain.InsertHeaderChain(headers, 1); err != nil {
+		t.Fatalf("failed to insert header %d: %v", n, err)
+	}
+	if n, err := blockchain.InsertReceiptChain(blocks, receipts); err != nil {
+		t.Fatalf("failed to insert receipt %d: %v", n, err)
+	}
+	tx1, tx2 := blocks[0].Transactions()[0].Hash(), blocks[1].Transactions()[0].Hash()
+	if have := GetAddrTransactions(db, addr, 0, 10); !reflect.DeepEqual(have, []common.Hash{tx1, tx2}) {
+		t.Errorf("indexed transactions mismatch: have %x, want %x", have, []common.Hash{tx1, tx2})
+	}
+	// Rewind the second block and ensure its entries are gone
+	blockchain.SetHead(1)
+	if have := GetAddrTransactions(db, addr, 0, 10); !reflect.DeepEqual(have, []common.Hash{tx1}) {
+		t.Errorf("rewound transactions mismatch: have %x, want %x", have, []common.Hash{tx1})
+	}
+}
+
 func TestFastVsFullChains(t *testing.T) {
 	// Configure and generate a sample block chain
 	var (
@@ -705,7 +778,7 @@ func TestFastVsFullChains(t *testing.T) {
 		funds    = big.NewInt(1000000000)
 		genesis  = GenesisBlockForTesting(gendb, address, funds)
 	)
-	blocks, receipts := GenerateChain(genesis, gendb, 1024, func(i int, block *BlockGen) {
+	blocks, receipts := GenerateChain(MainNetChainConfig, genesis, gendb, 1024, func(i int, block *BlockGen) {
 		block.SetCoinbase(common.Address{0x00})
 
 		// If the block number is multiple of 3, send a few bonus transactions to the miner
@@ -727,7 +800,7 @@ func TestFastVsFullChains(t *testing.T) {
 	archiveDb, _ := vecdb.NewMemDatabase()
 	WriteGenesisBlockForTesting(archiveDb, GenesisAccount{address, funds})
 
-	archive, _ := NewBlockChain(archiveDb, FakePow{}, new(event.TypeMux))
+	archive, _ := NewBlockChain(archiveDb, MainNetChainConfig, FakePow{}, new(event.TypeMux))
 
 	if n, err := archive.InsertChain(blocks); err != nil {
 		t.Fatalf("failed to process block %d: %v", n, err)
@@ -735,7 +808,7 @@ func TestFastVsFullChains(t *testing.T) {
 	// Fast import the chain as a non-archive node to test
 	fastDb, _ := vecdb.NewMemDatabase()
 	WriteGenesisBlockForTesting(fastDb, GenesisAccount{address, funds})
-	fast, _ := NewBlockChain(fastDb, FakePow{}, new(event.TypeMux))
+	fast, _ := NewBlockChain(fastDb, MainNetChainConfig, FakePow{}, new(event.TypeMux))
 
 	headers := make([]*types.Header, len(blocks))
 	for i, block := range blocks {
@@ -788,7 +861,7 @@ func TestLightVsFastVsFullChainHeads(t *testing.T) {
 		genesis  = GenesisBlockForTesting(gendb, address, funds)
 	)
 	height := uint64(1024)
-	blocks, receipts := GenerateChain(genesis, gendb, int(height), nil)
+	blocks, receipts := GenerateChain(MainNetChainConfig, genesis, gendb, int(height), nil)
 
 	// Configure a subchain to roll back
 	remove := []common.Hash{}
@@ -811,7 +884,7 @@ func TestLightVsFastVsFullChainHeads(t *testing.T) {
 	archiveDb, _ := vecdb.NewMemDatabase()
 	WriteGenesisBlockForTesting(archiveDb, GenesisAccount{address, funds})
 
-	archive, _ := NewBlockChain(archiveDb, FakePow{}, new(event.TypeMux))
+	archive, _ := NewBlockChain(archiveDb, MainNetChainConfig, FakePow{}, new(event.TypeMux))
 
 	if n, err := archive.InsertChain(blocks); err != nil {
 		t.Fatalf("failed to process block %d: %v", n, err)
@@ -823,7 +896,7 @@ func TestLightVsFastVsFullChainHeads(t *testing.T) {
 	// Import the chain as a non-archive node and ensure all pointers are updated
 	fastDb, _ := vecdb.NewMemDatabase()
 	WriteGenesisBlockForTesting(fastDb, GenesisAccount{address, funds})
-	fast, _ := NewBlockChain(fastDb, FakePow{}, new(event.TypeMux))
+	fast, _ := NewBlockChain(fastDb, MainNetChainConfig, FakePow{}, new(event.TypeMux))
 
 	headers := make([]*types.Header, len(blocks))
 	for i, block := range blocks {
@@ -842,7 +915,7 @@ func TestLightVsFastVsFullChainHeads(t *testing.T) {
 	// Import the chain as a light node and ensure all pointers are updated
 	lightDb, _ := vecdb.NewMemDatabase()
 	WriteGenesisBlockForTesting(lightDb, GenesisAccount{address, funds})
-	light, _ := NewBlockChain(lightDb, FakePow{}, new(event.TypeMux))
+	light, _ := NewBlockChain(lightDb, MainNetChainConfig, FakePow{}, new(event.TypeMux))
 
 	if n, err := light.InsertHeaderChain(headers, 1); err != nil {
 		t.Fatalf("failed to insert header %d: %v", n, err)
@@ -888,7 +961,7 @@ func TestChainTxReorgs(t *testing.T) {
 	//  - futureAdd: transaction added after the reorg has already finished
 	var pastAdd, freshAdd, futureAdd *types.Transaction
 
-	chain, _ := GenerateChain(genesis, db, 3, func(i int, gen *BlockGen) {
+	chain, _ := GenerateChain(MainNetChainConfig, genesis, db, 3, func(i int, gen *BlockGen) {
 		switch i {
 		case 0:
 			pastDrop, _ = types.NewTransaction(gen.TxNonce(addr2), addr2, big.NewInt(1000), params.TxGas, nil, nil).SignECDSA(key2)
@@ -907,13 +980,13 @@ func TestChainTxReorgs(t *testing.T) {
 	})
 	// Import the chain. This runs all block validation rules.
 	evmux := &event.TypeMux{}
-	blockchain, _ := NewBlockChain(db, FakePow{}, evmux)
+	blockchain, _ := NewBlockChain(db, MainNetChainConfig, FakePow{}, evmux)
 	if i, err := blockchain.InsertChain(chain); err != nil {
 		t.Fatalf("failed to insert original chain[%d]: %v", i, err)
 	}
 
 	// overwrite the old chain
-	chain, _ = GenerateChain(genesis, db, 5, func(i int, gen *BlockGen) {
+	chain, _ = GenerateChain(MainNetChainConfig, genesis, db, 5, func(i int, gen *BlockGen) {
 		switch i {
 		case 0:
 			pastAdd, _ = types.NewTransaction(gen.TxNonce(addr3), addr3, big.NewInt(1000), params.TxGas, nil, nil).SignECDSA(key3)
@@ -963,3 +1036,270 @@ func TestChainTxReorgs(t *testing.T) {
 		}
 	}
 }
+
+// Tests that a pruning chain only retains the recent states in memory, falls back
+// to an available state when rewound, and persists its head state on shutdown.
+func TestStatePruning(t *testing.T) {
+	genDb, _ := vecdb.NewMemDatabase()
+	genesis, _ := WriteTestNetGenesisBlock(genDb, 0)
+	blocks := makeBlockChain(genesis, 2*triesInMemory, genDb, canonicalSeed)
+	head := uint64(len(blocks))
+
+	db, _ := vecdb.NewMemDatabase()
+	WriteTestNetGenesisBlock(db, 0)
+
+	chain, _ := NewBlockChain(state.NewNodeCache(db), MainNetChainConfig, FakePow{}, &event.TypeMux{})
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	for _, block := range blocks {
+		_, err := state.New(block.Root(), chain.chainDb)
+		if want := block.NumberU64()+triesInMemory > head; (err == nil) != want {
+			t.Errorf("block #%d: state availability mismatch: have %v, want %v", block.NumberU64(), err == nil, want)
+		}
+		if _, err := state.New(block.Root(), db); err == nil {
+			t.Errorf("block #%d: state persisted before shutdown", block.NumberU64())
+		}
+	}
+	// Rewind within and beyond the retained states
+	chain.SetHead(head - 10)
+	if number := chain.CurrentBlock().NumberU64(); number != head-10 {
+		t.Errorf("rewind within retained states: head mismatch: have #%d, want #%d", number, head-10)
+	}
+	chain.SetHead(triesInMemory / 2)
+	if number := chain.CurrentBlock().NumberU64(); number != 0 {
+		t.Errorf("rewind beyond retained states: head mismatch: have #%d, want #0", number)
+	}
+	if number := chain.CurrentHeader().Number.Uint64(); number != 0 {
+		t.Errorf("rewind beyond retained states: head header mismatch: have #%d, want #0", number)
+	}
+	if hash := GetCanonicalHash(db, 1); hash != (common.Hash{}) {
+		t.Errorf("rewind beyond retained states: canonical hash #1 retained: %x", hash)
+	}
+	// Reimport the chain and ensure the head state survives a restart
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to reinsert chain: %v", err)
+	}
+	chain.Stop()
+
+	chain, _ = NewBlockChain(state.NewNodeCache(db), MainNetChainConfig, FakePow{}, &event.TypeMux{})
+	if number := chain.CurrentBlock().NumberU64(); number != head {
+		t.Errorf("restarted head mismatch: have #%d, want #%d", number, head)
+	}
+	if _, err := state.New(blocks[len(blocks)-1].Root(), db); err != nil {
+		t.Errorf("head state not persisted: %v", err)
+	}
+}
+
+// Tests that a pruning chain restarted without persisting its head state rewinds
+// its head header, fast block and canonical numbering along with the head block.
+func TestStatePruningCrashRewind(t *testing.T) {
+	genDb, _ := vecdb.NewMemDatabase()
+	genesis, _ := WriteTestNetGenesisBlock(genDb, 0)
+	blocks := makeBlockChain(genesis, triesInMemory/2, genDb, canonicalSeed)
+
+	db, _ := vecdb.NewMemDatabase()
+	WriteTestNetGenesisBlock(db, 0)
+
+	chain, _ := NewBlockChain(state.NewNodeCache(db), MainNetChainConfig, FakePow{}, &event.TypeMux{})
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	// Restart without stopping the chain, losing the cached states
+	chain, _ = NewBlockChain(state.NewNodeCache(db), MainNetChainConfig, FakePow{}, &event.TypeMux{})
+	if number := chain.CurrentBlock().NumberU64(); number != 0 {
+		t.Errorf("head block mismatch: have #%d, want #0", number)
+	}
+	if number := chain.CurrentHeader().Number.Uint64(); number != 0 {
+		t.Errorf("head header mismatch: have #%d, want #0", number)
+	}
+	if number := chain.CurrentFastBlock().NumberU64(); number != 0 {
+		t.Errorf("head fast block mismatch: have #%d, want #0", number)
+	}
+	for _, block := range blocks {
+		if hash := GetCanonicalHash(db, block.NumberU64()); hash != (common.Hash{}) {
+			t.Errorf("block #%d: canonical hash retained: %x", block.NumberU64(), hash)
+		}
+	}
+	// Reimport the chain on top of the rewound head
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to reinsert chain: %v", err)
+	}
+	if head := chain.CurrentBlock(); head.Hash() != blocks[len(blocks)-1].Hash() {
+		t.Errorf("reinserted head mismatch: have #%d [%x], want #%d", head.NumberU64(), head.Hash(), len(blocks))
+	}
+}
+
+// Tests that the committed state of a block failing to be written is released
+// from the trie node cache.
+func TestStatePruningDiscard(t *testing.T) {
+	db, _ := vecdb.NewMemDatabase()
+	genesis, _ := WriteTestNetGenesisBlock(db, 0)
+
+	cache := state.NewNodeCache(db)
+	chain, _ := NewBlockChain(cache, MainNetChainConfig, FakePow{}, &event.TypeMux{})
+	size := cache.Size()
+
+	// Commit the state of a block with an unknown parent into the cache
+	statedb, _ := state.New(genesis.Root(), cache)
+	statedb.AddBalance(common.Address{1}, big.NewInt(1))
+	root, err := statedb.Commit()
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if cache.Size() == size {
+		t.Fatalf("committed state not cached")
+	}
+	block := types.NewBlockWithHeader(&types.Header{ParentHash: common.Hash{1}, Number: big.NewInt(1), Root: root, Difficulty: big.NewInt(1)})
+	if _, err := chain.WriteBlock(block); err == nil {
+		t.Fatalf("block with unknown parent written")
+	}
+	if have := cache.Size(); have != size {
+		t.Errorf("cache size mismatch: have %v, want %v", have, size)
+	}
+}
+
+// Tests that the logs of the blocks dropped from the canonical chain during a
+// reorganisation are announced as removed.
+func TestLogReorgs(t *testing.T) {
+	params.MinGasLimit = big.NewInt(125000)      // Minimum the gas limit may ever be.
+	params.GenesisGasLimit = big.NewInt(3141592) // Gas limit of the Genesis block.
+
+	var (
+		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr1   = crypto.PubkeyToAddress(key1.PublicKey)
+		db, _   = vecdb.NewMemDatabase()
+		// this code generates a log
+		code = common.Hex2Bytes("60606040525b7f24ec1d3ff24c2f6ff210738839dbc339cd45a5294d85c79361016243157aae7b60405180905060405180910390a15b600a8060416000396000f360606040526008565b00")
+	)
+	genesis := WriteGenesisBlockForTesting(db, GenesisAccount{addr1, big.NewInt(10000000000000)})
+
+	evmux := &event.TypeMux{}
+	blockchain, _ := NewBlockChain(db, MainNetChainConfig, FakePow{}, evmux)
+
+	subs := evmux.Subscribe(RemovedLogsEvent{})
+	defer subs.Unsubscribe()
+
+	chain, _ := GenerateChain(MainNetChainConfig, genesis, db, 2, func(i int, gen *BlockGen) {
+		if i == 1 {
+			tx, err := types.NewContractCreation(gen.TxNonce(addr1), new(big.Int), big.NewInt(1000000), new(big.Int), code).SignECDSA(key1)
+			if err != nil {
+				t.Fatalf("failed to create tx: %v", err)
+			}
+			gen.AddTx(tx)
+		}
+	})
+	if _, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	var logs vm.Logs
+	for _, receipt := range GetBlockReceipts(db, chain[1].Hash()) {
+		logs = append(logs, receipt.Logs...)
+	}
+	if len(logs) == 0 {
+		t.Fatalf("no logs generated by the original chain")
+	}
+	// Replace the chain with a longer one without the log generating transaction
+	chain, _ = GenerateChain(MainNetChainConfig, genesis, db, 3, func(i int, gen *BlockGen) {})
+	if _, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert forked chain: %v", err)
+	}
+	select {
+	case ev := <-subs.Chan():
+		removed := ev.Data.(RemovedLogsEvent).Logs
+		if len(removed) != len(logs) {
+			t.Fatalf("removed log count mismatch: have %d, want %d", len(removed), len(logs))
+		}
+		for i, log := range removed {
+			if !log.Removed {
+				t.Errorf("log %d: not flagged as removed", i)
+			}
+			if log.TxHash != logs[i].TxHash || log.Address != logs[i].Address {
+				t.Errorf("log %d: mismatch: have %v, want %v", i, log, logs[i])
+			}
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("timeout waiting for removed logs event")
+	}
+}
+
+// Tests that the address transaction index is maintained for canonical blocks
+// and unwound when the blocks are reorged out of the chain.
+func TestAddrTxIndexReorgs(t *testing.T) {
+	var (
+		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		key2, _ = crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+		addr1   = crypto.PubkeyToAddress(key1.PublicKey)
+		addr2   = crypto.PubkeyToAddress(key2.PublicKey)
+		addr3   = common.HexToAddress("0x3333333333333333333333333333333333333333")
+		db, _   = vecdb.NewMemDatabase()
+	)
+	genesis := WriteGenesisBlockForTesting(db,
+		GenesisAccount{addr1, big.NewInt(1000000)},
+		GenesisAccount{addr2, big.NewInt(1000000)},
+	)
+	blockchain, _ := NewBlockChain(db, MainNetChainConfig, FakePow{}, &event.TypeMux{})
+	blockchain.SetAddrTxIndexing(true)
+
+	// Create a chain with a transfer from addr1 to addr2 and one from addr2 to addr3
+	chain, _ := GenerateChain(MainNetChainConfig, genesis, db, 2, func(i int, gen *BlockGen) {
+		switch i {
+		case 0:
+			tx, _ := types.NewTransaction(gen.TxNonce(addr1), addr2, big.NewInt(1000), params.TxGas, nil, nil).SignECDSA(key1)
+			gen.AddTx(tx)
+		case 1:
+			tx, _ := types.NewTransaction(gen.TxNonce(addr2), addr3, big.NewInt(1000), params.TxGas, nil, nil).SignECDSA(key2)
+			gen.AddTx(tx)
+		}
+	})
+	if _, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	tx1, tx2 := chain[0].Transactions()[0].Hash(), chain[1].Transactions()[0].Hash()
+
+	tests := []struct {
+		addr common.Address
+		want []common.Hash
+	}{
+		{addr1, []common.Hash{tx1}},
+		{addr2, []common.Hash{tx1, tx2}},
+		{addr3, []common.Hash{tx2}},
+	}
+	for i, tt := range tests {
+		if have := GetAddrTransactions(db, tt.addr, 0, 10); !reflect.DeepEqual(have, tt.want) {
+			t.Errorf("test %d: indexed transactions mismatch: have %x, want %x", i, have, tt.want)
+		}
+	}
+	// Check the paging of the results
+	if have := GetAddrTransactions(db, addr2, 1, 10); !reflect.DeepEqual(have, []common.Hash{tx2}) {
+		t.Errorf("offset transactions mismatch: have %x, want %x", have, []common.Hash{tx2})
+	}
+	if have := GetAddrTransactions(db, addr2, 0, 1); !reflect.DeepEqual(have, []common.Hash{tx1}) {
+		t.Errorf("limited transactions mismatch: have %x, want %x", have, []common.Hash{tx1})
+	}
+	// Replace the second block with a longer fork only containing a transfer to addr3 by addr1
+	forks, _ := GenerateChain(MainNetChainConfig, chain[0], db, 2, func(i int, gen *BlockGen) {
+		if i == 0 {
+			tx, _ := types.NewTransaction(gen.TxNonce(addr1), addr3, big.NewInt(1000), params.TxGas, nil, nil).SignECDSA(key1)
+			gen.AddTx(tx)
+		}
+	})
+	if _, err := blockchain.InsertChain(forks); err != nil {
+		t.Fatalf("failed to insert forked chain: %v", err)
+	}
+	tx3 := forks[0].Transactions()[0].Hash()
+
+	tests = []struct {
+		addr common.Address
+		want []common.Hash
+	}{
+		{addr1, []common.Hash{tx1, tx3}},
+		{addr2, []common.Hash{tx1}},
+		{addr3, []common.Hash{tx3}},
+	}
+	for i, tt := range tests {
+		if have := GetAddrTransactions(db, tt.addr, 0, 10); !reflect.DeepEqual(have, tt.want) {
+			t.Errorf("reorg test %d: indexed transactions mismatch: have %x, want %x", i, have, tt.want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2016 The go-vector Authors
+// This file is part of the go-vector library.
+//
+// The go-vector library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vector library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vector library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/vector/go-vector/core/vm"
+	"github.com/vector/go-vector/params"
+)
+
+var ChainConfigNotFoundErr = errors.New("ChainConfig not found") // general config not found error
+
+var (
+	// MainNetChainConfig is the chain configuration of the main network.
+	MainNetChainConfig = &ChainConfig{HomesteadBlock: params.MainNetHomesteadBlock}
+
+	// TestNetChainConfig is the chain configuration of the test network.
+	TestNetChainConfig = &ChainConfig{HomesteadBlock: params.TestNetHomesteadBlock}
+)
+
+// ChainConfig is the core config which determines the blockchain settings.
+//
+// ChainConfig is stored in the database on a per block basis. This means
+// that any network, identified by its genesis block, can have its own
+// set of configuration options.
+type ChainConfig struct {
+	HomesteadBlock  *big.Int `json:"homesteadBlock"`            // Homestead switch block (nil = no fork, 0 = already homestead)
+	MetropolisBlock *big.Int `json:"metropolisBlock,omitempty"` // Metropolis switch block (nil = no fork, 0 = already metropolis)
+}
+
+// IsHomestead returns whether num is either equal to the homestead block or greater.
+func (c *ChainConfig) IsHomestead(num *big.Int) bool {
+	if c.HomesteadBlock == nil || num == nil {
+		return false
+	}
+	return num.Cmp(c.HomesteadBlock) >= 0
+}
+
+// IsMetropolis returns whether num is either equal to the metropolis block or
+// greater. Metropolis activates the modular exponentiation and the alt_bn128
+// precompiled contracts.
+func (c *ChainConfig) IsMetropolis(num *big.Int) bool {
+	if c.MetropolisBlock == nil || num == nil {
+		return false
+	}
+	return num.Cmp(c.MetropolisBlock) >= 0
+}
+
+// ChainConfig implements the vm.RuleSet interface
+var _ vm.RuleSet = (*ChainConfig)(nil)
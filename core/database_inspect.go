@@ -0,0 +1,153 @@
+// Copyright 2016 The go-vector Authors
+// This file is part of the go-vector library.
+//
+// The go-vector library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vector library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vector library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+
+	"github.com/vector/go-vector/common"
+	"github.com/vector/go-vector/vecdb"
+)
+
+// DatabaseStat is the number of entries and their total size (keys and values)
+// of a single category of data stored in the chain database.
+type DatabaseStat struct {
+	Name  string
+	Count uint64
+	Size  common.StorageSize
+}
+
+// Names of the data categories reported by InspectDatabase.
+const (
+	statHeaders      = "Headers"
+	statBodies       = "Bodies"
+	statTds          = "Total difficulties"
+	statBlockRcpts   = "Block receipts"
+	statTxRcpts      = "Transaction receipts"
+	statTxs          = "Transactions"
+	statTxLookups    = "Transaction lookups"
+	statAddrTxs      = "Address transaction index"
+	statCanonical    = "Canonical hashes"
+	statMipmaps      = "Mipmap blooms"
+	statTrieNodes    = "Trie nodes and code"
+	statAncientIndex = "Ancient hash indices"
+	statLegacyBlocks = "Legacy blocks"
+	statDapp         = "Dapp data"
+	statOther        = "Other"
+)
+
+// inspectOrder is the order in which the categories are reported.
+var inspectOrder = []string{
+	statHeaders, statBodies, statTds, statBlockRcpts, statTxRcpts, statTxs, statTxLookups,
+	statAddrTxs, statCanonical, statMipmaps, statTrieNodes, statAncientIndex, statLegacyBlocks, statDapp, statOther,
+}
+
+// InspectDatabase iterates over the entire chain database, counting the entries
+// and their sizes for each category of data stored. The entries of the dapp table
+// are reported on their own, apart from the chain data. If the database is backed
+// by an ancient store, the frozen tables are reported too.
+func InspectDatabase(db vecdb.Database) ([]*DatabaseStat, error) {
+	stats := make(map[string]*DatabaseStat)
+	for _, name := range inspectOrder {
+		stats[name] = &DatabaseStat{Name: name}
+	}
+	account := func(name string, key, value []byte) {
+		stats[name].Count++
+		stats[name].Size += common.StorageSize(len(key) + len(value))
+	}
+	// Transactions and trie nodes are both keyed by a bare hash, a transaction
+	// being immediately followed by its lookup entry in iteration order.
+	var pendKey, pendVal []byte
+
+	it := db.NewIterator(nil, nil)
+	defer it.Release()
+
+	for it.Next() {
+		key, value := it.Key(), it.Value()
+
+		if pendKey != nil {
+			if len(key) == len(pendKey)+len(txMetaSuffix) && bytes.HasPrefix(key, pendKey) && bytes.HasSuffix(key, txMetaSuffix) {
+				account(statTxs, pendKey, pendVal)
+			} else {
+				account(statTrieNodes, pendKey, pendVal)
+			}
+			pendKey, pendVal = nil, nil
+		}
+		hashLen := len(common.Hash{})
+
+		switch {
+		case bytes.HasPrefix(key, dappPrefix):
+			account(statDapp, key, value)
+		case len(key) == hashLen:
+			pendKey, pendVal = common.CopyBytes(key), common.CopyBytes(value)
+		case len(key) == hashLen+len(txMetaSuffix) && bytes.HasSuffix(key, txMetaSuffix):
+			account(statTxLookups, key, value)
+		case bytes.HasPrefix(key, blockHashPrefix):
+			account(statLegacyBlocks, key, value)
+		case bytes.HasPrefix(key, blockNumPrefix):
+			account(statCanonical, key, value)
+		case bytes.HasPrefix(key, blockPrefix) && len(key) > len(blockPrefix)+hashLen:
+			switch suffix := key[len(blockPrefix)+hashLen:]; {
+			case bytes.Equal(suffix, headerSuffix):
+				account(statHeaders, key, value)
+			case bytes.Equal(suffix, bodySuffix):
+				account(statBodies, key, value)
+			case bytes.Equal(suffix, tdSuffix):
+				account(statTds, key, value)
+			case bytes.Equal(suffix, ancientSuffix):
+				account(statAncientIndex, key, value)
+			default:
+				account(statOther, key, value)
+			}
+		case bytes.HasPrefix(key, blockReceiptsPrefix):
+			account(statBlockRcpts, key, value)
+		case bytes.HasPrefix(key, receiptsPrefix):
+			account(statTxRcpts, key, value)
+		case bytes.HasPrefix(key, addrTxPrefix):
+			account(statAddrTxs, key, value)
+		case bytes.HasPrefix(key, mipmapPre):
+			account(statMipmaps, key, value)
+		default:
+			account(statOther, key, value)
+		}
+	}
+	if pendKey != nil {
+		account(statTrieNodes, pendKey, pendVal)
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+	result := make([]*DatabaseStat, 0, len(inspectOrder))
+	for _, name := range inspectOrder {
+		result = append(result, stats[name])
+	}
+	// Append the frozen tables if an ancient store is attached
+	if adb, ok := db.(*AncientDatabase); ok {
+		for _, name := range freezerTables {
+			table := adb.freezer.tables[name]
+
+			table.lock.RLock()
+			result = append(result, &DatabaseStat{
+				Name:  "Ancient " + name,
+				Count: table.items,
+				Size:  common.StorageSize(table.size + table.items*indexEntrySize),
+			})
+			table.lock.RUnlock()
+		}
+	}
+	return result, nil
+}
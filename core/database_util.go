@@ -19,17 +19,24 @@ package core
 import (
 	"bytes"
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"math/big"
 
 	"github.com/vector/go-vector/common"
 	"github.com/vector/go-vector/core/types"
+	"github.com/vector/go-vector/crypto"
 	"github.com/vector/go-vector/vecdb"
 	"github.com/vector/go-vector/logger"
 	"github.com/vector/go-vector/logger/glog"
 	"github.com/vector/go-vector/rlp"
 )
 
+// DappTablePrefix is the key prefix of the dapp table, which shares the keyspace
+// of the chain database with the unprefixed chain data. Code iterating over the
+// whole chain database must skip the keys of the table.
+const DappTablePrefix = "dapp/"
+
 var (
 	headHeaderKey = []byte("LastHeader")
 	headBlockKey  = []byte("LastBlock")
@@ -46,10 +53,16 @@ var (
 	receiptsPrefix      = []byte("receipts-")
 	blockReceiptsPrefix = []byte("receipts-block-")
 
+	addrTxPrefix = []byte("addr-tx-") // addrTxPrefix + address + num (uint64 big endian) + index (uint32 big endian) -> tx hash
+
 	mipmapPre    = []byte("mipmap-log-bloom-")
 	MIPMapLevels = []uint64{1000000, 500000, 100000, 50000, 1000}
 
+	configPrefix = []byte("vector-config-") // config prefix for the db
+
 	blockHashPrefix = []byte("block-hash-") // [deprecated by the header/block split, remove eventually]
+
+	dappPrefix = []byte(DappTablePrefix)
 )
 
 // GetCanonicalHash retrieves a hash assigned to a canonical block number.
@@ -413,6 +426,76 @@ func WriteReceipts(db vecdb.Database, receipts types.Receipts) error {
 	return nil
 }
 
+// addrTxKey returns the address index key of the transaction at the given
+// position within the canonical chain.
+func addrTxKey(addr common.Address, number uint64, index uint32) []byte {
+	key := make([]byte, len(addrTxPrefix)+len(addr)+8+4)
+	n := copy(key, addrTxPrefix)
+	n += copy(key[n:], addr[:])
+	binary.BigEndian.PutUint64(key[n:], number)
+	binary.BigEndian.PutUint32(key[n+8:], index)
+	return key
+}
+
+// txAddresses returns the accounts touched by a transaction: its sender and
+// either its recipient or the address of the contract it creates.
+func txAddresses(tx *types.Transaction) ([]common.Address, error) {
+	from, err := tx.FromFrontier()
+	if err != nil {
+		return nil, err
+	}
+	if to := tx.To(); to != nil {
+		if *to == from {
+			return []common.Address{from}, nil
+		}
+		return []common.Address{from, *to}, nil
+	}
+	return []common.Address{from, crypto.CreateAddress(from, tx.Nonce())}, nil
+}
+
+// WriteAddrTxIndex stores an address index entry for the sender and recipient
+// of every transaction in the block, allowing the transaction history of an
+// account to be retrieved with GetAddrTransactions.
+func WriteAddrTxIndex(db vecdb.Database, block *types.Block) error {
+	batch := db.NewBatch()
+
+	number := block.NumberU64()
+	for i, tx := range block.Transactions() {
+		addrs, err := txAddresses(tx)
+		if err != nil {
+			return err
+		}
+		for _, addr := range addrs {
+			if err := batch.Put(addrTxKey(addr, number, uint32(i)), tx.Hash().Bytes()); err != nil {
+				return err
+			}
+		}
+	}
+	if err := batch.Write(); err != nil {
+		glog.Fatalf("failed to store address index into database: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetAddrTransactions retrieves the hashes of the canonical transactions sent
+// from or to the given address, ordered by their position in the chain. The
+// first skip entries are omitted and at most limit hashes are returned.
+func GetAddrTransactions(db vecdb.Database, addr common.Address, skip, limit int) []common.Hash {
+	it := db.NewIterator(append(common.CopyBytes(addrTxPrefix), addr[:]...), nil)
+	defer it.Release()
+
+	var hashes []common.Hash
+	for len(hashes) < limit && it.Next() {
+		if skip > 0 {
+			skip--
+			continue
+		}
+		hashes = append(hashes, common.BytesToHash(it.Value()))
+	}
+	return hashes
+}
+
 // DeleteCanonicalHash removes the number to hash canonical mapping.
 func DeleteCanonicalHash(db vecdb.Database, number uint64) {
 	db.Delete(append(blockNumPrefix, big.NewInt(int64(number)).Bytes()...))
@@ -452,6 +535,21 @@ func DeleteTransaction(db vecdb.Database, hash common.Hash) {
 	db.Delete(append(hash.Bytes(), txMetaSuffix...))
 }
 
+// DeleteAddrTxIndex removes the address index entries of all the transactions
+// contained in the block.
+func DeleteAddrTxIndex(db vecdb.Database, block *types.Block) {
+	number := block.NumberU64()
+	for i, tx := range block.Transactions() {
+		addrs, err := txAddresses(tx)
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			db.Delete(addrTxKey(addr, number, uint32(i)))
+		}
+	}
+}
+
 // DeleteReceipt removes all receipt data associated with a transaction hash.
 func DeleteReceipt(db vecdb.Database, hash common.Hash) {
 	db.Delete(append(receiptsPrefix, hash.Bytes()...))
@@ -513,3 +611,48 @@ func GetMipmapBloom(db vecdb.Database, number, level uint64) types.Bloom {
 	bloomDat, _ := db.Get(mipmapKey(number, level))
 	return types.BytesToBloom(bloomDat)
 }
+
+// WriteChainConfig writes the chain config settings to the database.
+func WriteChainConfig(db vecdb.Database, hash common.Hash, cfg *ChainConfig) error {
+	// short circuit and ignore if nil config. GetChainConfig
+	// will return a default.
+	if cfg == nil {
+		return nil
+	}
+	jsonChainConfig, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	return db.Put(append(configPrefix, hash[:]...), jsonChainConfig)
+}
+
+// GetChainConfig will fetch the network settings based on the given hash.
+func GetChainConfig(db vecdb.Database, hash common.Hash) (*ChainConfig, error) {
+	jsonChainConfig, _ := db.Get(append(configPrefix, hash[:]...))
+	if len(jsonChainConfig) == 0 {
+		return nil, ChainConfigNotFoundErr
+	}
+	var config ChainConfig
+	if err := json.Unmarshal(jsonChainConfig, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// LoadChainConfig retrieves the chain configuration stored along the genesis
+// block of the database. If none was stored (e.g. the database predates chain
+// configs), the given default is stored and returned instead.
+func LoadChainConfig(db vecdb.Database, def *ChainConfig) (*ChainConfig, error) {
+	genesis := GetCanonicalHash(db, 0)
+	if (genesis == common.Hash{}) {
+		return def, nil
+	}
+	config, err := GetChainConfig(db, genesis)
+	if err != ChainConfigNotFoundErr {
+		return config, err
+	}
+	if err := WriteChainConfig(db, genesis, def); err != nil {
+		return nil, err
+	}
+	return def, nil
+}
@@ -19,9 +19,11 @@ package core
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"math/big"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/vector/go-vector/common"
@@ -561,7 +563,7 @@ func TestMipmapChain(t *testing.T) {
 	defer db.Close()
 
 	genesis := WriteGenesisBlockForTesting(db, GenesisAccount{addr, big.NewInt(1000000)})
-	chain, receipts := GenerateChain(genesis, db, 1010, func(i int, gen *BlockGen) {
+	chain, receipts := GenerateChain(MainNetChainConfig, genesis, db, 1010, func(i int, gen *BlockGen) {
 		var receipts types.Receipts
 		switch i {
 		case 1:
@@ -607,3 +609,139 @@ func TestMipmapChain(t *testing.T) {
 		t.Error("address was included in bloom and should not have")
 	}
 }
+
+// Tests that the database inspection classifies the stored entries correctly.
+func TestInspectDatabase(t *testing.T) {
+	db, _ := vecdb.NewMemDatabase()
+
+	tx1 := types.NewTransaction(1, common.BytesToAddress([]byte{0x11}), big.NewInt(111), big.NewInt(1111), big.NewInt(11111), nil)
+	tx2 := types.NewTransaction(2, common.BytesToAddress([]byte{0x22}), big.NewInt(222), big.NewInt(2222), big.NewInt(22222), nil)
+	block := types.NewBlock(&types.Header{Number: big.NewInt(314)}, []*types.Transaction{tx1, tx2}, nil, nil)
+
+	WriteBlock(db, block)
+	WriteTd(db, block.Hash(), big.NewInt(1))
+	WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+	WriteTransactions(db, block)
+	WriteBlockReceipts(db, block.Hash(), nil)
+	db.Put(crypto.Sha3([]byte("node")), []byte("node")) // fake trie node
+	WriteHeadBlockHash(db, block.Hash())
+
+	// Dapp entries share the keyspace, even if looking like chain data
+	dapp := vecdb.NewTable(db, DappTablePrefix)
+	dapp.Put([]byte("key"), []byte("value"))
+	dapp.Put(make([]byte, len(common.Hash{})-len(DappTablePrefix)), []byte("hash sized"))
+
+	stats, err := InspectDatabase(db)
+	if err != nil {
+		t.Fatalf("failed to inspect database: %v", err)
+	}
+	want := map[string]uint64{
+		statHeaders: 1, statBodies: 1, statTds: 1, statCanonical: 1, statBlockRcpts: 1,
+		statTxs: 2, statTxLookups: 2, statTrieNodes: 1, statDapp: 2, statOther: 1,
+	}
+	for _, stat := range stats {
+		if stat.Count != want[stat.Name] {
+			t.Errorf("%s: count mismatch: have %d, want %d", stat.Name, stat.Count, want[stat.Name])
+		}
+	}
+}
+
+// Tests that rejected blocks are retained in a bounded ring, most recent first.
+func TestBadBlockStorage(t *testing.T) {
+	db, _ := vecdb.NewMemDatabase()
+
+	if blocks := GetBadBlocks(db); len(blocks) != 0 {
+		t.Fatalf("non existent bad blocks returned: %v", blocks)
+	}
+	for i := 0; i < badBlockLimit+2; i++ {
+		block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(int64(i)), Extra: []byte("bad block")})
+		if err := WriteBadBlock(db, block, fmt.Sprintf("peer-%d", i), fmt.Errorf("error %d", i)); err != nil {
+			t.Fatalf("failed to write bad block %d: %v", i, err)
+		}
+		// Reinserting the same block should be a noop
+		if err := WriteBadBlock(db, block, "other", fmt.Errorf("other")); err != nil {
+			t.Fatalf("failed to rewrite bad block %d: %v", i, err)
+		}
+	}
+	blocks := GetBadBlocks(db)
+	if len(blocks) != badBlockLimit {
+		t.Fatalf("bad block count mismatch: have %d, want %d", len(blocks), badBlockLimit)
+	}
+	for i, bad := range blocks {
+		number := badBlockLimit + 1 - i
+		if bad.Block.NumberU64() != uint64(number) {
+			t.Errorf("bad block %d: number mismatch: have %v, want %d", i, bad.Block.Number(), number)
+		}
+		if want := fmt.Sprintf("peer-%d", number); bad.Origin != want {
+			t.Errorf("bad block %d: origin mismatch: have %s, want %s", i, bad.Origin, want)
+		}
+		if want := fmt.Sprintf("error %d", number); bad.Error != want {
+			t.Errorf("bad block %d: error mismatch: have %s, want %s", i, bad.Error, want)
+		}
+	}
+}
+
+// Tests that the chain configuration in the genesis specification is persisted
+// along the genesis block and can be retrieved.
+func TestChainConfigStorage(t *testing.T) {
+	db, _ := vecdb.NewMemDatabase()
+
+	genesis, err := WriteGenesisBlock(db, strings.NewReader(`{
+		"config": {"homesteadBlock": 42},
+		"difficulty": "0x20000",
+		"gasLimit": "0x2FEFD8",
+		"alloc": {}
+	}`))
+	if err != nil {
+		t.Fatalf("failed to write genesis block: %v", err)
+	}
+	config, err := GetChainConfig(db, genesis.Hash())
+	if err != nil {
+		t.Fatalf("failed to retrieve chain config: %v", err)
+	}
+	if config.HomesteadBlock == nil || config.HomesteadBlock.Cmp(big.NewInt(42)) != 0 {
+		t.Errorf("homestead block mismatch: have %v, want %v", config.HomesteadBlock, 42)
+	}
+	if config.IsHomestead(big.NewInt(41)) || !config.IsHomestead(big.NewInt(42)) {
+		t.Errorf("homestead transition not at block 42")
+	}
+	// Genesis blocks without a configuration should have none stored
+	genesis, err = WriteTestNetGenesisBlock(db, 0)
+	if err != nil {
+		t.Fatalf("failed to write testnet genesis block: %v", err)
+	}
+	if config, err := GetChainConfig(db, genesis.Hash()); err != ChainConfigNotFoundErr {
+		t.Errorf("chain config mismatch: have %v/%v, want nil/%v", config, err, ChainConfigNotFoundErr)
+	}
+}
+
+// Tests that loading the chain configuration prefers the stored one, and stores
+// the default if none was.
+func TestLoadChainConfig(t *testing.T) {
+	db, _ := vecdb.NewMemDatabase()
+
+	// Empty databases use the default without storing it
+	if config, err := LoadChainConfig(db, TestNetChainConfig); config != TestNetChainConfig || err != nil {
+		t.Fatalf("empty database: config mismatch: have %v/%v, want %v/nil", config, err, TestNetChainConfig)
+	}
+	// Databases without a stored configuration get the default
+	genesis, _ := WriteTestNetGenesisBlock(db, 0)
+	if config, err := LoadChainConfig(db, TestNetChainConfig); config != TestNetChainConfig || err != nil {
+		t.Fatalf("unconfigured database: config mismatch: have %v/%v, want %v/nil", config, err, TestNetChainConfig)
+	}
+	stored, err := GetChainConfig(db, genesis.Hash())
+	if err != nil {
+		t.Fatalf("default config not stored: %v", err)
+	}
+	if stored.HomesteadBlock.Cmp(TestNetChainConfig.HomesteadBlock) != 0 {
+		t.Errorf("stored homestead block mismatch: have %v, want %v", stored.HomesteadBlock, TestNetChainConfig.HomesteadBlock)
+	}
+	// Stored configurations take precedence over the default
+	config, err := LoadChainConfig(db, MainNetChainConfig)
+	if err != nil {
+		t.Fatalf("failed to load stored config: %v", err)
+	}
+	if config.HomesteadBlock.Cmp(TestNetChainConfig.HomesteadBlock) != 0 {
+		t.Errorf("loaded homestead block mismatch: have %v, want %v", config.HomesteadBlock, TestNetChainConfig.HomesteadBlock)
+	}
+}
@@ -201,3 +201,13 @@ func IsGasLimitErr(err error) bool {
 func (err *GasLimitErr) Error() string {
 	return fmt.Sprintf("GasLimit reached. Have %d gas, transaction requires %d", err.Have, err.Want)
 }
+
+// GenesisMismatchError is returned when a genesis block is written into a
+// database already holding a different one.
+type GenesisMismatchError struct {
+	Stored, New common.Hash
+}
+
+func (err *GenesisMismatchError) Error() string {
+	return fmt.Sprintf("database already contains an incompatible genesis block (have %x, new %x)", err.Stored[:8], err.New[:8])
+}
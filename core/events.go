@@ -27,6 +27,23 @@ import (
 // TxPreEvent is posted when a transaction enters the transaction pool.
 type TxPreEvent struct{ Tx *types.Transaction }
 
+// TxDroppedEvent is posted when a transaction leaves the transaction pool
+// without being replaced. The reason is one of ErrUnderpriced, ErrNonce,
+// ErrInsufficientFunds, ErrExpired or ErrQueueLimit. Transactions included in
+// the chain are removed without an event, ErrNonce is reported if another
+// transaction used their nonce.
+type TxDroppedEvent struct {
+	Tx     *types.Transaction
+	Reason error
+}
+
+// TxReplacedEvent is posted when a transaction in the transaction pool is
+// replaced by another one of the same nonce paying a higher gas price.
+type TxReplacedEvent struct {
+	Old *types.Transaction
+	New *types.Transaction
+}
+
 // TxPostEvent is posted when a transaction has been processed.
 type TxPostEvent struct{ Tx *types.Transaction }
 
@@ -39,6 +56,10 @@ type NewMinedBlockEvent struct{ Block *types.Block }
 // RemovedTransactionEvent is posted when a reorg happens
 type RemovedTransactionEvent struct{ Txs types.Transactions }
 
+// RemovedLogsEvent is posted when a reorg happens, carrying the logs of the
+// blocks dropped from the canonical chain, each flagged as removed.
+type RemovedLogsEvent struct{ Logs vm.Logs }
+
 // ChainSplit is posted when a new head is detected
 type ChainSplitEvent struct {
 	Block *types.Block
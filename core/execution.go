@@ -18,6 +18,7 @@ package core
 
 import (
 	"math/big"
+	"time"
 
 	"github.com/vector/go-vector/common"
 	"github.com/vector/go-vector/core/vm"
@@ -27,14 +28,14 @@ import (
 
 // Call executes within the given contract
 func Call(env vm.Environment, caller vm.ContractRef, addr common.Address, input []byte, gas, gasPrice, value *big.Int) (ret []byte, err error) {
-	ret, _, err = exec(env, caller, &addr, &addr, input, env.Db().GetCode(addr), gas, gasPrice, value)
+	ret, _, err = exec(env, vm.CALL, caller, &addr, &addr, input, env.Db().GetCode(addr), gas, gasPrice, value)
 	return ret, err
 }
 
 // CallCode executes the given address' code as the given contract address
 func CallCode(env vm.Environment, caller vm.ContractRef, addr common.Address, input []byte, gas, gasPrice, value *big.Int) (ret []byte, err error) {
 	callerAddr := caller.Address()
-	ret, _, err = exec(env, caller, &callerAddr, &addr, input, env.Db().GetCode(addr), gas, gasPrice, value)
+	ret, _, err = exec(env, vm.CALLCODE, caller, &callerAddr, &addr, input, env.Db().GetCode(addr), gas, gasPrice, value)
 	return ret, err
 }
 
@@ -49,7 +50,7 @@ func DelegateCall(env vm.Environment, caller vm.ContractRef, addr common.Address
 
 // Create creates a new contract with the given code
 func Create(env vm.Environment, caller vm.ContractRef, code []byte, gas, gasPrice, value *big.Int) (ret []byte, address common.Address, err error) {
-	ret, address, err = exec(env, caller, nil, nil, nil, code, gas, gasPrice, value)
+	ret, address, err = exec(env, vm.CREATE, caller, nil, nil, nil, code, gas, gasPrice, value)
 	// Here we get an error if we run into maximum stack depth,
 	// See: https://github.com/vector/yellowpaper/pull/131
 	// and YP definitions for CREATE instruction
@@ -59,8 +60,18 @@ func Create(env vm.Environment, caller vm.ContractRef, code []byte, gas, gasPric
 	return ret, address, err
 }
 
-func exec(env vm.Environment, caller vm.ContractRef, address, codeAddr *common.Address, input, code []byte, gas, gasPrice, value *big.Int) (ret []byte, addr common.Address, err error) {
+func exec(env vm.Environment, typ vm.OpCode, caller vm.ContractRef, address, codeAddr *common.Address, input, code []byte, gas, gasPrice, value *big.Int) (ret []byte, addr common.Address, err error) {
 	evm := vm.NewVm(env)
+	if env.Tracer() != nil {
+		to, data := common.Address{}, input
+		if codeAddr != nil {
+			to = *codeAddr
+		} else {
+			to, data = crypto.CreateAddress(caller.Address(), env.Db().GetNonce(caller.Address())), code
+		}
+		done := capture(env, typ, caller.Address(), to, data, gas, value)
+		defer func() { done(ret, err) }()
+	}
 	// Depth check execution. Fail if we're trying to execute above the
 	// limit.
 	if env.Depth() > int(params.CallCreateDepth.Int64()) {
@@ -126,7 +137,7 @@ func exec(env vm.Environment, caller vm.ContractRef, address, codeAddr *common.A
 	// When an error was returned by the EVM or when setting the creation code
 	// above we revert to the snapshot and consume any gas remaining. Additionally
 	// when we're in homestead this also counts for code storage gas errors.
-	if err != nil && (params.IsHomestead(env.BlockNumber()) || err != vm.CodeStoreOutOfGasError) {
+	if err != nil && (env.RuleSet().IsHomestead(env.BlockNumber()) || err != vm.CodeStoreOutOfGasError) {
 		contract.UseGas(contract.Gas)
 
 		env.SetSnapshot(snapshotPreTransfer)
@@ -137,6 +148,10 @@ func exec(env vm.Environment, caller vm.ContractRef, address, codeAddr *common.A
 
 func execDelegateCall(env vm.Environment, caller vm.ContractRef, originAddr, toAddr, codeAddr *common.Address, input, code []byte, gas, gasPrice, value *big.Int) (ret []byte, addr common.Address, err error) {
 	evm := vm.NewVm(env)
+	if env.Tracer() != nil {
+		done := capture(env, vm.DELEGATECALL, caller.Address(), *codeAddr, input, gas, value)
+		defer func() { done(ret, err) }()
+	}
 	// Depth check execution. Fail if we're trying to execute above the
 	// limit.
 	if env.Depth() > int(params.CallCreateDepth.Int64()) {
@@ -168,6 +183,29 @@ func execDelegateCall(env vm.Environment, caller vm.ContractRef, originAddr, toA
 	return ret, addr, err
 }
 
+// capture reports a message call or contract creation about to be executed to
+// the tracer of the environment, returning the function to report its outcome
+// with. The outermost execution is reported as the start of the trace, nested
+// ones as entered frames.
+func capture(env vm.Environment, typ vm.OpCode, from, to common.Address, input []byte, gas, value *big.Int) func(ret []byte, err error) {
+	var (
+		tracer   = env.Tracer()
+		start    = time.Now()
+		startGas = new(big.Int).Set(gas)
+	)
+	// The gas is consumed in place by the executed contract, see vm.NewContract
+	if env.Depth() == 0 {
+		tracer.CaptureStart(from, to, typ == vm.CREATE, input, startGas, value)
+		return func(ret []byte, err error) {
+			tracer.CaptureEnd(ret, new(big.Int).Sub(startGas, gas), time.Since(start), err)
+		}
+	}
+	tracer.CaptureEnter(typ, from, to, input, startGas, value)
+	return func(ret []byte, err error) {
+		tracer.CaptureExit(ret, new(big.Int).Sub(startGas, gas), err)
+	}
+}
+
 // generic transfer method
 func Transfer(from, to vm.Account, amount *big.Int) {
 	from.SubBalance(amount)
@@ -0,0 +1,334 @@
+// Copyright 2016 The go-vector Authors
+// This file is part of the go-vector library.
+//
+// The go-vector library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vector library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vector library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// errOutOfBounds is returned if the item requested is not contained within
+	// the freezer table.
+	errOutOfBounds = errors.New("out of bounds")
+
+	// errOutOrderInsertion is returned if the user attempts to inject out-of-order
+	// items into the freezer.
+	errOutOrderInsertion = errors.New("the append operation is out-order")
+)
+
+const (
+	freezerHeaderTable     = "headers"  // Freezer table of the RLP encoded block headers
+	freezerBodiesTable     = "bodies"   // Freezer table of the RLP encoded block bodies
+	freezerReceiptTable    = "receipts" // Freezer table of the RLP encoded block receipts
+	freezerDifficultyTable = "diffs"    // Freezer table of the RLP encoded total difficulties
+
+	indexEntrySize = 8 // Size of an index entry: the big endian end offset of an item
+)
+
+// freezerTables is the list of tables maintained by the freezer, all of which
+// must contain exactly the same number of items.
+var freezerTables = []string{freezerHeaderTable, freezerBodiesTable, freezerReceiptTable, freezerDifficultyTable}
+
+// freezerTable is an append-only flat file store of opaque binary blobs. Items
+// are laid out back to back in a data file, the index file holding the end
+// offset of each of them.
+type freezerTable struct {
+	data  *os.File // Data file containing the concatenated items
+	index *os.File // Index file containing the end offsets of the items
+
+	items uint64 // Number of items stored in the table
+	size  uint64 // Number of bytes stored in the data file
+
+	lock sync.RWMutex // Mutex protecting the files and counters
+}
+
+// newFreezerTable opens the given table within a directory, truncating any
+// partially written item left over by an unclean shutdown.
+func newFreezerTable(dir, name string) (*freezerTable, error) {
+	data, err := os.OpenFile(filepath.Join(dir, name+".dat"), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	index, err := os.OpenFile(filepath.Join(dir, name+".idx"), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		data.Close()
+		return nil, err
+	}
+	table := &freezerTable{data: data, index: index}
+	if err := table.repair(); err != nil {
+		table.close()
+		return nil, err
+	}
+	return table, nil
+}
+
+// repair cross checks the index and data files, discarding any dangling index
+// entries or data bytes not fully committed by both.
+func (t *freezerTable) repair() error {
+	stat, err := t.index.Stat()
+	if err != nil {
+		return err
+	}
+	items := uint64(stat.Size()) / indexEntrySize
+
+	if stat, err = t.data.Stat(); err != nil {
+		return err
+	}
+	size := uint64(stat.Size())
+
+	// Drop trailing index entries pointing past the end of the data file
+	for items > 0 {
+		end, err := t.offset(items - 1)
+		if err != nil {
+			return err
+		}
+		if end <= size {
+			size = end
+			break
+		}
+		items--
+	}
+	if items == 0 {
+		size = 0
+	}
+	if err := t.index.Truncate(int64(items * indexEntrySize)); err != nil {
+		return err
+	}
+	if err := t.data.Truncate(int64(size)); err != nil {
+		return err
+	}
+	t.items, t.size = items, size
+	return nil
+}
+
+// offset retrieves the end offset of an item from the index file.
+func (t *freezerTable) offset(item uint64) (uint64, error) {
+	var buf [indexEntrySize]byte
+	if _, err := t.index.ReadAt(buf[:], int64(item*indexEntrySize)); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(buf[:]), nil
+}
+
+// append injects a binary blob at the end of the table. The item number must
+// match the current number of items to prevent gaps.
+func (t *freezerTable) append(item uint64, blob []byte) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if item != t.items {
+		return errOutOrderInsertion
+	}
+	if _, err := t.data.WriteAt(blob, int64(t.size)); err != nil {
+		return err
+	}
+	var buf [indexEntrySize]byte
+	binary.BigEndian.PutUint64(buf[:], t.size+uint64(len(blob)))
+	if _, err := t.index.WriteAt(buf[:], int64(t.items*indexEntrySize)); err != nil {
+		return err
+	}
+	t.items++
+	t.size += uint64(len(blob))
+	return nil
+}
+
+// retrieve looks up the binary blob stored at the given item number.
+func (t *freezerTable) retrieve(item uint64) ([]byte, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if item >= t.items {
+		return nil, errOutOfBounds
+	}
+	var start uint64
+	if item > 0 {
+		var err error
+		if start, err = t.offset(item - 1); err != nil {
+			return nil, err
+		}
+	}
+	end, err := t.offset(item)
+	if err != nil {
+		return nil, err
+	}
+	blob := make([]byte, end-start)
+	if _, err := t.data.ReadAt(blob, int64(start)); err != nil {
+		return nil, err
+	}
+	return blob, nil
+}
+
+// truncate discards any items beyond the given limit.
+func (t *freezerTable) truncate(items uint64) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.items <= items {
+		return nil
+	}
+	var size uint64
+	if items > 0 {
+		var err error
+		if size, err = t.offset(items - 1); err != nil {
+			return err
+		}
+	}
+	if err := t.index.Truncate(int64(items * indexEntrySize)); err != nil {
+		return err
+	}
+	if err := t.data.Truncate(int64(size)); err != nil {
+		return err
+	}
+	t.items, t.size = items, size
+	return nil
+}
+
+// sync flushes the table's files to disk, data first.
+func (t *freezerTable) sync() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if err := t.data.Sync(); err != nil {
+		return err
+	}
+	return t.index.Sync()
+}
+
+// close releases the table's file handles.
+func (t *freezerTable) close() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	var errs []error
+	if err := t.data.Close(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := t.index.Close(); err != nil {
+		errs = append(errs, err)
+	}
+	if errs != nil {
+		return fmt.Errorf("%v", errs)
+	}
+	return nil
+}
+
+// freezer is an append-only store of finalized chain data, holding a flat file
+// table for each kind of block data, indexed by block number.
+type freezer struct {
+	frozen uint64 // Number of blocks already frozen (atomic access)
+	tables map[string]*freezerTable
+}
+
+// newFreezer opens the freezer tables within a directory, aligning them to the
+// same number of items.
+func newFreezer(dir string) (*freezer, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	f := &freezer{tables: make(map[string]*freezerTable)}
+	for _, name := range freezerTables {
+		table, err := newFreezerTable(dir, name)
+		if err != nil {
+			f.close()
+			return nil, err
+		}
+		f.tables[name] = table
+	}
+	// Truncate all tables to the shortest one to discard partial appends
+	frozen := f.tables[freezerTables[0]].items
+	for _, table := range f.tables {
+		if table.items < frozen {
+			frozen = table.items
+		}
+	}
+	for _, table := range f.tables {
+		if err := table.truncate(frozen); err != nil {
+			f.close()
+			return nil, err
+		}
+	}
+	f.frozen = frozen
+	return f, nil
+}
+
+// ancients returns the number of blocks contained within the freezer.
+func (f *freezer) ancients() uint64 {
+	return atomic.LoadUint64(&f.frozen)
+}
+
+// retrieve returns the blob of the given kind stored for a block number.
+func (f *freezer) retrieve(kind string, number uint64) ([]byte, error) {
+	table, ok := f.tables[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown freezer table %q", kind)
+	}
+	return table.retrieve(number)
+}
+
+// append injects all the binary blobs belonging to a block into the freezer.
+func (f *freezer) append(number uint64, header, body, receipts, td []byte) error {
+	if frozen := f.ancients(); number != frozen {
+		return errOutOrderInsertion
+	}
+	blobs := map[string][]byte{
+		freezerHeaderTable:     header,
+		freezerBodiesTable:     body,
+		freezerReceiptTable:    receipts,
+		freezerDifficultyTable: td,
+	}
+	for _, name := range freezerTables {
+		if err := f.tables[name].append(number, blobs[name]); err != nil {
+			// Roll back any tables already extended to keep them aligned
+			for _, table := range f.tables {
+				table.truncate(number)
+			}
+			return err
+		}
+	}
+	atomic.AddUint64(&f.frozen, 1)
+	return nil
+}
+
+// sync flushes all the freezer tables to disk.
+func (f *freezer) sync() error {
+	for _, table := range f.tables {
+		if err := table.sync(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// close releases all the freezer tables.
+func (f *freezer) close() error {
+	var errs []error
+	for _, table := range f.tables {
+		if err := table.close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if errs != nil {
+		return fmt.Errorf("%v", errs)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+// Copyright 2016 The go-vector Authors
+// This file is part of the go-vector library.
+//
+// The go-vector library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vector library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vector library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vector/go-vector/core/types"
+	"github.com/vector/go-vector/event"
+	"github.com/vector/go-vector/vecdb"
+)
+
+// Tests that freezer tables store and retrieve items, and that partially
+// written items are discarded when reopening.
+func TestFreezerTableRepair(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	table, err := newFreezerTable(dir, "test")
+	if err != nil {
+		t.Fatalf("failed to open table: %v", err)
+	}
+	blobs := [][]byte{[]byte("first"), {}, []byte("third")}
+	for i, blob := range blobs {
+		if err := table.append(uint64(i), blob); err != nil {
+			t.Fatalf("failed to append item %d: %v", i, err)
+		}
+	}
+	if err := table.append(5, []byte("gap")); err != errOutOrderInsertion {
+		t.Errorf("gapped append error mismatch: have %v, want %v", err, errOutOrderInsertion)
+	}
+	// Simulate a crash in the middle of an append: data written, index not
+	table.data.WriteAt([]byte("dangling"), int64(table.size))
+	table.close()
+
+	if table, err = newFreezerTable(dir, "test"); err != nil {
+		t.Fatalf("failed to reopen table: %v", err)
+	}
+	defer table.close()
+
+	if table.items != uint64(len(blobs)) {
+		t.Fatalf("item count mismatch: have %d, want %d", table.items, len(blobs))
+	}
+	for i, want := range blobs {
+		if have, err := table.retrieve(uint64(i)); err != nil || !bytes.Equal(have, want) {
+			t.Errorf("item %d: have %q/%v, want %q", i, have, err, want)
+		}
+	}
+	if _, err := table.retrieve(uint64(len(blobs))); err != errOutOfBounds {
+		t.Errorf("out of bounds error mismatch: have %v, want %v", err, errOutOfBounds)
+	}
+}
+
+// Tests that old blocks are moved into the freezer and remain accessible
+// through the regular chain accessors afterwards.
+func TestAncientFreezing(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ancient")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	kvdb, _ := vecdb.NewMemDatabase()
+	db, err := NewAncientDatabase(kvdb, filepath.Join(dir, "ancient"), 0)
+	if err != nil {
+		t.Fatalf("failed to create ancient database: %v", err)
+	}
+	db.depth = 8 // set after creation to avoid the background freezer
+
+	genesis, _ := WriteTestNetGenesisBlock(db, 0)
+	chain, _ := NewBlockChain(db, MainNetChainConfig, FakePow{}, &event.TypeMux{})
+	blocks := makeBlockChain(genesis, 32, db, canonicalSeed)
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	if err := db.Freeze(); err != nil {
+		t.Fatalf("failed to freeze blocks: %v", err)
+	}
+	if frozen := db.Ancients(); frozen != 32-8+1 {
+		t.Fatalf("ancient count mismatch: have %d, want %d", frozen, 32-8+1)
+	}
+	// Frozen blocks must be gone from the key-value store, but still readable
+	for _, block := range append([]*types.Block{genesis}, blocks...) {
+		hash, frozen := block.Hash(), block.NumberU64() < db.Ancients()
+		if len(GetBodyRLP(kvdb, hash)) == 0 != frozen {
+			t.Errorf("block #%d: key-value presence mismatch: frozen %v", block.NumberU64(), frozen)
+		}
+		if stored := GetBlock(db, hash); stored == nil || stored.Hash() != hash {
+			t.Errorf("block #%d: not retrievable", block.NumberU64())
+		}
+		if td := GetTd(db, hash); td == nil {
+			t.Errorf("block #%d: total difficulty not retrievable", block.NumberU64())
+		}
+	}
+	db.Close()
+
+	// Reopen the freezer and check that the frozen data is still served
+	if db, err = NewAncientDatabase(kvdb, filepath.Join(dir, "ancient"), 0); err != nil {
+		t.Fatalf("failed to reopen ancient database: %v", err)
+	}
+	defer db.Close()
+
+	if frozen := db.Ancients(); frozen != 32-8+1 {
+		t.Fatalf("reopened ancient count mismatch: have %d, want %d", frozen, 32-8+1)
+	}
+	if header := GetHeader(db, blocks[0].Hash()); header == nil || header.Hash() != blocks[0].Hash() {
+		t.Errorf("frozen header not retrievable after reopen")
+	}
+}
@@ -31,31 +31,50 @@ import (
 	"github.com/vector/go-vector/logger"
 	"github.com/vector/go-vector/logger/glog"
 	"github.com/vector/go-vector/params"
+	"github.com/vector/go-vector/rlp"
 )
 
+// genesisSpec is the JSON specification of a genesis block.
+type genesisSpec struct {
+	ChainConfig *ChainConfig              `json:"config,omitempty"`
+	Nonce       string                    `json:"nonce"`
+	Timestamp   string                    `json:"timestamp"`
+	ParentHash  string                    `json:"parentHash"`
+	ExtraData   string                    `json:"extraData"`
+	GasLimit    string                    `json:"gasLimit"`
+	Difficulty  string                    `json:"difficulty"`
+	Mixhash     string                    `json:"mixhash"`
+	Coinbase    string                    `json:"coinbase"`
+	Alloc       map[string]genesisAccount `json:"alloc"`
+}
+
+// genesisAccount is the JSON specification of an account allocated in the
+// genesis block.
+type genesisAccount struct {
+	Code    string            `json:"code,omitempty"`
+	Storage map[string]string `json:"storage,omitempty"`
+	Balance string            `json:"balance"`
+}
+
 // WriteGenesisBlock writes the genesis block to the database as block number 0
 func WriteGenesisBlock(chainDb vecdb.Database, reader io.Reader) (*types.Block, error) {
+	return writeGenesisBlock(chainDb, reader, true)
+}
+
+// InitGenesisBlock writes the genesis block to the database as block number 0,
+// just like WriteGenesisBlock, but refuses to replace a different genesis block
+// already stored, returning a *GenesisMismatchError instead.
+func InitGenesisBlock(chainDb vecdb.Database, reader io.Reader) (*types.Block, error) {
+	return writeGenesisBlock(chainDb, reader, false)
+}
+
+func writeGenesisBlock(chainDb vecdb.Database, reader io.Reader, overwrite bool) (*types.Block, error) {
 	contents, err := ioutil.ReadAll(reader)
 	if err != nil {
 		return nil, err
 	}
 
-	var genesis struct {
-		Nonce      string
-		Timestamp  string
-		ParentHash string
-		ExtraData  string
-		GasLimit   string
-		Difficulty string
-		Mixhash    string
-		Coinbase   string
-		Alloc      map[string]struct {
-			Code    string
-			Storage map[string]string
-			Balance string
-		}
-	}
-
+	var genesis genesisSpec
 	if err := json.Unmarshal(contents, &genesis); err != nil {
 		return nil, err
 	}
@@ -85,12 +104,19 @@ func WriteGenesisBlock(chainDb vecdb.Database, reader io.Reader) (*types.Block,
 		Root:       root,
 	}, nil, nil, nil)
 
+	if stored := GetCanonicalHash(chainDb, 0); !overwrite && (stored != common.Hash{}) && stored != block.Hash() {
+		return nil, &GenesisMismatchError{Stored: stored, New: block.Hash()}
+	}
 	if block := GetBlock(chainDb, block.Hash()); block != nil {
 		glog.V(logger.Info).Infoln("Genesis block already in chain. Writing canonical number")
 		err := WriteCanonicalHash(chainDb, block.Hash(), block.NumberU64())
 		if err != nil {
 			return nil, err
 		}
+		// Databases initialised before chain configs were stored lack one
+		if err := WriteChainConfig(chainDb, block.Hash(), genesis.ChainConfig); err != nil {
+			return nil, err
+		}
 		return block, nil
 	}
 
@@ -112,9 +138,59 @@ func WriteGenesisBlock(chainDb vecdb.Database, reader io.Reader) (*types.Block,
 	if err := WriteHeadBlockHash(chainDb, block.Hash()); err != nil {
 		return nil, err
 	}
+	if err := WriteChainConfig(chainDb, block.Hash(), genesis.ChainConfig); err != nil {
+		return nil, err
+	}
 	return block, nil
 }
 
+// DumpGenesisBlock retrieves the genesis block stored in the database along with
+// its chain configuration and initial state, encoded as a JSON genesis
+// specification accepted by WriteGenesisBlock.
+func DumpGenesisBlock(chainDb vecdb.Database) ([]byte, error) {
+	block := GetBlock(chainDb, GetCanonicalHash(chainDb, 0))
+	if block == nil {
+		return nil, ErrNoGenesis
+	}
+	statedb, err := state.New(block.Root(), chainDb)
+	if err != nil {
+		return nil, fmt.Errorf("genesis state missing: %v", err)
+	}
+	genesis := genesisSpec{
+		Nonce:      fmt.Sprintf("0x%x", block.Nonce()),
+		Timestamp:  fmt.Sprintf("0x%x", block.Time()),
+		ParentHash: block.ParentHash().Hex(),
+		ExtraData:  fmt.Sprintf("0x%x", block.Extra()),
+		GasLimit:   fmt.Sprintf("0x%x", block.GasLimit()),
+		Difficulty: fmt.Sprintf("0x%x", block.Difficulty()),
+		Mixhash:    block.MixDigest().Hex(),
+		Coinbase:   block.Coinbase().Hex(),
+		Alloc:      make(map[string]genesisAccount),
+	}
+	if config, err := GetChainConfig(chainDb, block.Hash()); err == nil {
+		genesis.ChainConfig = config
+	} else if err != ChainConfigNotFoundErr {
+		return nil, err
+	}
+	for addr, dump := range statedb.RawDump().Accounts {
+		// Code is emitted without prefix, as that's how WriteGenesisBlock parses it
+		account := genesisAccount{Balance: dump.Balance, Code: dump.Code}
+		for key, value := range dump.Storage {
+			// Storage values are stored RLP encoded in the trie
+			content, _, err := rlp.SplitString(common.Hex2Bytes(value))
+			if err != nil {
+				return nil, fmt.Errorf("invalid storage value of %s: %v", addr, err)
+			}
+			if account.Storage == nil {
+				account.Storage = make(map[string]string)
+			}
+			account.Storage[common.HexToHash(key).Hex()] = common.BytesToHash(content).Hex()
+		}
+		genesis.Alloc[addr] = account
+	}
+	return json.MarshalIndent(genesis, "", "  ")
+}
+
 // GenesisBlockForTesting creates a block in which addr has the given wei balance.
 // The state trie of the block is written to db. the passed db needs to contain a state root
 func GenesisBlockForTesting(db vecdb.Database, addr common.Address, balance *big.Int) *types.Block {
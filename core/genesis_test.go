@@ -0,0 +1,123 @@
+// Copyright 2016 The go-vector Authors
+// This file is part of the go-vector library.
+//
+// The go-vector library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vector library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vector library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/vector/go-vector/common"
+	"github.com/vector/go-vector/vecdb"
+)
+
+var testGenesisSpec = `{
+	"config": {"homesteadBlock": 5},
+	"nonce": "0x42",
+	"difficulty": "0x400",
+	"gasLimit": "0x2FEFD8",
+	"extraData": "0x1234",
+	"alloc": {
+		"0000000000000000000000000000000000000001": {"balance": "100", "code": "6001", "storage": {"0x01": "0x02"}},
+		"0000000000000000000000000000000000000002": {"balance": "200"}
+	}
+}`
+
+// Tests that a dumped genesis block can be used to recreate the exact same
+// genesis block and chain configuration.
+func TestGenesisDumpRoundtrip(t *testing.T) {
+	db, _ := vecdb.NewMemDatabase()
+	genesis, err := WriteGenesisBlock(db, strings.NewReader(testGenesisSpec))
+	if err != nil {
+		t.Fatalf("failed to write genesis block: %v", err)
+	}
+	dump, err := DumpGenesisBlock(db)
+	if err != nil {
+		t.Fatalf("failed to dump genesis block: %v", err)
+	}
+	redb, _ := vecdb.NewMemDatabase()
+	regenesis, err := InitGenesisBlock(redb, bytes.NewReader(dump))
+	if err != nil {
+		t.Fatalf("failed to reinitialize dumped genesis block: %v", err)
+	}
+	if regenesis.Hash() != genesis.Hash() {
+		t.Errorf("genesis hash mismatch: have %x, want %x", regenesis.Hash(), genesis.Hash())
+	}
+	config, err := GetChainConfig(redb, regenesis.Hash())
+	if err != nil {
+		t.Fatalf("failed to retrieve chain config: %v", err)
+	}
+	if config.HomesteadBlock.Cmp(big.NewInt(5)) != 0 {
+		t.Errorf("homestead block mismatch: have %v, want %v", config.HomesteadBlock, 5)
+	}
+	// Dumping a database without a genesis should fail
+	empty, _ := vecdb.NewMemDatabase()
+	if _, err := DumpGenesisBlock(empty); err != ErrNoGenesis {
+		t.Errorf("empty database dump error mismatch: have %v, want %v", err, ErrNoGenesis)
+	}
+}
+
+// Tests that initializing a genesis block is idempotent, but refuses to replace
+// a different genesis block.
+func TestInitGenesisMismatch(t *testing.T) {
+	db, _ := vecdb.NewMemDatabase()
+	genesis, err := InitGenesisBlock(db, strings.NewReader(testGenesisSpec))
+	if err != nil {
+		t.Fatalf("failed to initialize genesis block: %v", err)
+	}
+	if _, err := InitGenesisBlock(db, strings.NewReader(testGenesisSpec)); err != nil {
+		t.Fatalf("failed to reinitialize same genesis block: %v", err)
+	}
+	other := strings.Replace(testGenesisSpec, `"nonce": "0x42"`, `"nonce": "0x43"`, 1)
+	_, err = InitGenesisBlock(db, strings.NewReader(other))
+	if mismatch, ok := err.(*GenesisMismatchError); !ok {
+		t.Fatalf("error mismatch: have %v, want *GenesisMismatchError", err)
+	} else if mismatch.Stored != genesis.Hash() {
+		t.Errorf("stored hash mismatch: have %x, want %x", mismatch.Stored, genesis.Hash())
+	}
+	if hash := GetCanonicalHash(db, 0); hash != genesis.Hash() {
+		t.Errorf("canonical genesis replaced: have %x, want %x", hash, genesis.Hash())
+	}
+	if (GetHeadBlockHash(db) == common.Hash{}) {
+		t.Errorf("head block hash missing")
+	}
+}
+
+// Tests that reinitializing a genesis block already in the chain writes its
+// chain configuration, as databases created before configs were stored lack it.
+func TestInitGenesisConfigUpgrade(t *testing.T) {
+	db, _ := vecdb.NewMemDatabase()
+	legacy := strings.Replace(testGenesisSpec, `"config": {"homesteadBlock": 5},`, "", 1)
+	genesis, err := InitGenesisBlock(db, strings.NewReader(legacy))
+	if err != nil {
+		t.Fatalf("failed to initialize genesis block: %v", err)
+	}
+	if _, err := GetChainConfig(db, genesis.Hash()); err != ChainConfigNotFoundErr {
+		t.Fatalf("chain config error mismatch: have %v, want %v", err, ChainConfigNotFoundErr)
+	}
+	if _, err := InitGenesisBlock(db, strings.NewReader(testGenesisSpec)); err != nil {
+		t.Fatalf("failed to reinitialize genesis block: %v", err)
+	}
+	config, err := GetChainConfig(db, genesis.Hash())
+	if err != nil {
+		t.Fatalf("failed to retrieve chain config: %v", err)
+	}
+	if config.HomesteadBlock.Cmp(big.NewInt(5)) != 0 {
+		t.Errorf("homestead block mismatch: have %v, want %v", config.HomesteadBlock, 5)
+	}
+}
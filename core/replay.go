@@ -0,0 +1,94 @@
+// Copyright 2016 The go-vector Authors
+// This file is part of the go-vector library.
+//
+// The go-vector library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vector library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vector library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/vector/go-vector/core/state"
+	"github.com/vector/go-vector/core/types"
+)
+
+// ReplayStats contains the time spent in the various phases of re-executing
+// a single block.
+type ReplayStats struct {
+	Senders   time.Duration // Time spent recovering the transaction senders
+	Execution time.Duration // Time spent executing the transactions in the EVM
+	Hashing   time.Duration // Time spent hashing the state and receipt tries
+	Commit    time.Duration // Time spent committing the state changes into a batch
+}
+
+// Total returns the total time spent replaying the block.
+func (s *ReplayStats) Total() time.Duration {
+	return s.Senders + s.Execution + s.Hashing + s.Commit
+}
+
+// ReplayBlock re-executes a stored block on top of the state of its parent,
+// verifying the resulting state and receipt roots against the ones in the
+// header. Nothing is written to the database.
+//
+// Note, the intermediate state roots embedded in the receipts are accounted
+// for as part of the execution, only the final root is counted as hashing.
+func (self *BlockChain) ReplayBlock(block *types.Block) (*ReplayStats, error) {
+	parent := self.GetBlock(block.ParentHash())
+	if parent == nil {
+		return nil, ParentError(block.ParentHash())
+	}
+	statedb, err := state.New(parent.Root(), self.chainDb)
+	if err != nil {
+		return nil, fmt.Errorf("parent state %x unavailable: %v", parent.Root(), err)
+	}
+	stats := new(ReplayStats)
+
+	// Recover the senders up front, the transactions cache them for execution
+	start := time.Now()
+	homestead := self.config.IsHomestead(block.Number())
+	for i, tx := range block.Transactions() {
+		var err error
+		if homestead {
+			_, err = tx.From()
+		} else {
+			_, err = tx.FromFrontier()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("tx %d [%x]: invalid sender: %v", i, tx.Hash(), err)
+		}
+	}
+	stats.Senders = time.Since(start)
+
+	start = time.Now()
+	receipts, _, usedGas, err := self.Processor().Process(block, statedb)
+	if err != nil {
+		return nil, err
+	}
+	stats.Execution = time.Since(start)
+
+	start = time.Now()
+	if err := self.Validator().ValidateState(block, parent, statedb, receipts, usedGas); err != nil {
+		return nil, err
+	}
+	stats.Hashing = time.Since(start)
+
+	start = time.Now()
+	if root, _ := statedb.CommitBatch(); root != block.Root() {
+		return nil, fmt.Errorf("invalid committed root: header=%x computed=%x", block.Root(), root)
+	}
+	stats.Commit = time.Since(start)
+
+	return stats, nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2016 The go-vector Authors
+// This file is part of the go-vector library.
+//
+// The go-vector library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vector library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vector library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/vector/go-vector/common"
+	"github.com/vector/go-vector/core/state"
+	"github.com/vector/go-vector/core/types"
+	"github.com/vector/go-vector/core/vm"
+	"github.com/vector/go-vector/crypto"
+	"github.com/vector/go-vector/event"
+	"github.com/vector/go-vector/params"
+	"github.com/vector/go-vector/vecdb"
+)
+
+// divergingProcessor is a block processor crediting an extra balance to the
+// coinbase, modelling a consensus bug in the state transition.
+type divergingProcessor struct {
+	Processor
+}
+
+func (p divergingProcessor) Process(block *types.Block, statedb *state.StateDB) (types.Receipts, vm.Logs, *big.Int, error) {
+	receipts, logs, usedGas, err := p.Processor.Process(block, statedb)
+	statedb.AddBalance(block.Coinbase(), big.NewInt(1))
+	return receipts, logs, usedGas, err
+}
+
+// Tests that stored blocks can be replayed on top of their parent states and
+// that diverging state transitions are detected.
+func TestReplayBlock(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		db, _   = vecdb.NewMemDatabase()
+		genesis = WriteGenesisBlockForTesting(db, GenesisAccount{addr, big.NewInt(1000000)})
+	)
+	blockchain, _ := NewBlockChain(db, MainNetChainConfig, FakePow{}, &event.TypeMux{})
+
+	chain, _ := GenerateChain(MainNetChainConfig, genesis, db, 3, func(i int, gen *BlockGen) {
+		tx, _ := types.NewTransaction(gen.TxNonce(addr), common.Address{byte(i + 1)}, big.NewInt(1000), params.TxGas, nil, nil).SignECDSA(key)
+		gen.AddTx(tx)
+	})
+	if _, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	for i, block := range chain {
+		if _, err := blockchain.ReplayBlock(block); err != nil {
+			t.Errorf("block %d: replay failed: %v", i, err)
+		}
+	}
+	// Replaying with a diverging processor must fail the state validation
+	blockchain.SetProcessor(divergingProcessor{blockchain.Processor()})
+	if _, err := blockchain.ReplayBlock(chain[1]); err == nil {
+		t.Errorf("diverging replay succeeded")
+	}
+	// Replaying a block with an unknown parent must fail
+	orphans, _ := GenerateChain(MainNetChainConfig, chain[2], db, 2, nil)
+	if _, err := blockchain.ReplayBlock(orphans[1]); err == nil {
+		t.Errorf("orphan replay succeeded")
+	}
+}
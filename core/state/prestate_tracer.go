@@ -0,0 +1,224 @@
+// Copyright 2016 The go-vector Authors
+// This file is part of the go-vector library.
+//
+// The go-vector library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vector library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vector library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/vector/go-vector/common"
+	"github.com/vector/go-vector/core/vm"
+)
+
+// AccountState is the state of an account at a point of a traced transaction,
+// limited to the storage slots the transaction accessed.
+type AccountState struct {
+	Balance *big.Int
+	Nonce   uint64
+	Code    []byte
+	Storage map[common.Hash]common.Hash
+
+	exists bool
+}
+
+// MarshalJSON implements json.Marshaler, encoding the account with hex values.
+func (a *AccountState) MarshalJSON() ([]byte, error) {
+	account := map[string]interface{}{
+		"balance": fmt.Sprintf("0x%x", a.Balance),
+		"nonce":   a.Nonce,
+	}
+	if len(a.Code) > 0 {
+		account["code"] = fmt.Sprintf("0x%x", a.Code)
+	}
+	if len(a.Storage) > 0 {
+		storage := make(map[string]string, len(a.Storage))
+		for key, value := range a.Storage {
+			storage[key.Hex()] = value.Hex()
+		}
+		account["storage"] = storage
+	}
+	return json.Marshal(account)
+}
+
+// AccountDiff is the state of an account before and after a traced transaction.
+type AccountDiff struct {
+	Pre, Post *AccountState
+}
+
+// MarshalJSON implements json.Marshaler, encoding the fields changed by the
+// transaction as {"from": pre, "to": post} pairs.
+func (d *AccountDiff) MarshalJSON() ([]byte, error) {
+	change := func(from, to interface{}) map[string]interface{} {
+		return map[string]interface{}{"from": from, "to": to}
+	}
+	diff := make(map[string]interface{})
+	if d.Pre.Balance.Cmp(d.Post.Balance) != 0 {
+		diff["balance"] = change(fmt.Sprintf("0x%x", d.Pre.Balance), fmt.Sprintf("0x%x", d.Post.Balance))
+	}
+	if d.Pre.Nonce != d.Post.Nonce {
+		diff["nonce"] = change(d.Pre.Nonce, d.Post.Nonce)
+	}
+	if string(d.Pre.Code) != string(d.Post.Code) {
+		diff["code"] = change(fmt.Sprintf("0x%x", d.Pre.Code), fmt.Sprintf("0x%x", d.Post.Code))
+	}
+	storage := make(map[string]interface{})
+	for key, value := range d.Pre.Storage {
+		if post := d.Post.Storage[key]; post != value {
+			storage[key.Hex()] = change(value.Hex(), post.Hex())
+		}
+	}
+	if len(storage) > 0 {
+		diff["storage"] = storage
+	}
+	return json.Marshal(diff)
+}
+
+// Changed reports whether the transaction changed any field of the account.
+func (d *AccountDiff) Changed() bool {
+	if d.Pre.Balance.Cmp(d.Post.Balance) != 0 || d.Pre.Nonce != d.Post.Nonce || string(d.Pre.Code) != string(d.Post.Code) {
+		return true
+	}
+	for key, value := range d.Pre.Storage {
+		if d.Post.Storage[key] != value {
+			return true
+		}
+	}
+	return false
+}
+
+// PrestateTracer is a tracer recording the state a transaction accessed, as it
+// was prior to the transaction. In diff mode it instead reports the values the
+// transaction changed. It observes the state through the AccessHook of the
+// StateDB the transaction is executed on, which must be set prior to the
+// execution, and Finalise must be called once the state changes of the
+// transaction were finalised.
+type PrestateTracer struct {
+	diff bool
+	pre  map[common.Address]*AccountState // State prior to the transaction
+	post map[common.Address]*AccountState // State after the transaction
+}
+
+// NewPrestateTracer returns a new prestate tracer, reporting the state changes
+// instead of the prestate if diff is set.
+func NewPrestateTracer(diff bool) *PrestateTracer {
+	return &PrestateTracer{
+		diff: diff,
+		pre:  make(map[common.Address]*AccountState),
+	}
+}
+
+// AccountAccessed implements AccessHook, recording the prestate of an account
+// when it's first accessed.
+func (t *PrestateTracer) AccountAccessed(db *StateDB, addr common.Address) {
+	if _, ok := t.pre[addr]; !ok {
+		t.pre[addr] = readAccountState(db, addr, nil)
+	}
+}
+
+// StorageAccessed implements AccessHook, recording the prestate of a storage
+// slot when it's first accessed.
+func (t *PrestateTracer) StorageAccessed(db *StateDB, addr common.Address, key common.Hash) {
+	t.AccountAccessed(db, addr)
+
+	account := t.pre[addr]
+	if _, ok := account.Storage[key]; !ok {
+		if object := db.GetStateObject(addr); object != nil {
+			account.Storage[key] = object.GetState(key)
+		} else {
+			account.Storage[key] = common.Hash{}
+		}
+	}
+}
+
+// Finalise records the state of the accessed accounts and storage slots after
+// the transaction, needed by the diff mode.
+func (t *PrestateTracer) Finalise(db *StateDB) {
+	t.post = make(map[common.Address]*AccountState, len(t.pre))
+	for addr, pre := range t.pre {
+		t.post[addr] = readAccountState(db, addr, pre.Storage)
+	}
+}
+
+// readAccountState reads the current state of an account along with the given
+// storage slots.
+func readAccountState(db *StateDB, addr common.Address, slots map[common.Hash]common.Hash) *AccountState {
+	account := &AccountState{
+		Balance: new(big.Int),
+		Storage: make(map[common.Hash]common.Hash, len(slots)),
+	}
+	object := db.GetStateObject(addr)
+	if object != nil {
+		account.exists = true
+		account.Balance.Set(object.Balance())
+		account.Nonce = object.Nonce()
+		account.Code = common.CopyBytes(object.Code())
+	}
+	for key := range slots {
+		if object != nil {
+			account.Storage[key] = object.GetState(key)
+		} else {
+			account.Storage[key] = common.Hash{}
+		}
+	}
+	return account
+}
+
+// CaptureStart implements vm.Tracer, it's a noop for the prestate tracer.
+func (t *PrestateTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas, value *big.Int) {
+}
+
+// CaptureState implements vm.Tracer, it's a noop for the prestate tracer.
+func (t *PrestateTracer) CaptureState(env vm.Environment, pc uint64, op vm.OpCode, gas, cost *big.Int, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) {
+}
+
+// CaptureEnter implements vm.Tracer, it's a noop for the prestate tracer.
+func (t *PrestateTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas, value *big.Int) {
+}
+
+// CaptureExit implements vm.Tracer, it's a noop for the prestate tracer.
+func (t *PrestateTracer) CaptureExit(output []byte, gasUsed *big.Int, err error) {
+}
+
+// CaptureEnd implements vm.Tracer, it's a noop for the prestate tracer.
+func (t *PrestateTracer) CaptureEnd(output []byte, gasUsed *big.Int, d time.Duration, err error) {
+}
+
+// Result returns the prestate of the accessed accounts that existed prior to
+// the transaction, or in diff mode the changes to the accessed accounts, both
+// keyed by the hex address.
+func (t *PrestateTracer) Result() interface{} {
+	if !t.diff {
+		accounts := make(map[string]*AccountState)
+		for addr, account := range t.pre {
+			if account.exists {
+				accounts[addr.Hex()] = account
+			}
+		}
+		return accounts
+	}
+	diffs := make(map[string]*AccountDiff)
+	for addr, pre := range t.pre {
+		if post, ok := t.post[addr]; ok {
+			if diff := (&AccountDiff{Pre: pre, Post: post}); diff.Changed() {
+				diffs[addr.Hex()] = diff
+			}
+		}
+	}
+	return diffs
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/vector/go-vector/vecdb"
 	"github.com/vector/go-vector/logger"
 	"github.com/vector/go-vector/logger/glog"
+	"github.com/vector/go-vector/rlp"
 	"github.com/vector/go-vector/trie"
 )
 
@@ -49,6 +50,16 @@ type StateDB struct {
 	txIndex      int
 	logs         map[common.Hash]vm.Logs
 	logSize      uint
+
+	hook AccessHook
+}
+
+// AccessHook is notified by a StateDB of the accounts and storage slots
+// accessed through its vm.Database methods, before the access takes effect.
+// Accesses made directly on the state objects are not reported.
+type AccessHook interface {
+	AccountAccessed(db *StateDB, addr common.Address)
+	StorageAccessed(db *StateDB, addr common.Address, key common.Hash)
 }
 
 // Create a new state from a given trie
@@ -66,6 +77,44 @@ func New(root common.Hash, db vecdb.Database) (*StateDB, error) {
 	}, nil
 }
 
+// NewNodeCache wraps db with a trie node cache that keeps the storage trie of
+// every account alive for as long as the account itself. State committed into
+// the cache is only written to db when flushed.
+func NewNodeCache(db vecdb.Database) *trie.NodeCache {
+	return trie.NewNodeCache(db, func(leaf []byte) []common.Hash {
+		var account struct {
+			Nonce    uint64
+			Balance  *big.Int
+			Root     common.Hash
+			CodeHash []byte
+		}
+		if err := rlp.DecodeBytes(leaf, &account); err != nil {
+			return nil
+		}
+		return []common.Hash{account.Root}
+	})
+}
+
+// SetAccessHook sets the hook notified of state accesses (nil = none). The hook
+// isn't carried over to copies of the state.
+func (self *StateDB) SetAccessHook(hook AccessHook) {
+	self.hook = hook
+}
+
+// accessAccount notifies the access hook, if any, of an account access.
+func (self *StateDB) accessAccount(addr common.Address) {
+	if self.hook != nil {
+		self.hook.AccountAccessed(self, addr)
+	}
+}
+
+// accessStorage notifies the access hook, if any, of a storage slot access.
+func (self *StateDB) accessStorage(addr common.Address, key common.Hash) {
+	if self.hook != nil {
+		self.hook.StorageAccessed(self, addr, key)
+	}
+}
+
 func (self *StateDB) StartRecord(thash, bhash common.Hash, ti int) {
 	self.thash = thash
 	self.bhash = bhash
@@ -98,19 +147,23 @@ func (self *StateDB) AddRefund(gas *big.Int) {
 }
 
 func (self *StateDB) HasAccount(addr common.Address) bool {
+	self.accessAccount(addr)
 	return self.GetStateObject(addr) != nil
 }
 
 func (self *StateDB) Exist(addr common.Address) bool {
+	self.accessAccount(addr)
 	return self.GetStateObject(addr) != nil
 }
 
 func (self *StateDB) GetAccount(addr common.Address) vm.Account {
+	self.accessAccount(addr)
 	return self.GetStateObject(addr)
 }
 
 // Retrieve the balance from the given address or 0 if object not found
 func (self *StateDB) GetBalance(addr common.Address) *big.Int {
+	self.accessAccount(addr)
 	stateObject := self.GetStateObject(addr)
 	if stateObject != nil {
 		return stateObject.balance
@@ -120,6 +173,7 @@ func (self *StateDB) GetBalance(addr common.Address) *big.Int {
 }
 
 func (self *StateDB) GetNonce(addr common.Address) uint64 {
+	self.accessAccount(addr)
 	stateObject := self.GetStateObject(addr)
 	if stateObject != nil {
 		return stateObject.nonce
@@ -129,6 +183,7 @@ func (self *StateDB) GetNonce(addr common.Address) uint64 {
 }
 
 func (self *StateDB) GetCode(addr common.Address) []byte {
+	self.accessAccount(addr)
 	stateObject := self.GetStateObject(addr)
 	if stateObject != nil {
 		return stateObject.code
@@ -138,6 +193,7 @@ func (self *StateDB) GetCode(addr common.Address) []byte {
 }
 
 func (self *StateDB) GetState(a common.Address, b common.Hash) common.Hash {
+	self.accessStorage(a, b)
 	stateObject := self.GetStateObject(a)
 	if stateObject != nil {
 		return stateObject.GetState(b)
@@ -147,6 +203,7 @@ func (self *StateDB) GetState(a common.Address, b common.Hash) common.Hash {
 }
 
 func (self *StateDB) IsDeleted(addr common.Address) bool {
+	self.accessAccount(addr)
 	stateObject := self.GetStateObject(addr)
 	if stateObject != nil {
 		return stateObject.remove
@@ -159,6 +216,7 @@ func (self *StateDB) IsDeleted(addr common.Address) bool {
  */
 
 func (self *StateDB) AddBalance(addr common.Address, amount *big.Int) {
+	self.accessAccount(addr)
 	stateObject := self.GetOrNewStateObject(addr)
 	if stateObject != nil {
 		stateObject.AddBalance(amount)
@@ -166,6 +224,7 @@ func (self *StateDB) AddBalance(addr common.Address, amount *big.Int) {
 }
 
 func (self *StateDB) SetNonce(addr common.Address, nonce uint64) {
+	self.accessAccount(addr)
 	stateObject := self.GetOrNewStateObject(addr)
 	if stateObject != nil {
 		stateObject.SetNonce(nonce)
@@ -173,6 +232,7 @@ func (self *StateDB) SetNonce(addr common.Address, nonce uint64) {
 }
 
 func (self *StateDB) SetCode(addr common.Address, code []byte) {
+	self.accessAccount(addr)
 	stateObject := self.GetOrNewStateObject(addr)
 	if stateObject != nil {
 		stateObject.SetCode(code)
@@ -180,6 +240,7 @@ func (self *StateDB) SetCode(addr common.Address, code []byte) {
 }
 
 func (self *StateDB) SetState(addr common.Address, key common.Hash, value common.Hash) {
+	self.accessStorage(addr, key)
 	stateObject := self.GetOrNewStateObject(addr)
 	if stateObject != nil {
 		stateObject.SetState(key, value)
@@ -187,6 +248,7 @@ func (self *StateDB) SetState(addr common.Address, key common.Hash, value common
 }
 
 func (self *StateDB) Delete(addr common.Address) bool {
+	self.accessAccount(addr)
 	stateObject := self.GetStateObject(addr)
 	if stateObject != nil {
 		stateObject.MarkForDeletion()
@@ -287,6 +349,7 @@ func (self *StateDB) CreateStateObject(addr common.Address) *StateObject {
 }
 
 func (self *StateDB) CreateAccount(addr common.Address) vm.Account {
+	self.accessAccount(addr)
 	return self.CreateStateObject(addr)
 }
 
@@ -345,8 +408,12 @@ func (s *StateDB) IntermediateRoot() common.Hash {
 	return s.trie.Hash()
 }
 
-// Commit commits all state changes to the database.
+// Commit commits all state changes to the database. If the database is a trie
+// node cache, the state trie is only committed into the cache.
 func (s *StateDB) Commit() (root common.Hash, err error) {
+	if cache, ok := s.db.(*trie.NodeCache); ok {
+		return s.commit(cache.Nodes())
+	}
 	return s.commit(s.db)
 }
 
@@ -17,11 +17,12 @@ var (
 )
 
 type StateProcessor struct {
-	bc *BlockChain
+	config *ChainConfig
+	bc     *BlockChain
 }
 
-func NewStateProcessor(bc *BlockChain) *StateProcessor {
-	return &StateProcessor{bc}
+func NewStateProcessor(config *ChainConfig, bc *BlockChain) *StateProcessor {
+	return &StateProcessor{config, bc}
 }
 
 // Process processes the state changes according to the Vector rules by running
@@ -43,7 +44,7 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB) (ty
 
 	for i, tx := range block.Transactions() {
 		statedb.StartRecord(tx.Hash(), block.Hash(), i)
-		receipt, logs, _, err := ApplyTransaction(p.bc, gp, statedb, header, tx, totalUsedGas)
+		receipt, logs, _, err := ApplyTransaction(p.config, p.bc, gp, statedb, header, tx, totalUsedGas)
 		if err != nil {
 			return nil, nil, totalUsedGas, err
 		}
@@ -60,8 +61,12 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB) (ty
 //
 // ApplyTransactions returns the generated receipts and vm logs during the
 // execution of the state transition phase.
-func ApplyTransaction(bc *BlockChain, gp *GasPool, statedb *state.StateDB, header *types.Header, tx *types.Transaction, usedGas *big.Int) (*types.Receipt, vm.Logs, *big.Int, error) {
-	_, gas, err := ApplyMessage(NewEnv(statedb, bc, tx, header), tx, gp)
+func ApplyTransaction(config *ChainConfig, bc *BlockChain, gp *GasPool, statedb *state.StateDB, header *types.Header, tx *types.Transaction, usedGas *big.Int) (*types.Receipt, vm.Logs, *big.Int, error) {
+	var tracer vm.Tracer
+	if vm.Debug {
+		tracer = vm.NewStructLogger(nil)
+	}
+	_, gas, err := ApplyMessage(NewEnv(statedb, config, bc, tx, header, tracer), tx, gp)
 	if err != nil {
 		return nil, nil, nil, err
 	}
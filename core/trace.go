@@ -0,0 +1,96 @@
+// Copyright 2016 The go-vector Authors
+// This file is part of the go-vector library.
+//
+// The go-vector library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vector library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vector library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+
+	"github.com/vector/go-vector/common"
+	"github.com/vector/go-vector/core/state"
+	"github.com/vector/go-vector/core/types"
+	"github.com/vector/go-vector/core/vm"
+)
+
+// stateTracer is a tracer also observing the state accessed by the traced
+// transaction, such as the state.PrestateTracer.
+type stateTracer interface {
+	state.AccessHook
+	Finalise(statedb *state.StateDB)
+}
+
+// TraceBlock re-executes the transactions of a stored block on top of the state
+// of its parent, attaching the tracer returned by newTracer to each of them (nil
+// = no tracing). Nothing is written to the database.
+func (self *BlockChain) TraceBlock(block *types.Block, newTracer func(index int, tx *types.Transaction) vm.Tracer) error {
+	return self.traceBlock(block, len(block.Transactions()), newTracer)
+}
+
+// TraceTransaction re-executes a stored transaction with the given tracer on
+// top of the state it was originally executed on, which is rebuilt from the
+// parent state of its block by replaying the preceding transactions.
+func (self *BlockChain) TraceTransaction(hash common.Hash, tracer vm.Tracer) error {
+	tx, blockHash, _, index := GetTransaction(self.chainDb, hash)
+	if tx == nil {
+		return fmt.Errorf("transaction %x not found", hash)
+	}
+	block := self.GetBlock(blockHash)
+	if block == nil {
+		return fmt.Errorf("block %x not found", blockHash)
+	}
+	return self.traceBlock(block, int(index)+1, func(i int, tx *types.Transaction) vm.Tracer {
+		if i == int(index) {
+			return tracer
+		}
+		return nil
+	})
+}
+
+// traceBlock re-executes the first n transactions of a block on top of the
+// state of its parent, the same way the state processor does.
+func (self *BlockChain) traceBlock(block *types.Block, n int, newTracer func(int, *types.Transaction) vm.Tracer) error {
+	parent := self.GetBlock(block.ParentHash())
+	if parent == nil {
+		return ParentError(block.ParentHash())
+	}
+	statedb, err := state.New(parent.Root(), self.chainDb)
+	if err != nil {
+		return fmt.Errorf("parent state %x unavailable: %v", parent.Root(), err)
+	}
+	var (
+		header = block.Header()
+		gp     = new(GasPool).AddGas(block.GasLimit())
+	)
+	for i, tx := range block.Transactions()[:n] {
+		tracer := newTracer(i, tx)
+
+		st, _ := tracer.(stateTracer)
+		statedb.SetAccessHook(st)
+
+		statedb.StartRecord(tx.Hash(), block.Hash(), i)
+		if _, _, err := ApplyMessage(NewEnv(statedb, self.config, self, tx, header, tracer), tx, gp); err != nil {
+			return fmt.Errorf("tx %d [%x]: %v", i, tx.Hash(), err)
+		}
+		// Finalise the state changes of the transaction as the receipt does
+		statedb.IntermediateRoot()
+		statedb.SetAccessHook(nil)
+
+		if st != nil {
+			st.Finalise(statedb)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+// Copyright 2016 The go-vector Authors
+// This file is part of the go-vector library.
+//
+// The go-vector library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vector library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vector library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/vector/go-vector/common"
+	"github.com/vector/go-vector/core/state"
+	"github.com/vector/go-vector/core/types"
+	"github.com/vector/go-vector/core/vm"
+	"github.com/vector/go-vector/crypto"
+	"github.com/vector/go-vector/event"
+	"github.com/vector/go-vector/vecdb"
+)
+
+// newTraceTestChain creates a chain with a single block, which deploys a contract
+// storing 1 into slot 0 whenever called and then calls it.
+func newTraceTestChain(t *testing.T) (*BlockChain, *types.Block, *types.Transaction) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		db, _   = vecdb.NewMemDatabase()
+		genesis = WriteGenesisBlockForTesting(db, GenesisAccount{addr, big.NewInt(1000000000)})
+
+		runtime = []byte{byte(vm.PUSH1), 1, byte(vm.PUSH1), 0, byte(vm.SSTORE), byte(vm.STOP)}
+		deploy  = append([]byte{
+			byte(vm.PUSH1), byte(len(runtime)), byte(vm.PUSH1), 12, byte(vm.PUSH1), 0, byte(vm.CODECOPY),
+			byte(vm.PUSH1), byte(len(runtime)), byte(vm.PUSH1), 0, byte(vm.RETURN),
+		}, runtime...)
+		contract = crypto.CreateAddress(addr, 0)
+	)
+	blockchain, _ := NewBlockChain(db, MainNetChainConfig, FakePow{}, &event.TypeMux{})
+
+	var call *types.Transaction
+	chain, _ := GenerateChain(MainNetChainConfig, genesis, db, 1, func(i int, gen *BlockGen) {
+		create, _ := types.NewContractCreation(gen.TxNonce(addr), new(big.Int), big.NewInt(100000), new(big.Int), deploy).SignECDSA(key)
+		gen.AddTx(create)
+
+		call, _ = types.NewTransaction(gen.TxNonce(addr), contract, new(big.Int), big.NewInt(100000), new(big.Int), nil).SignECDSA(key)
+		gen.AddTx(call)
+	})
+	if _, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	return blockchain, chain[0], call
+}
+
+// Tests that stored transactions can be traced on top of the state left by the
+// preceding transactions of their block.
+func TestTraceTransaction(t *testing.T) {
+	blockchain, block, call := newTraceTestChain(t)
+
+	// Trace the call, which only runs code if the creation was replayed
+	logger := vm.NewStructLogger(nil)
+	if err := blockchain.TraceTransaction(call.Hash(), logger); err != nil {
+		t.Fatalf("failed to trace transaction: %v", err)
+	}
+	logs := logger.StructLogs()
+	if len(logs) != 4 {
+		t.Fatalf("struct log count mismatch: have %d, want %d", len(logs), 4)
+	}
+	if logs[2].Op != vm.SSTORE || logs[2].Storage[common.Hash{}] != common.BigToHash(big.NewInt(1)) {
+		t.Errorf("storage write not captured: op %v, storage %v", logs[2].Op, logs[2].Storage)
+	}
+	// Trace the entire block with the stack capture disabled
+	loggers := make(map[int]*vm.StructLogger)
+	err := blockchain.TraceBlock(block, func(i int, tx *types.Transaction) vm.Tracer {
+		loggers[i] = vm.NewStructLogger(&vm.LogConfig{DisableStack: true})
+		return loggers[i]
+	})
+	if err != nil {
+		t.Fatalf("failed to trace block: %v", err)
+	}
+	if len(loggers) != 2 {
+		t.Fatalf("traced transaction count mismatch: have %d, want %d", len(loggers), 2)
+	}
+	for i, logger := range loggers {
+		if len(logger.StructLogs()) == 0 {
+			t.Errorf("tx %d: no struct logs", i)
+		}
+		for _, log := range logger.StructLogs() {
+			if log.Stack != nil {
+				t.Errorf("tx %d: stack captured although disabled", i)
+				break
+			}
+		}
+	}
+	if err := blockchain.TraceTransaction(common.Hash{1}, logger); err == nil {
+		t.Errorf("unknown transaction traced")
+	}
+}
+
+// Tests that the prestate tracer records the state accessed by a transaction
+// and the changes it made to it.
+func TestTracePrestate(t *testing.T) {
+	blockchain, _, call := newTraceTestChain(t)
+
+	from, _ := call.From()
+	contract := *call.To()
+
+	tracer := state.NewPrestateTracer(false)
+	if err := blockchain.TraceTransaction(call.Hash(), tracer); err != nil {
+		t.Fatalf("failed to trace transaction: %v", err)
+	}
+	accounts := tracer.Result().(map[string]*state.AccountState)
+	if sender := accounts[from.Hex()]; sender == nil || sender.Nonce != 1 {
+		t.Errorf("sender prestate mismatch: have %+v, want nonce 1", sender)
+	}
+	if account := accounts[contract.Hex()]; account == nil || len(account.Code) == 0 || account.Storage[common.Hash{}] != (common.Hash{}) {
+		t.Errorf("contract prestate mismatch: have %+v", account)
+	}
+	// Trace the changes, the gas being free only the nonce and storage change
+	tracer = state.NewPrestateTracer(true)
+	if err := blockchain.TraceTransaction(call.Hash(), tracer); err != nil {
+		t.Fatalf("failed to trace transaction: %v", err)
+	}
+	diffs := tracer.Result().(map[string]*state.AccountDiff)
+	if len(diffs) != 2 {
+		t.Fatalf("changed account count mismatch: have %d, want %d", len(diffs), 2)
+	}
+	if sender := diffs[from.Hex()]; sender == nil || sender.Pre.Nonce != 1 || sender.Post.Nonce != 2 {
+		t.Errorf("sender diff mismatch: have %+v", sender)
+	}
+	if account := diffs[contract.Hex()]; account == nil || account.Post.Storage[common.Hash{}] != common.BigToHash(big.NewInt(1)) {
+		t.Errorf("contract diff mismatch: have %+v", account)
+	}
+}
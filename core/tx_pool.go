@@ -17,11 +17,13 @@
 package core
 
 import (
+	"container/heap"
 	"errors"
 	"fmt"
 	"math/big"
 	"sort"
 	"sync"
+	"time"
 
 	"github.com/vector/go-vector/common"
 	"github.com/vector/go-vector/core/state"
@@ -29,7 +31,7 @@ import (
 	"github.com/vector/go-vector/event"
 	"github.com/vector/go-vector/logger"
 	"github.com/vector/go-vector/logger/glog"
-	"github.com/vector/go-vector/params"
+	"github.com/vector/go-vector/vecdb"
 )
 
 var (
@@ -43,11 +45,71 @@ var (
 	ErrIntrinsicGas       = errors.New("Intrinsic gas too low")
 	ErrGasLimit           = errors.New("Exceeds block gas limit")
 	ErrNegativeValue      = errors.New("Negative value")
+	ErrUnderpriced        = errors.New("Transaction underpriced for a full pool")
+	ErrReplaceUnderpriced = errors.New("Replacement transaction underpriced")
+	ErrExpired            = errors.New("Queued transaction expired")
+	ErrQueueLimit         = errors.New("Queued transaction limit exceeded")
 )
 
-const (
-	maxQueued = 64 // max limit of queued txs per address
-)
+// evictionInterval is the time interval to check for expired queued transactions.
+const evictionInterval = time.Minute
+
+// TxPoolConfig are the capacity limits of the transaction pool. Once a global
+// limit is exceeded the cheapest remote transactions are evicted; transactions
+// submitted locally are never evicted to make room for others.
+type TxPoolConfig struct {
+	AccountSlots uint64 // Number of executable transactions guaranteed per account
+	GlobalSlots  uint64 // Maximum number of executable transactions of all accounts
+	AccountQueue uint64 // Maximum number of non-executable transactions per account
+	GlobalQueue  uint64 // Maximum number of non-executable transactions of all accounts
+
+	PriceBump uint64 // Minimum gas price bump in percent to replace a transaction with the same nonce
+
+	Journal   string        // Journal of local transactions to survive node restarts (empty = disabled)
+	Rejournal time.Duration // Time interval to regenerate the local transaction journal
+
+	Lifetime time.Duration // Maximum time a remote account may have non-executable transactions queued
+}
+
+// DefaultTxPoolConfig contains the default capacity limits of the transaction pool.
+var DefaultTxPoolConfig = TxPoolConfig{
+	AccountSlots: 16,
+	GlobalSlots:  4096,
+	AccountQueue: 64,
+	GlobalQueue:  1024,
+
+	PriceBump: 10,
+
+	Rejournal: time.Hour,
+
+	Lifetime: 3 * time.Hour,
+}
+
+// sanitize replaces the unset limits of the configuration with the defaults.
+func (config TxPoolConfig) sanitize() TxPoolConfig {
+	if config.AccountSlots == 0 {
+		config.AccountSlots = DefaultTxPoolConfig.AccountSlots
+	}
+	if config.GlobalSlots == 0 {
+		config.GlobalSlots = DefaultTxPoolConfig.GlobalSlots
+	}
+	if config.AccountQueue == 0 {
+		config.AccountQueue = DefaultTxPoolConfig.AccountQueue
+	}
+	if config.GlobalQueue == 0 {
+		config.GlobalQueue = DefaultTxPoolConfig.GlobalQueue
+	}
+	if config.PriceBump == 0 {
+		config.PriceBump = DefaultTxPoolConfig.PriceBump
+	}
+	if config.Rejournal < time.Second {
+		config.Rejournal = time.Second
+	}
+	if config.Lifetime == 0 {
+		config.Lifetime = DefaultTxPoolConfig.Lifetime
+	}
+	return config
+}
 
 type stateFn func() (*state.StateDB, error)
 
@@ -59,8 +121,11 @@ type stateFn func() (*state.StateDB, error)
 // current state) and future transactions. Transactions move between those
 // two states over time as they are received and processed.
 type TxPool struct {
-	quit         chan bool // Quiting channel
-	currentState stateFn   // The state function which will allow us to do some pre checkes
+	config       *ChainConfig
+	poolConfig   TxPoolConfig
+	quit         chan bool      // Quiting channel
+	currentState stateFn        // The state function which will allow us to do some pre checkes
+	chainDb      vecdb.Database // Chain database to tell included transactions apart
 	pendingState *state.ManagedState
 	gasLimit     func() *big.Int // The current gas limit function callback
 	minGasPrice  *big.Int
@@ -69,28 +134,130 @@ type TxPool struct {
 	mu           sync.RWMutex
 	pending      map[common.Hash]*types.Transaction // processable transactions
 	queue        map[common.Address]map[common.Hash]*types.Transaction
+	queued       uint64                                           // number of queued transactions
+	nonces       map[common.Address]map[uint64]*types.Transaction // pending and queued transactions by account and nonce
+	pendingCosts *txPriceHeap                                     // remote processable transactions by gas price
+	queueCosts   *txPriceHeap                                     // remote queued transactions by gas price
+	locals       map[common.Address]struct{}                      // accounts exempt from the capacity limits
+	journal      *txJournal                                       // journal of local transactions to back up to disk
+	beats        map[common.Address]time.Time                     // last promotion or first queuing of each account
 
 	homestead bool
 }
 
-func NewTxPool(eventMux *event.TypeMux, currentStateFn stateFn, gasLimitFn func() *big.Int) *TxPool {
+func NewTxPool(config *ChainConfig, poolConfig TxPoolConfig, eventMux *event.TypeMux, chainDb vecdb.Database, currentStateFn stateFn, gasLimitFn func() *big.Int) *TxPool {
 	pool := &TxPool{
+		config:       config,
+		poolConfig:   poolConfig.sanitize(),
 		pending:      make(map[common.Hash]*types.Transaction),
 		queue:        make(map[common.Address]map[common.Hash]*types.Transaction),
+		nonces:       make(map[common.Address]map[uint64]*types.Transaction),
+		pendingCosts: newTxPriceHeap(),
+		queueCosts:   newTxPriceHeap(),
+		locals:       make(map[common.Address]struct{}),
+		beats:        make(map[common.Address]time.Time),
 		quit:         make(chan bool),
 		eventMux:     eventMux,
 		currentState: currentStateFn,
+		chainDb:      chainDb,
 		gasLimit:     gasLimitFn,
 		minGasPrice:  new(big.Int),
 		pendingState: nil,
 		events:       eventMux.Subscribe(ChainHeadEvent{}, GasPriceChanged{}, RemovedTransactionEvent{}),
 	}
+	// Reinject the local transactions of a previous run and start journaling
+	if pool.poolConfig.Journal != "" {
+		pool.journal = newTxJournal(pool.poolConfig.Journal)
 
+		if err := pool.journal.load(pool.addLocals); err != nil {
+			glog.V(logger.Warn).Infof("Failed to load transaction journal: %v", err)
+		}
+		if err := pool.journal.rotate(pool.localTxs()); err != nil {
+			glog.V(logger.Warn).Infof("Failed to rotate transaction journal: %v", err)
+		}
+	}
 	go pool.eventLoop()
+	go pool.loop()
 
 	return pool
 }
 
+// loop periodically evicts the expired queued transactions and regenerates the
+// journal of local transactions, dropping the ones that have left the pool in
+// the meantime.
+func (pool *TxPool) loop() {
+	evict := time.NewTicker(evictionInterval)
+	defer evict.Stop()
+
+	var rejournal <-chan time.Time
+	if pool.journal != nil {
+		ticker := time.NewTicker(pool.poolConfig.Rejournal)
+		defer ticker.Stop()
+		rejournal = ticker.C
+	}
+	for {
+		select {
+		case <-evict.C:
+			pool.mu.Lock()
+			pool.expireQueue(time.Now())
+			pool.mu.Unlock()
+
+		case <-rejournal:
+			pool.mu.Lock()
+			if err := pool.journal.rotate(pool.localTxs()); err != nil {
+				glog.V(logger.Warn).Infof("Failed to rotate transaction journal: %v", err)
+			}
+			pool.mu.Unlock()
+
+		case <-pool.quit:
+			if pool.journal != nil {
+				pool.mu.Lock()
+				pool.journal.close()
+				pool.mu.Unlock()
+			}
+			return
+		}
+	}
+}
+
+// expireQueue drops the queued transactions of the remote accounts that have
+// not had any of them promoted within the configured lifetime.
+func (pool *TxPool) expireQueue(now time.Time) {
+	for addr, beat := range pool.beats {
+		txs, ok := pool.queue[addr]
+		if !ok {
+			delete(pool.beats, addr)
+			continue
+		}
+		if pool.isLocal(addr) || now.Sub(beat) <= pool.poolConfig.Lifetime {
+			continue
+		}
+		for hash, tx := range txs {
+			if glog.V(logger.Debug) {
+				glog.Infof("Queued tx %s expired\n", common.PP(hash[:]))
+			}
+			pool.unqueueTx(addr, hash)
+			pool.dropTx(tx, ErrExpired)
+		}
+		delete(pool.beats, addr)
+	}
+}
+
+// dropTx notifies the subscribers of a transaction that was removed from the
+// pool for the given reason.
+func (pool *TxPool) dropTx(tx *types.Transaction, reason error) {
+	// Posted in a goroutine for the same reason as TxPreEvent, see addTx
+	go pool.eventMux.Post(TxDroppedEvent{tx, reason})
+}
+
+// dropStaleTx notifies the subscribers of a transaction removed from the pool
+// as its nonce was used by the chain, unless it was included itself.
+func (pool *TxPool) dropStaleTx(tx *types.Transaction) {
+	if included, _, _, _ := GetTransaction(pool.chainDb, tx.Hash()); included == nil {
+		pool.dropTx(tx, ErrNonce)
+	}
+}
+
 func (pool *TxPool) eventLoop() {
 	// Track chain events. When a chain events occurs (new chain canon block)
 	// we need to know the new state. The new state will help us determine
@@ -99,7 +266,7 @@ func (pool *TxPool) eventLoop() {
 		switch ev := ev.Data.(type) {
 		case ChainHeadEvent:
 			pool.mu.Lock()
-			if ev.Block != nil && params.IsHomestead(ev.Block.Number()) {
+			if ev.Block != nil && pool.config.IsHomestead(ev.Block.Number()) {
 				pool.homestead = true
 			}
 
@@ -163,15 +330,42 @@ func (pool *TxPool) State() *state.ManagedState {
 	return pool.pendingState
 }
 
+// Config returns the capacity limits of the transaction pool.
+func (pool *TxPool) Config() TxPoolConfig {
+	return pool.poolConfig
+}
+
 func (pool *TxPool) Stats() (pending int, queued int) {
 	pool.mu.RLock()
 	defer pool.mu.RUnlock()
 
-	pending = len(pool.pending)
-	for _, txs := range pool.queue {
-		queued += len(txs)
+	return len(pool.pending), int(pool.queued)
+}
+
+// Content retrieves the data content of the transaction pool, returning all the
+// pending as well as queued transactions, grouped by account and sorted by nonce.
+func (pool *TxPool) Content() (map[common.Address]types.Transactions, map[common.Address]types.Transactions) {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	pending := make(map[common.Address]types.Transactions)
+	for _, tx := range pool.pending {
+		from, _ := tx.From()
+		pending[from] = append(pending[from], tx)
 	}
-	return
+	queued := make(map[common.Address]types.Transactions)
+	for addr, txs := range pool.queue {
+		for _, tx := range txs {
+			queued[addr] = append(queued[addr], tx)
+		}
+	}
+	for _, txs := range pending {
+		sort.Sort(types.TxByNonce(txs))
+	}
+	for _, txs := range queued {
+		sort.Sort(types.TxByNonce(txs))
+	}
+	return pending, queued
 }
 
 // validateTx checks whvec a transaction is valid according
@@ -230,8 +424,9 @@ func (pool *TxPool) validateTx(tx *types.Transaction) error {
 	return nil
 }
 
-// validate and queue transactions.
-func (self *TxPool) add(tx *types.Transaction) error {
+// validate and queue transactions. Local transactions exempt their sender
+// from the capacity limits of the pool.
+func (self *TxPool) add(tx *types.Transaction, local bool) error {
 	hash := tx.Hash()
 
 	if self.pending[hash] != nil {
@@ -241,7 +436,38 @@ func (self *TxPool) add(tx *types.Transaction) error {
 	if err != nil {
 		return err
 	}
+	from, _ := tx.From() // already validated
+
+	// A transaction with a nonce already in the pool may only replace the old
+	// one if it raises the gas price by at least the configured percentage
+	if old, executable := self.sameNonce(from, tx.Nonce()); old != nil {
+		if old.Hash() == hash {
+			return fmt.Errorf("Known transaction (%x)", hash[:4])
+		}
+		threshold := new(big.Int).Mul(old.GasPrice(), big.NewInt(int64(100+self.poolConfig.PriceBump)))
+		if new(big.Int).Mul(tx.GasPrice(), big.NewInt(100)).Cmp(threshold) < 0 {
+			return ErrReplaceUnderpriced
+		}
+		self.replaceTx(from, old, tx, executable)
+		if local {
+			self.markLocal(from)
+		}
+		return nil
+	}
+	// If the pool is full, only accept remote transactions outbidding the
+	// cheapest remote one, which will be evicted to make room for it
+	if !local && !self.isLocal(from) {
+		executable := self.pendingState != nil && tx.Nonce() <= self.pendingState.GetNonce(from)
+		if self.full(executable) {
+			if cheapest := self.cheapestRemote(executable); cheapest == nil || tx.GasPrice().Cmp(cheapest) <= 0 {
+				return ErrUnderpriced
+			}
+		}
+	}
 	self.queueTx(hash, tx)
+	if local {
+		self.markLocal(from)
+	}
 
 	if glog.V(logger.Debug) {
 		var toname string
@@ -260,13 +486,129 @@ func (self *TxPool) add(tx *types.Transaction) error {
 	return nil
 }
 
+// sameNonce returns the transaction of the account with the given nonce if
+// the pool already holds one, and whether that transaction is executable.
+func (pool *TxPool) sameNonce(from common.Address, nonce uint64) (*types.Transaction, bool) {
+	tx := pool.nonces[from][nonce]
+	if tx == nil {
+		return nil, false
+	}
+	_, executable := pool.pending[tx.Hash()]
+	return tx, executable
+}
+
+// replaceTx drops a transaction from the pool, taking over its position in
+// the executable or future queue with another one of the same nonce.
+func (pool *TxPool) replaceTx(from common.Address, old, tx *types.Transaction, executable bool) {
+	if glog.V(logger.Debug) {
+		glog.Infof("Replaced tx %x (price %v) with %x (price %v)\n", old.Hash().Bytes()[:4], old.GasPrice(), tx.Hash().Bytes()[:4], tx.GasPrice())
+	}
+	go pool.eventMux.Post(TxReplacedEvent{old, tx})
+
+	if !executable {
+		pool.queueTx(tx.Hash(), tx)
+		pool.unqueueTx(from, old.Hash())
+		return
+	}
+	pool.pendTx(tx.Hash(), from, tx)
+	pool.unpendTx(old.Hash())
+
+	// Notify the subscribers of the new processable transaction, see addTx
+	go pool.eventMux.Post(TxPreEvent{tx})
+}
+
 // queueTx will queue an unknown transaction
 func (self *TxPool) queueTx(hash common.Hash, tx *types.Transaction) {
 	from, _ := tx.From() // already validated
 	if self.queue[from] == nil {
 		self.queue[from] = make(map[common.Hash]*types.Transaction)
 	}
+	if _, ok := self.beats[from]; !ok {
+		self.beats[from] = time.Now()
+	}
+	if _, ok := self.queue[from][hash]; ok {
+		return
+	}
 	self.queue[from][hash] = tx
+	self.queued++
+
+	self.indexTx(from, tx)
+	if !self.isLocal(from) {
+		self.queueCosts.insert(txQueueEntry{hash, from, tx})
+	}
+}
+
+// unqueueTx removes a transaction from the future queue.
+func (pool *TxPool) unqueueTx(addr common.Address, hash common.Hash) {
+	txs := pool.queue[addr]
+	tx, ok := txs[hash]
+	if !ok {
+		return
+	}
+	delete(txs, hash)
+	if len(txs) == 0 {
+		delete(pool.queue, addr)
+	}
+	pool.queued--
+
+	pool.queueCosts.remove(hash)
+	pool.unindexTx(addr, hash, tx)
+}
+
+// pendTx inserts a transaction into the processable set, without touching the
+// pending state or notifying the subscribers.
+func (pool *TxPool) pendTx(hash common.Hash, addr common.Address, tx *types.Transaction) {
+	if _, ok := pool.pending[hash]; ok {
+		return
+	}
+	pool.pending[hash] = tx
+
+	pool.indexTx(addr, tx)
+	if !pool.isLocal(addr) {
+		pool.pendingCosts.insert(txQueueEntry{hash, addr, tx})
+	}
+}
+
+// unpendTx removes a transaction from the processable set.
+func (pool *TxPool) unpendTx(hash common.Hash) {
+	tx, ok := pool.pending[hash]
+	if !ok {
+		return
+	}
+	delete(pool.pending, hash)
+
+	addr, _ := tx.From()
+	pool.pendingCosts.remove(hash)
+	pool.unindexTx(addr, hash, tx)
+}
+
+// indexTx tracks a transaction entering the pool under its account and nonce.
+func (pool *TxPool) indexTx(addr common.Address, tx *types.Transaction) {
+	if pool.nonces[addr] == nil {
+		pool.nonces[addr] = make(map[uint64]*types.Transaction)
+	}
+	pool.nonces[addr][tx.Nonce()] = tx
+}
+
+// unindexTx stops tracking a transaction once it left both the processable set
+// and the future queue, or was replaced by another one with the same nonce. The
+// accounts without any transactions left lose their local status.
+func (pool *TxPool) unindexTx(addr common.Address, hash common.Hash, tx *types.Transaction) {
+	if _, ok := pool.pending[hash]; ok {
+		return
+	}
+	if _, ok := pool.queue[addr][hash]; ok {
+		return
+	}
+	nonces := pool.nonces[addr]
+	if indexed := nonces[tx.Nonce()]; indexed == nil || indexed.Hash() != hash {
+		return
+	}
+	delete(nonces, tx.Nonce())
+	if len(nonces) == 0 {
+		delete(pool.nonces, addr)
+		delete(pool.locals, addr)
+	}
 }
 
 // addTx will add a transaction to the pending (processable queue) list of transactions
@@ -276,8 +618,10 @@ func (pool *TxPool) addTx(hash common.Hash, addr common.Address, tx *types.Trans
 		pool.resetState()
 	}
 
+	pool.beats[addr] = time.Now()
+
 	if _, ok := pool.pending[hash]; !ok {
-		pool.pending[hash] = tx
+		pool.pendTx(hash, addr, tx)
 
 		// Increment the nonce on the pending state. This can only happen if
 		// the nonce is +1 to the previous one.
@@ -289,25 +633,77 @@ func (pool *TxPool) addTx(hash common.Hash, addr common.Address, tx *types.Trans
 	}
 }
 
-// Add queues a single transaction in the pool if it is valid.
+// Add queues a single locally submitted transaction in the pool if it is
+// valid. The sender of the transaction becomes exempt from the capacity limits.
 func (self *TxPool) Add(tx *types.Transaction) error {
 	self.mu.Lock()
 	defer self.mu.Unlock()
 
-	if err := self.add(tx); err != nil {
+	if err := self.add(tx, true); err != nil {
 		return err
 	}
+	if self.journal != nil {
+		if err := self.journal.insert(tx); err != nil {
+			glog.V(logger.Warn).Infof("Failed to journal local transaction: %v", err)
+		}
+	}
 	self.checkQueue()
 	return nil
 }
 
-// AddTransactions attempts to queue all valid transactions in txs.
+// addLocals queues a batch of previously journaled local transactions.
+func (self *TxPool) addLocals(txs types.Transactions) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	for _, tx := range txs {
+		if err := self.add(tx, true); err != nil {
+			glog.V(logger.Debug).Infoln("journaled tx error:", err)
+		}
+	}
+	self.checkQueue()
+}
+
+// localTxs returns all the transactions in the pool sent by local accounts,
+// ordered by nonce.
+func (self *TxPool) localTxs() types.Transactions {
+	var txs types.Transactions
+	for addr := range self.locals {
+		for _, tx := range self.nonces[addr] {
+			txs = append(txs, tx)
+		}
+	}
+	sort.Sort(types.TxByNonce(txs))
+	return txs
+}
+
+// markLocal exempts an account from the capacity limits of the pool, sparing
+// its transactions from eviction.
+func (self *TxPool) markLocal(addr common.Address) {
+	if self.isLocal(addr) {
+		return
+	}
+	self.locals[addr] = struct{}{}
+	for _, tx := range self.nonces[addr] {
+		self.pendingCosts.remove(tx.Hash())
+		self.queueCosts.remove(tx.Hash())
+	}
+}
+
+// isLocal reports whether the account has submitted transactions locally,
+// exempting it from the capacity limits of the pool.
+func (self *TxPool) isLocal(addr common.Address) bool {
+	_, ok := self.locals[addr]
+	return ok
+}
+
+// AddTransactions attempts to queue all valid remote transactions in txs.
 func (self *TxPool) AddTransactions(txs []*types.Transaction) {
 	self.mu.Lock()
 	defer self.mu.Unlock()
 
 	for _, tx := range txs {
-		if err := self.add(tx); err != nil {
+		if err := self.add(tx, false); err != nil {
 			glog.V(logger.Debug).Infoln("tx error:", err)
 		} else {
 			h := tx.Hash()
@@ -382,16 +778,11 @@ func (self *TxPool) RemoveTransactions(txs types.Transactions) {
 // RemoveTx removes the transaction with the given hash from the pool.
 func (pool *TxPool) RemoveTx(hash common.Hash) {
 	// delete from pending pool
-	delete(pool.pending, hash)
+	pool.unpendTx(hash)
 	// delete from queue
 	for address, txs := range pool.queue {
 		if _, ok := txs[hash]; ok {
-			if len(txs) == 1 {
-				// if only one tx, remove entire address entry.
-				delete(pool.queue, address)
-			} else {
-				delete(txs, hash)
-			}
+			pool.unqueueTx(address, hash)
 			break
 		}
 	}
@@ -424,7 +815,12 @@ func (pool *TxPool) checkQueue() {
 				if glog.V(logger.Core) {
 					glog.Infof("removed tx (%v) from pool queue: low tx nonce or out of funds\n", tx)
 				}
-				delete(txs, hash)
+				pool.unqueueTx(address, hash)
+				if tx.Nonce() < trueNonce {
+					pool.dropStaleTx(tx)
+				} else {
+					pool.dropTx(tx, ErrInsufficientFunds)
+				}
 				continue
 			}
 			// Collect the remaining transactions for the next pass.
@@ -436,28 +832,116 @@ func (pool *TxPool) checkQueue() {
 		for i, entry := range promote {
 			// If we reached a gap in the nonces, enforce transaction limit and stop
 			if entry.Nonce() > guessedNonce {
-				if len(promote)-i > maxQueued {
+				if limit := pool.poolConfig.AccountQueue; uint64(len(promote)-i) > limit {
 					if glog.V(logger.Debug) {
 						glog.Infof("Queued tx limit exceeded for %s. Tx %s removed\n", common.PP(address[:]), common.PP(entry.hash[:]))
 					}
-					for _, drop := range promote[i+maxQueued:] {
-						delete(txs, drop.hash)
+					for _, drop := range promote[uint64(i)+limit:] {
+						pool.unqueueTx(address, drop.hash)
+						pool.dropTx(drop.Transaction, ErrQueueLimit)
 					}
 				}
 				break
 			}
 			// Otherwise promote the transaction and move the guess nonce if needed
 			pool.addTx(entry.hash, address, entry.Transaction)
-			delete(txs, entry.hash)
+			pool.unqueueTx(address, entry.hash)
 
 			if entry.Nonce() == guessedNonce {
 				guessedNonce++
 			}
 		}
-		// Delete the entire queue entry if it became empty.
-		if len(txs) == 0 {
-			delete(pool.queue, address)
+	}
+	// Evict the cheapest remote transactions if the pool is over capacity
+	pool.truncatePending()
+	pool.truncateQueue()
+}
+
+// full reports whether the pool holds as many executable or non-executable
+// transactions as the global limits allow.
+func (pool *TxPool) full(executable bool) bool {
+	if executable {
+		return uint64(len(pool.pending)) >= pool.poolConfig.GlobalSlots
+	}
+	return pool.queued >= pool.poolConfig.GlobalQueue
+}
+
+// cheapestRemote returns the lowest gas price of the remote executable or
+// non-executable transactions in the pool, or nil if there are none.
+func (pool *TxPool) cheapestRemote(executable bool) *big.Int {
+	costs := pool.queueCosts
+	if executable {
+		costs = pool.pendingCosts
+	}
+	if cheapest, ok := costs.cheapest(); ok {
+		return cheapest.GasPrice()
+	}
+	return nil
+}
+
+// truncatePending evicts the cheapest remote executable transactions while the
+// pool holds more of them than the global limit allows. Accounts holding more
+// than their guaranteed slots are trimmed first, and only then are the others
+// touched. The transactions of the same account following an evicted one are
+// moved back into the future queue.
+func (pool *TxPool) truncatePending() {
+	if uint64(len(pool.pending)) <= pool.poolConfig.GlobalSlots {
+		return
+	}
+	var (
+		remotes = append(txQueue(nil), pool.pendingCosts.entries...)
+		slots   = make(map[common.Address]uint64)
+	)
+	for _, entry := range remotes {
+		slots[entry.addr]++
+	}
+	sort.Sort(txQueueByPrice(remotes))
+
+	for _, guaranteed := range []bool{true, false} {
+		for _, entry := range remotes {
+			if uint64(len(pool.pending)) <= pool.poolConfig.GlobalSlots {
+				return
+			}
+			// Skip transactions already evicted or postponed, and spare the
+			// guaranteed slots of the accounts on the first pass
+			if _, ok := pool.pending[entry.hash]; !ok {
+				continue
+			}
+			if guaranteed && slots[entry.addr] <= pool.poolConfig.AccountSlots {
+				continue
+			}
+			if glog.V(logger.Debug) {
+				glog.Infof("Pending tx limit exceeded. Tx %s removed\n", common.PP(entry.hash[:]))
+			}
+			pool.unpendTx(entry.hash)
+			pool.dropTx(entry.Transaction, ErrUnderpriced)
+			slots[entry.addr]--
+
+			for nonce, tx := range pool.nonces[entry.addr] {
+				if hash := tx.Hash(); nonce > entry.Nonce() && pool.pending[hash] != nil {
+					pool.queueTx(hash, tx)
+					pool.unpendTx(hash)
+					slots[entry.addr]--
+				}
+			}
+			pool.pendingState.SetNonce(entry.addr, entry.Nonce())
+		}
+	}
+}
+
+// truncateQueue evicts the cheapest remote non-executable transactions while the
+// pool holds more of them than the global limit allows.
+func (pool *TxPool) truncateQueue() {
+	for pool.queued > pool.poolConfig.GlobalQueue {
+		entry, ok := pool.queueCosts.cheapest()
+		if !ok {
+			return
+		}
+		if glog.V(logger.Debug) {
+			glog.Infof("Queued tx limit exceeded. Tx %s removed\n", common.PP(entry.hash[:]))
 		}
+		pool.unqueueTx(entry.addr, entry.hash)
+		pool.dropTx(entry.Transaction, ErrUnderpriced)
 	}
 }
 
@@ -491,10 +975,13 @@ func (pool *TxPool) validatePool() {
 			if glog.V(logger.Core) {
 				glog.Infof("removed tx (%v) from pool: low tx nonce or out of funds\n", tx)
 			}
-			delete(pool.pending, hash)
+			pool.unpendTx(hash)
 
 			// Track the smallest invalid nonce to postpone subsequent transactions
-			if !past {
+			if past {
+				pool.dropStaleTx(tx)
+			} else {
+				pool.dropTx(tx, ErrInsufficientFunds)
 				if prev, ok := gaps[sender]; !ok || tx.Nonce() < prev {
 					gaps[sender] = tx.Nonce()
 				}
@@ -510,7 +997,7 @@ func (pool *TxPool) validatePool() {
 					glog.Infof("postponed tx (%v) due to introduced gap\n", tx)
 				}
 				pool.queueTx(hash, tx)
-				delete(pool.pending, hash)
+				pool.unpendTx(hash)
 			}
 		}
 	}
@@ -527,3 +1014,73 @@ type txQueueEntry struct {
 func (q txQueue) Len() int           { return len(q) }
 func (q txQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
 func (q txQueue) Less(i, j int) bool { return q[i].Nonce() < q[j].Nonce() }
+
+// txQueueByPrice sorts queue entries by increasing gas price. Among equally
+// priced entries the higher nonces come first, so evicting in order opens as
+// few nonce gaps as possible.
+type txQueueByPrice txQueue
+
+func (q txQueueByPrice) Len() int      { return len(q) }
+func (q txQueueByPrice) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q txQueueByPrice) Less(i, j int) bool {
+	if cmp := q[i].GasPrice().Cmp(q[j].GasPrice()); cmp != 0 {
+		return cmp < 0
+	}
+	return q[i].Nonce() > q[j].Nonce()
+}
+
+// txPriceHeap is a min-heap of transactions in txQueueByPrice order, indexed by
+// hash so that the transactions leaving the pool can be removed from it.
+type txPriceHeap struct {
+	entries txQueue
+	index   map[common.Hash]int
+}
+
+func newTxPriceHeap() *txPriceHeap {
+	return &txPriceHeap{index: make(map[common.Hash]int)}
+}
+
+func (h *txPriceHeap) Len() int           { return len(h.entries) }
+func (h *txPriceHeap) Less(i, j int) bool { return txQueueByPrice(h.entries).Less(i, j) }
+
+func (h *txPriceHeap) Swap(i, j int) {
+	h.entries[i], h.entries[j] = h.entries[j], h.entries[i]
+	h.index[h.entries[i].hash] = i
+	h.index[h.entries[j].hash] = j
+}
+
+func (h *txPriceHeap) Push(x interface{}) {
+	entry := x.(txQueueEntry)
+	h.index[entry.hash] = len(h.entries)
+	h.entries = append(h.entries, entry)
+}
+
+func (h *txPriceHeap) Pop() interface{} {
+	last := len(h.entries) - 1
+	entry := h.entries[last]
+	h.entries = h.entries[:last]
+	delete(h.index, entry.hash)
+	return entry
+}
+
+// insert adds a transaction to the heap, unless already contained.
+func (h *txPriceHeap) insert(entry txQueueEntry) {
+	if _, ok := h.index[entry.hash]; !ok {
+		heap.Push(h, entry)
+	}
+}
+
+// remove drops a transaction from the heap, if contained.
+func (h *txPriceHeap) remove(hash common.Hash) {
+	if i, ok := h.index[hash]; ok {
+		heap.Remove(h, i)
+	}
+}
+
+// cheapest returns the lowest priced transaction of the heap, if any.
+func (h *txPriceHeap) cheapest() (txQueueEntry, bool) {
+	if len(h.entries) == 0 {
+		return txQueueEntry{}, false
+	}
+	return h.entries[0], true
+}
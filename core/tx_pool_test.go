@@ -18,8 +18,12 @@ package core
 
 import (
 	"crypto/ecdsa"
+	"io/ioutil"
 	"math/big"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/vector/go-vector/common"
 	"github.com/vector/go-vector/core/state"
@@ -30,21 +34,77 @@ import (
 )
 
 func transaction(nonce uint64, gaslimit *big.Int, key *ecdsa.PrivateKey) *types.Transaction {
-	tx, _ := types.NewTransaction(nonce, common.Address{}, big.NewInt(100), gaslimit, big.NewInt(1), nil).SignECDSA(key)
+	return pricedTransaction(nonce, gaslimit, big.NewInt(1), key)
+}
+
+func pricedTransaction(nonce uint64, gaslimit, gasprice *big.Int, key *ecdsa.PrivateKey) *types.Transaction {
+	tx, _ := types.NewTransaction(nonce, common.Address{}, big.NewInt(100), gaslimit, gasprice, nil).SignECDSA(key)
 	return tx
 }
 
 func setupTxPool() (*TxPool, *ecdsa.PrivateKey) {
+	return setupTxPoolWithConfig(DefaultTxPoolConfig)
+}
+
+func setupTxPoolWithConfig(config TxPoolConfig) (*TxPool, *ecdsa.PrivateKey) {
 	db, _ := vecdb.NewMemDatabase()
 	statedb, _ := state.New(common.Hash{}, db)
 
 	var m event.TypeMux
 	key, _ := crypto.GenerateKey()
-	newPool := NewTxPool(&m, func() (*state.StateDB, error) { return statedb, nil }, func() *big.Int { return big.NewInt(1000000) })
+	newPool := NewTxPool(MainNetChainConfig, config, &m, db, func() (*state.StateDB, error) { return statedb, nil }, func() *big.Int { return big.NewInt(1000000) })
 	newPool.resetState()
 	return newPool, key
 }
 
+// validateTxPoolInternals checks that the queued counter, the nonce index and the
+// price heaps of the pool are in sync with its pending and queued transactions.
+func validateTxPoolInternals(t *testing.T, pool *TxPool) {
+	var queued uint64
+	for _, txs := range pool.queue {
+		queued += uint64(len(txs))
+	}
+	if pool.queued != queued {
+		t.Errorf("queued counter mismatch: have %d, want %d", pool.queued, queued)
+	}
+	indexed, remotes := 0, make(map[common.Hash]bool)
+	for addr, nonces := range pool.nonces {
+		for nonce, tx := range nonces {
+			if tx.Nonce() != nonce {
+				t.Errorf("tx %x: indexed under nonce %d, have %d", tx.Hash(), nonce, tx.Nonce())
+			}
+			if pool.GetTransaction(tx.Hash()) == nil {
+				t.Errorf("tx %x: indexed but not pooled", tx.Hash())
+			}
+			if !pool.isLocal(addr) {
+				remotes[tx.Hash()] = true
+			}
+			indexed++
+		}
+	}
+	if pooled := len(pool.pending) + int(queued); indexed != pooled {
+		t.Errorf("nonce index size mismatch: have %d, want %d", indexed, pooled)
+	}
+	for addr := range pool.locals {
+		if len(pool.nonces[addr]) == 0 {
+			t.Errorf("local account %x: kept without transactions", addr)
+		}
+	}
+	if have := pool.pendingCosts.Len() + pool.queueCosts.Len(); have != len(remotes) {
+		t.Errorf("price heap size mismatch: have %d, want %d", have, len(remotes))
+	}
+	for _, entry := range pool.pendingCosts.entries {
+		if pool.pending[entry.hash] == nil || !remotes[entry.hash] {
+			t.Errorf("tx %x: priced as pending remote", entry.hash)
+		}
+	}
+	for _, entry := range pool.queueCosts.entries {
+		if pool.queue[entry.addr][entry.hash] == nil || !remotes[entry.hash] {
+			t.Errorf("tx %x: priced as queued remote", entry.hash)
+		}
+	}
+}
+
 func TestInvalidTransactions(t *testing.T) {
 	pool, key := setupTxPool()
 
@@ -174,14 +234,14 @@ func TestTransactionChainFork(t *testing.T) {
 	resetState()
 
 	tx := transaction(0, big.NewInt(100000), key)
-	if err := pool.add(tx); err != nil {
+	if err := pool.add(tx, false); err != nil {
 		t.Error("didn't expect error", err)
 	}
 	pool.RemoveTransactions([]*types.Transaction{tx})
 
 	// reset the pool's internal state
 	resetState()
-	if err := pool.add(tx); err != nil {
+	if err := pool.add(tx, false); err != nil {
 		t.Error("didn't expect error", err)
 	}
 }
@@ -201,16 +261,23 @@ func TestTransactionDoubleNonce(t *testing.T) {
 
 	tx := transaction(0, big.NewInt(100000), key)
 	tx2 := transaction(0, big.NewInt(1000000), key)
-	if err := pool.add(tx); err != nil {
+	tx3 := pricedTransaction(0, big.NewInt(1000000), big.NewInt(2), key)
+	if err := pool.add(tx, false); err != nil {
 		t.Error("didn't expect error", err)
 	}
-	if err := pool.add(tx2); err != nil {
+	if err := pool.add(tx2, false); err != ErrReplaceUnderpriced {
+		t.Errorf("same priced replacement error mismatch: have %v, want %v", err, ErrReplaceUnderpriced)
+	}
+	if err := pool.add(tx3, false); err != nil {
 		t.Error("didn't expect error", err)
 	}
 
 	pool.checkQueue()
-	if len(pool.pending) != 2 {
-		t.Error("expected 2 pending txs. Got", len(pool.pending))
+	if len(pool.pending) != 1 {
+		t.Error("expected 1 pending tx. Got", len(pool.pending))
+	}
+	if pool.GetTransaction(tx3.Hash()) == nil {
+		t.Error("expected replacement tx in the pool")
 	}
 }
 
@@ -220,7 +287,7 @@ func TestMissingNonce(t *testing.T) {
 	currentState, _ := pool.currentState()
 	currentState.AddBalance(addr, big.NewInt(100000000000000))
 	tx := transaction(1, big.NewInt(100000), key)
-	if err := pool.add(tx); err != nil {
+	if err := pool.add(tx, false); err != nil {
 		t.Error("didn't expect error", err)
 	}
 	if len(pool.pending) != 0 {
@@ -395,20 +462,20 @@ func TestTransactionQueueLimiting(t *testing.T) {
 	state.AddBalance(account, big.NewInt(1000000))
 
 	// Keep queuing up transactions and make sure all above a limit are dropped
-	for i := uint64(1); i <= maxQueued+5; i++ {
+	for i := uint64(1); i <= DefaultTxPoolConfig.AccountQueue+5; i++ {
 		if err := pool.Add(transaction(i, big.NewInt(100000), key)); err != nil {
 			t.Fatalf("tx %d: failed to add transaction: %v", i, err)
 		}
 		if len(pool.pending) != 0 {
 			t.Errorf("tx %d: pending pool size mismatch: have %d, want %d", i, len(pool.pending), 0)
 		}
-		if i <= maxQueued {
+		if i <= DefaultTxPoolConfig.AccountQueue {
 			if len(pool.queue[account]) != int(i) {
 				t.Errorf("tx %d: queue size mismatch: have %d, want %d", i, len(pool.queue[account]), i)
 			}
 		} else {
-			if len(pool.queue[account]) != maxQueued {
-				t.Errorf("tx %d: queue limit mismatch: have %d, want %d", i, len(pool.queue[account]), maxQueued)
+			if uint64(len(pool.queue[account])) != DefaultTxPoolConfig.AccountQueue {
+				t.Errorf("tx %d: queue limit mismatch: have %d, want %d", i, len(pool.queue[account]), DefaultTxPoolConfig.AccountQueue)
 			}
 		}
 	}
@@ -426,7 +493,7 @@ func TestTransactionPendingLimiting(t *testing.T) {
 	state.AddBalance(account, big.NewInt(1000000))
 
 	// Keep queuing up transactions and make sure all above a limit are dropped
-	for i := uint64(0); i < maxQueued+5; i++ {
+	for i := uint64(0); i < DefaultTxPoolConfig.AccountQueue+5; i++ {
 		if err := pool.Add(transaction(i, big.NewInt(100000), key)); err != nil {
 			t.Fatalf("tx %d: failed to add transaction: %v", i, err)
 		}
@@ -451,7 +518,7 @@ func testTransactionLimitingEquivalency(t *testing.T, origin uint64) {
 	state1, _ := pool1.currentState()
 	state1.AddBalance(account1, big.NewInt(1000000))
 
-	for i := uint64(0); i < maxQueued+5; i++ {
+	for i := uint64(0); i < DefaultTxPoolConfig.AccountQueue+5; i++ {
 		if err := pool1.Add(transaction(origin+i, big.NewInt(100000), key1)); err != nil {
 			t.Fatalf("tx %d: failed to add transaction: %v", i, err)
 		}
@@ -463,7 +530,7 @@ func testTransactionLimitingEquivalency(t *testing.T, origin uint64) {
 	state2.AddBalance(account2, big.NewInt(1000000))
 
 	txns := []*types.Transaction{}
-	for i := uint64(0); i < maxQueued+5; i++ {
+	for i := uint64(0); i < DefaultTxPoolConfig.AccountQueue+5; i++ {
 		txns = append(txns, transaction(origin+i, big.NewInt(100000), key2))
 	}
 	pool2.AddTransactions(txns)
@@ -477,6 +544,229 @@ func testTransactionLimitingEquivalency(t *testing.T, origin uint64) {
 	}
 }
 
+// Tests that if the executable transaction count of all accounts goes above the
+// global limit, the cheapest remote ones are evicted, trimming the accounts above
+// their guaranteed slots first and never touching local transactions.
+func TestTransactionPendingGlobalLimiting(t *testing.T) {
+	config := DefaultTxPoolConfig
+	config.AccountSlots = 2
+	config.GlobalSlots = 6
+
+	pool, local := setupTxPoolWithConfig(config)
+	state, _ := pool.currentState()
+
+	keys := make([]*ecdsa.PrivateKey, 3)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		state.AddBalance(crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(1000000000))
+	}
+	state.AddBalance(crypto.PubkeyToAddress(local.PublicKey), big.NewInt(1000000000))
+
+	// Fill the pool with cheap local transactions and remote ones of various prices
+	var locals types.Transactions
+	for i := uint64(0); i < 3; i++ {
+		locals = append(locals, pricedTransaction(i, big.NewInt(100000), big.NewInt(1), local))
+		if err := pool.Add(locals[i]); err != nil {
+			t.Fatalf("local tx %d: failed to add transaction: %v", i, err)
+		}
+	}
+	var txs types.Transactions
+	for i := uint64(0); i < 4; i++ {
+		txs = append(txs, pricedTransaction(i, big.NewInt(100000), big.NewInt(int64(10-i)), keys[0]))
+	}
+	txs = append(txs, pricedTransaction(0, big.NewInt(100000), big.NewInt(2), keys[1]))
+	txs = append(txs, pricedTransaction(0, big.NewInt(100000), big.NewInt(5), keys[2]))
+	pool.AddTransactions(txs)
+
+	if len(pool.pending) != int(config.GlobalSlots) {
+		t.Fatalf("pending pool size mismatch: have %d, want %d", len(pool.pending), config.GlobalSlots)
+	}
+	// The local transactions and the guaranteed slots must survive
+	for i, tx := range locals {
+		if pool.pending[tx.Hash()] == nil {
+			t.Errorf("local tx %d: evicted", i)
+		}
+	}
+	for i, tx := range txs[:2] {
+		if pool.pending[tx.Hash()] == nil {
+			t.Errorf("remote tx %d: evicted from guaranteed slot", i)
+		}
+	}
+	// Beyond the guaranteed slots, the cheapest remote transaction must be gone
+	if pool.pending[txs[3].Hash()] != nil {
+		t.Errorf("cheapest overflowing remote tx not evicted")
+	}
+	if pool.pending[txs[4].Hash()] != nil {
+		t.Errorf("cheapest remote tx not evicted")
+	}
+	validateTxPoolInternals(t, pool)
+}
+
+// Tests that if the non-executable transaction count of all accounts goes above
+// the global limit, the cheapest remote ones are evicted.
+func TestTransactionQueueGlobalLimiting(t *testing.T) {
+	config := DefaultTxPoolConfig
+	config.GlobalQueue = 4
+
+	pool, _ := setupTxPoolWithConfig(config)
+	state, _ := pool.currentState()
+
+	keys := make([]*ecdsa.PrivateKey, 2)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		state.AddBalance(crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(1000000000))
+	}
+	// Queue up gapped transactions, the second account paying more
+	var txs types.Transactions
+	for i := uint64(1); i <= 3; i++ {
+		txs = append(txs, pricedTransaction(i, big.NewInt(100000), big.NewInt(1), keys[0]))
+		txs = append(txs, pricedTransaction(i, big.NewInt(100000), big.NewInt(2), keys[1]))
+	}
+	pool.AddTransactions(txs)
+
+	if _, queued := pool.Stats(); queued != int(config.GlobalQueue) {
+		t.Fatalf("queued transaction count mismatch: have %d, want %d", queued, config.GlobalQueue)
+	}
+	if have := len(pool.queue[crypto.PubkeyToAddress(keys[1].PublicKey)]); have != 3 {
+		t.Errorf("expensive queue size mismatch: have %d, want %d", have, 3)
+	}
+	// A full pool must reject remote transactions not outbidding the cheapest one
+	if err := pool.add(pricedTransaction(5, big.NewInt(100000), big.NewInt(1), keys[1]), false); err != ErrUnderpriced {
+		t.Errorf("underpriced transaction error mismatch: have %v, want %v", err, ErrUnderpriced)
+	}
+	validateTxPoolInternals(t, pool)
+}
+
+// Tests that transactions with an already known nonce only replace the original
+// ones if they raise the gas price by the configured percentage, both in the
+// executable and the future queue.
+func TestTransactionReplacement(t *testing.T) {
+	pool, key := setupTxPool()
+	account, _ := transaction(0, big.NewInt(0), key).From()
+
+	state, _ := pool.currentState()
+	state.AddBalance(account, big.NewInt(1000000000))
+
+	price := int64(100)
+	threshold := price * int64(100+DefaultTxPoolConfig.PriceBump) / 100
+
+	for _, nonce := range []uint64{0, 2} {
+		orig := pricedTransaction(nonce, big.NewInt(100000), big.NewInt(price), key)
+		if err := pool.Add(orig); err != nil {
+			t.Fatalf("nonce %d: failed to add original transaction: %v", nonce, err)
+		}
+		if err := pool.Add(orig); err == nil {
+			t.Errorf("nonce %d: known transaction accepted", nonce)
+		}
+		if err := pool.Add(pricedTransaction(nonce, big.NewInt(100000), big.NewInt(threshold-1), key)); err != ErrReplaceUnderpriced {
+			t.Errorf("nonce %d: underpriced replacement error mismatch: have %v, want %v", nonce, err, ErrReplaceUnderpriced)
+		}
+		repl := pricedTransaction(nonce, big.NewInt(100000), big.NewInt(threshold), key)
+		if err := pool.Add(repl); err != nil {
+			t.Fatalf("nonce %d: failed to replace transaction: %v", nonce, err)
+		}
+		if pool.GetTransaction(orig.Hash()) != nil {
+			t.Errorf("nonce %d: replaced transaction still in pool", nonce)
+		}
+		if pool.GetTransaction(repl.Hash()) == nil {
+			t.Errorf("nonce %d: replacement transaction missing from pool", nonce)
+		}
+	}
+	if pending, queued := pool.Stats(); pending != 1 || queued != 1 {
+		t.Errorf("pool size mismatch: have %d/%d, want %d/%d", pending, queued, 1, 1)
+	}
+	if nonce := pool.State().GetNonce(account); nonce != 1 {
+		t.Errorf("pending nonce mismatch: have %d, want %d", nonce, 1)
+	}
+	validateTxPoolInternals(t, pool)
+}
+
+// Tests that the content of the pool is reported grouped by account and sorted
+// by nonce.
+func TestTransactionPoolContent(t *testing.T) {
+	pool, key := setupTxPool()
+	account, _ := transaction(0, big.NewInt(0), key).From()
+
+	state, _ := pool.currentState()
+	state.AddBalance(account, big.NewInt(1000000000))
+
+	for _, nonce := range []uint64{5, 1, 0, 4} {
+		if err := pool.Add(transaction(nonce, big.NewInt(100000), key)); err != nil {
+			t.Fatalf("nonce %d: failed to add transaction: %v", nonce, err)
+		}
+	}
+	pending, queued := pool.Content()
+	if len(pending) != 1 || len(queued) != 1 {
+		t.Fatalf("account count mismatch: have %d/%d, want %d/%d", len(pending), len(queued), 1, 1)
+	}
+	for i, want := range []uint64{0, 1} {
+		if have := pending[account][i].Nonce(); have != want {
+			t.Errorf("pending tx %d: nonce mismatch: have %d, want %d", i, have, want)
+		}
+	}
+	for i, want := range []uint64{4, 5} {
+		if have := queued[account][i].Nonce(); have != want {
+			t.Errorf("queued tx %d: nonce mismatch: have %d, want %d", i, have, want)
+		}
+	}
+}
+
+// Tests that local transactions are journaled to disk and reinjected into the
+// pool after a restart, while remote and included ones are dropped.
+func TestTransactionJournaling(t *testing.T) {
+	dir, err := ioutil.TempDir("", "txjournal")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := DefaultTxPoolConfig
+	config.Journal = filepath.Join(dir, "transactions.rlp")
+
+	db, _ := vecdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, db)
+	newPool := func() *TxPool {
+		pool := NewTxPool(MainNetChainConfig, config, new(event.TypeMux), db, func() (*state.StateDB, error) { return statedb, nil }, func() *big.Int { return big.NewInt(1000000) })
+		pool.resetState()
+		return pool
+	}
+	local, _ := crypto.GenerateKey()
+	remote, _ := crypto.GenerateKey()
+	statedb.AddBalance(crypto.PubkeyToAddress(local.PublicKey), big.NewInt(1000000000))
+	statedb.AddBalance(crypto.PubkeyToAddress(remote.PublicKey), big.NewInt(1000000000))
+
+	// Add executable and future local transactions along with a remote one
+	pool := newPool()
+	for _, nonce := range []uint64{0, 1, 3} {
+		if err := pool.Add(transaction(nonce, big.NewInt(100000), local)); err != nil {
+			t.Fatalf("nonce %d: failed to add local transaction: %v", nonce, err)
+		}
+	}
+	pool.AddTransactions(types.Transactions{transaction(0, big.NewInt(100000), remote)})
+	pool.Stop()
+
+	// Restart the pool and ensure only the local transactions are reinjected
+	pool = newPool()
+	if pending, queued := pool.Stats(); pending != 2 || queued != 1 {
+		t.Fatalf("pool size mismatch after restart: have %d/%d, want %d/%d", pending, queued, 2, 1)
+	}
+	// Include the first transaction, rotate the journal and restart again
+	statedb.SetNonce(crypto.PubkeyToAddress(local.PublicKey), 1)
+	pool.mu.Lock()
+	pool.resetState()
+	if err := pool.journal.rotate(pool.localTxs()); err != nil {
+		t.Fatalf("failed to rotate journal: %v", err)
+	}
+	pool.mu.Unlock()
+	pool.Stop()
+
+	pool = newPool()
+	if pending, queued := pool.Stats(); pending != 1 || queued != 1 {
+		t.Fatalf("pool size mismatch after rotation: have %d/%d, want %d/%d", pending, queued, 1, 1)
+	}
+	pool.Stop()
+}
+
 // Benchmarks the speed of validating the contents of the pending queue of the
 // transaction pool.
 func BenchmarkValidatePool100(b *testing.B)   { benchmarkValidatePool(b, 100) }
@@ -524,3 +814,197 @@ func benchmarkCheckQueue(b *testing.B, size int) {
 		pool.checkQueue()
 	}
 }
+
+// Tests that the queued transactions of remote accounts are dropped if none of
+// them got promoted within the configured lifetime, while local ones are kept.
+func TestTransactionExpiration(t *testing.T) {
+	config := DefaultTxPoolConfig
+	config.Lifetime = time.Hour
+
+	pool, remote := setupTxPoolWithConfig(config)
+	local, _ := crypto.GenerateKey()
+
+	state, _ := pool.currentState()
+	state.AddBalance(crypto.PubkeyToAddress(local.PublicKey), big.NewInt(1000000000))
+	state.AddBalance(crypto.PubkeyToAddress(remote.PublicKey), big.NewInt(1000000000))
+
+	if err := pool.Add(transaction(1, big.NewInt(100000), local)); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	pool.AddTransactions(types.Transactions{transaction(1, big.NewInt(100000), remote)})
+
+	pool.expireQueue(time.Now())
+	if _, queued := pool.Stats(); queued != 2 {
+		t.Fatalf("queued transaction count mismatch before expiry: have %d, want %d", queued, 2)
+	}
+	pool.expireQueue(time.Now().Add(config.Lifetime + time.Minute))
+	if _, queued := pool.Stats(); queued != 1 {
+		t.Fatalf("queued transaction count mismatch after expiry: have %d, want %d", queued, 1)
+	}
+	if _, ok := pool.queue[crypto.PubkeyToAddress(local.PublicKey)]; !ok {
+		t.Errorf("local queued transaction expired")
+	}
+}
+
+// Tests that transactions leaving the pool are announced along with the reason
+// of their removal.
+func TestTransactionDropEvents(t *testing.T) {
+	pool, key := setupTxPool()
+	account, _ := transaction(0, big.NewInt(0), key).From()
+
+	state, _ := pool.currentState()
+	state.AddBalance(account, big.NewInt(1000000000))
+
+	sub := pool.eventMux.Subscribe(TxDroppedEvent{}, TxReplacedEvent{})
+	defer sub.Unsubscribe()
+
+	orig := pricedTransaction(0, big.NewInt(100000), big.NewInt(1), key)
+	repl := pricedTransaction(0, big.NewInt(100000), big.NewInt(2), key)
+	if err := pool.Add(orig); err != nil {
+		t.Fatalf("failed to add original transaction: %v", err)
+	}
+	if err := pool.Add(repl); err != nil {
+		t.Fatalf("failed to replace transaction: %v", err)
+	}
+	select {
+	case ev := <-sub.Chan():
+		replaced, ok := ev.Data.(TxReplacedEvent)
+		if !ok {
+			t.Fatalf("event type mismatch: have %T, want %T", ev.Data, TxReplacedEvent{})
+		}
+		if replaced.Old != orig || replaced.New != repl {
+			t.Errorf("replaced transactions mismatch: have %x -> %x, want %x -> %x", replaced.Old.Hash(), replaced.New.Hash(), orig.Hash(), repl.Hash())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("replacement event timeout")
+	}
+	// Drain the funds of the account and ensure the drop is announced
+	state.AddBalance(account, big.NewInt(-1000000000))
+	pool.resetState()
+
+	select {
+	case ev := <-sub.Chan():
+		dropped, ok := ev.Data.(TxDroppedEvent)
+		if !ok {
+			t.Fatalf("event type mismatch: have %T, want %T", ev.Data, TxDroppedEvent{})
+		}
+		if dropped.Tx != repl || dropped.Reason != ErrInsufficientFunds {
+			t.Errorf("dropped transaction mismatch: have %x (%v), want %x (%v)", dropped.Tx.Hash(), dropped.Reason, repl.Hash(), ErrInsufficientFunds)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("drop event timeout")
+	}
+}
+
+// Tests that transactions removed because their nonce got included in a block
+// are not announced as dropped.
+func TestTransactionMinedNoDropEvent(t *testing.T) {
+	pool, key := setupTxPool()
+	account, _ := transaction(0, big.NewInt(0), key).From()
+
+	state, _ := pool.currentState()
+	state.AddBalance(account, big.NewInt(1000000000))
+
+	sub := pool.eventMux.Subscribe(TxDroppedEvent{})
+	defer sub.Unsubscribe()
+
+	// Add a pending and a queued transaction, then mine both
+	txs := types.Transactions{transaction(0, big.NewInt(100000), key), transaction(2, big.NewInt(100000), key)}
+	if err := pool.Add(txs[0]); err != nil {
+		t.Fatalf("failed to add pending transaction: %v", err)
+	}
+	if err := pool.Add(txs[1]); err != nil {
+		t.Fatalf("failed to add queued transaction: %v", err)
+	}
+	if pending, queued := pool.Stats(); pending != 1 || queued != 1 {
+		t.Fatalf("pool stats mismatch: have %d/%d, want 1/1", pending, queued)
+	}
+	WriteTransactions(pool.chainDb, types.NewBlock(&types.Header{Number: big.NewInt(1)}, txs, nil, nil))
+	state.SetNonce(account, 3)
+	pool.resetState()
+
+	if pending, queued := pool.Stats(); pending != 0 || queued != 0 {
+		t.Fatalf("pool stats mismatch after mining: have %d/%d, want 0/0", pending, queued)
+	}
+	select {
+	case ev := <-sub.Chan():
+		t.Errorf("mined transaction announced as dropped: %+v", ev.Data)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// Tests that pooled transactions whose nonce got used by other transactions are
+// announced as dropped.
+func TestTransactionNonceUsedDropEvent(t *testing.T) {
+	pool, key := setupTxPool()
+	account, _ := transaction(0, big.NewInt(0), key).From()
+
+	state, _ := pool.currentState()
+	state.AddBalance(account, big.NewInt(1000000000))
+
+	sub := pool.eventMux.Subscribe(TxDroppedEvent{})
+	defer sub.Unsubscribe()
+
+	// Add a pending and a queued transaction, then mine others with their nonces
+	pending, queued := transaction(0, big.NewInt(100000), key), transaction(2, big.NewInt(100000), key)
+	if err := pool.Add(pending); err != nil {
+		t.Fatalf("failed to add pending transaction: %v", err)
+	}
+	if err := pool.Add(queued); err != nil {
+		t.Fatalf("failed to add queued transaction: %v", err)
+	}
+	state.SetNonce(account, 3)
+	pool.resetState()
+
+	dropped := make(map[common.Hash]error)
+	for len(dropped) < 2 {
+		select {
+		case ev := <-sub.Chan():
+			drop := ev.Data.(TxDroppedEvent)
+			dropped[drop.Tx.Hash()] = drop.Reason
+		case <-time.After(time.Second):
+			t.Fatalf("timeout waiting for drop events, have %v", dropped)
+		}
+	}
+	for _, tx := range []*types.Transaction{pending, queued} {
+		if reason := dropped[tx.Hash()]; reason != ErrNonce {
+			t.Errorf("tx #%d: drop reason mismatch: have %v, want %v", tx.Nonce(), reason, ErrNonce)
+		}
+	}
+}
+
+// Tests that accounts lose their local status once all their transactions left
+// the pool, their later remote transactions becoming evictable again.
+func TestTransactionLocalsPruning(t *testing.T) {
+	config := DefaultTxPoolConfig
+	config.GlobalQueue = 1
+
+	pool, key := setupTxPoolWithConfig(config)
+	account, _ := transaction(0, big.NewInt(0), key).From()
+
+	state, _ := pool.currentState()
+	state.AddBalance(account, big.NewInt(1000000000))
+
+	if err := pool.Add(transaction(0, big.NewInt(100000), key)); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	if !pool.isLocal(account) {
+		t.Fatalf("account not marked local")
+	}
+	validateTxPoolInternals(t, pool)
+
+	// Include the transaction and ensure the account is pruned
+	state.SetNonce(account, 1)
+	pool.resetState()
+	if pool.isLocal(account) {
+		t.Errorf("account still local without transactions")
+	}
+	validateTxPoolInternals(t, pool)
+
+	// Remote transactions of the pruned account must obey the capacity limits
+	pool.AddTransactions(types.Transactions{transaction(3, big.NewInt(100000), key), transaction(4, big.NewInt(100000), key)})
+	if _, queued := pool.Stats(); queued != int(config.GlobalQueue) {
+		t.Errorf("queued transaction count mismatch: have %d, want %d", queued, config.GlobalQueue)
+	}
+	validateTxPoolInternals(t, pool)
+}
@@ -139,6 +139,10 @@ type Block struct {
 
 	// ReceivedAt is used by package vec to track block propagation time.
 	ReceivedAt time.Time
+
+	// ReceivedFrom is used by package vec to track the peer a block originated
+	// from, allowing package core to attribute rejected blocks.
+	ReceivedFrom interface{}
 }
 
 // DeprecatedTd is an old relic for extracting the TD of a block. It is in the
@@ -0,0 +1,143 @@
+// Copyright 2016 The go-vector Authors
+// This file is part of the go-vector library.
+//
+// The go-vector library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vector library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vector library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/vector/go-vector/common"
+)
+
+// CallFrame is a single message call or contract creation recorded by the
+// CallTracer, along with the nested ones it made.
+type CallFrame struct {
+	Type    OpCode         // CALL, CALLCODE, DELEGATECALL or CREATE
+	From    common.Address // Address of the caller
+	To      common.Address // Address of the callee or the created contract
+	Input   []byte         // Call data or contract init code
+	Output  []byte         // Return data or deployed contract code
+	Value   *big.Int       // Value transferred (apparent value for DELEGATECALL)
+	Gas     *big.Int       // Gas provided to the frame
+	GasUsed *big.Int       // Gas consumed by the frame, including the nested ones
+	Err     error          // Error aborting the frame, if any
+	Calls   []*CallFrame   // Nested calls made by the frame
+}
+
+// MarshalJSON implements json.Marshaler, encoding the frame with hex numbers.
+func (f *CallFrame) MarshalJSON() ([]byte, error) {
+	frame := map[string]interface{}{
+		"type":    f.Type.String(),
+		"from":    f.From.Hex(),
+		"to":      f.To.Hex(),
+		"input":   fmt.Sprintf("0x%x", f.Input),
+		"output":  fmt.Sprintf("0x%x", f.Output),
+		"value":   fmt.Sprintf("0x%x", f.Value),
+		"gas":     fmt.Sprintf("0x%x", f.Gas),
+		"gasUsed": fmt.Sprintf("0x%x", f.GasUsed),
+	}
+	if f.Err != nil {
+		frame["error"] = f.Err.Error()
+	}
+	if len(f.Calls) > 0 {
+		frame["calls"] = f.Calls
+	}
+	return json.Marshal(frame)
+}
+
+// CallTracer is a Tracer recording the tree of message calls and contract
+// creations of an execution, e.g. to reveal internal value transfers.
+type CallTracer struct {
+	root  *CallFrame
+	stack []*CallFrame // Frames entered but not yet exited, innermost last
+}
+
+// NewCallTracer returns a new call tree tracer.
+func NewCallTracer() *CallTracer {
+	return new(CallTracer)
+}
+
+// CaptureStart implements Tracer, opening the outermost frame.
+func (t *CallTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas, value *big.Int) {
+	typ := CALL
+	if create {
+		typ = CREATE
+	}
+	t.root = newCallFrame(typ, from, to, input, gas, value)
+	t.stack = []*CallFrame{t.root}
+}
+
+// CaptureState implements Tracer, it's a noop for the call tracer.
+func (t *CallTracer) CaptureState(env Environment, pc uint64, op OpCode, gas, cost *big.Int, memory *Memory, stack *Stack, contract *Contract, depth int, err error) {
+}
+
+// CaptureEnter implements Tracer, opening a nested frame within the current one.
+func (t *CallTracer) CaptureEnter(typ OpCode, from common.Address, to common.Address, input []byte, gas, value *big.Int) {
+	if len(t.stack) == 0 {
+		return
+	}
+	frame := newCallFrame(typ, from, to, input, gas, value)
+
+	parent := t.stack[len(t.stack)-1]
+	parent.Calls = append(parent.Calls, frame)
+	t.stack = append(t.stack, frame)
+}
+
+// CaptureExit implements Tracer, closing the current nested frame.
+func (t *CallTracer) CaptureExit(output []byte, gasUsed *big.Int, err error) {
+	if len(t.stack) < 2 {
+		return
+	}
+	t.stack[len(t.stack)-1].close(output, gasUsed, err)
+	t.stack = t.stack[:len(t.stack)-1]
+}
+
+// CaptureEnd implements Tracer, closing the outermost frame.
+func (t *CallTracer) CaptureEnd(output []byte, gasUsed *big.Int, d time.Duration, err error) {
+	if t.root != nil {
+		t.root.close(output, gasUsed, err)
+	}
+	t.stack = nil
+}
+
+// Result returns the outermost frame of the recorded call tree, or nil if no
+// execution was traced.
+func (t *CallTracer) Result() *CallFrame {
+	return t.root
+}
+
+// newCallFrame creates a frame, copying the VM owned input data and values.
+func newCallFrame(typ OpCode, from, to common.Address, input []byte, gas, value *big.Int) *CallFrame {
+	return &CallFrame{
+		Type:    typ,
+		From:    from,
+		To:      to,
+		Input:   common.CopyBytes(input),
+		Value:   new(big.Int).Set(value),
+		Gas:     new(big.Int).Set(gas),
+		GasUsed: new(big.Int),
+	}
+}
+
+// close records the outcome of a frame.
+func (f *CallFrame) close(output []byte, gasUsed *big.Int, err error) {
+	f.Output = common.CopyBytes(output)
+	f.GasUsed.Set(gasUsed)
+	f.Err = err
+}
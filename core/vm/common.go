@@ -25,7 +25,8 @@ import (
 	"github.com/vector/go-vector/params"
 )
 
-// Global Debug flag indicating Debug VM (full logging)
+// Global Debug flag requesting the environments to trace every execution with a
+// StructLogger and dump the logs to stderr (full logging)
 var Debug bool
 
 // Type is the VM type accepted by **NewVm**
@@ -17,10 +17,12 @@
 package vm
 
 import (
+	"errors"
 	"math/big"
 
 	"github.com/vector/go-vector/common"
 	"github.com/vector/go-vector/crypto"
+	"github.com/vector/go-vector/crypto/bn256"
 	"github.com/vector/go-vector/logger"
 	"github.com/vector/go-vector/logger/glog"
 	"github.com/vector/go-vector/params"
@@ -28,43 +30,56 @@ import (
 
 // PrecompiledAccount represents a native vector contract
 type PrecompiledAccount struct {
-	Gas func(l int) *big.Int
-	fn  func(in []byte) []byte
+	Gas func(in []byte) *big.Int
+	fn  func(in []byte) ([]byte, error)
 }
 
 // Call calls the native function
-func (self PrecompiledAccount) Call(in []byte) []byte {
+func (self PrecompiledAccount) Call(in []byte) ([]byte, error) {
 	return self.fn(in)
 }
 
 // Precompiled contains the default set of vector contracts
 var Precompiled = PrecompiledContracts()
 
+// PrecompiledMetropolis contains the set of vector contracts active since the
+// Metropolis fork.
+var PrecompiledMetropolis = PrecompiledContractsMetropolis()
+
+// ActivePrecompiled returns the set of precompiled contracts active under the
+// given rules at the given block number.
+func ActivePrecompiled(ruleSet RuleSet, num *big.Int) map[string]*PrecompiledAccount {
+	if ruleSet.IsMetropolis(num) {
+		return PrecompiledMetropolis
+	}
+	return Precompiled
+}
+
 // PrecompiledContracts returns the default set of precompiled vector
 // contracts defined by the vector yellow paper.
 func PrecompiledContracts() map[string]*PrecompiledAccount {
 	return map[string]*PrecompiledAccount{
 		// ECRECOVER
-		string(common.LeftPadBytes([]byte{1}, 20)): &PrecompiledAccount{func(l int) *big.Int {
+		string(common.LeftPadBytes([]byte{1}, 20)): &PrecompiledAccount{func(in []byte) *big.Int {
 			return params.EcrecoverGas
 		}, ecrecoverFunc},
 
 		// SHA256
-		string(common.LeftPadBytes([]byte{2}, 20)): &PrecompiledAccount{func(l int) *big.Int {
-			n := big.NewInt(int64(l+31) / 32)
+		string(common.LeftPadBytes([]byte{2}, 20)): &PrecompiledAccount{func(in []byte) *big.Int {
+			n := big.NewInt(int64(len(in)+31) / 32)
 			n.Mul(n, params.Sha256WordGas)
 			return n.Add(n, params.Sha256Gas)
 		}, sha256Func},
 
 		// RIPEMD160
-		string(common.LeftPadBytes([]byte{3}, 20)): &PrecompiledAccount{func(l int) *big.Int {
-			n := big.NewInt(int64(l+31) / 32)
+		string(common.LeftPadBytes([]byte{3}, 20)): &PrecompiledAccount{func(in []byte) *big.Int {
+			n := big.NewInt(int64(len(in)+31) / 32)
 			n.Mul(n, params.Ripemd160WordGas)
 			return n.Add(n, params.Ripemd160Gas)
 		}, ripemd160Func},
 
-		string(common.LeftPadBytes([]byte{4}, 20)): &PrecompiledAccount{func(l int) *big.Int {
-			n := big.NewInt(int64(l+31) / 32)
+		string(common.LeftPadBytes([]byte{4}, 20)): &PrecompiledAccount{func(in []byte) *big.Int {
+			n := big.NewInt(int64(len(in)+31) / 32)
 			n.Mul(n, params.IdentityWordGas)
 
 			return n.Add(n, params.IdentityGas)
@@ -72,17 +87,47 @@ func PrecompiledContracts() map[string]*PrecompiledAccount {
 	}
 }
 
-func sha256Func(in []byte) []byte {
-	return crypto.Sha256(in)
+// PrecompiledContractsMetropolis returns the set of precompiled vector
+// contracts active since the Metropolis fork: the default set extended by the
+// big integer modular exponentiation and the alt_bn128 elliptic curve
+// operations.
+func PrecompiledContractsMetropolis() map[string]*PrecompiledAccount {
+	contracts := PrecompiledContracts()
+
+	// MODEXP
+	contracts[string(common.LeftPadBytes([]byte{5}, 20))] = &PrecompiledAccount{modExpGas, modExpFunc}
+
+	// ECADD
+	contracts[string(common.LeftPadBytes([]byte{6}, 20))] = &PrecompiledAccount{func(in []byte) *big.Int {
+		return params.Bn256AddGas
+	}, bn256AddFunc}
+
+	// ECMUL
+	contracts[string(common.LeftPadBytes([]byte{7}, 20))] = &PrecompiledAccount{func(in []byte) *big.Int {
+		return params.Bn256ScalarMulGas
+	}, bn256ScalarMulFunc}
+
+	// ECPAIRING
+	contracts[string(common.LeftPadBytes([]byte{8}, 20))] = &PrecompiledAccount{func(in []byte) *big.Int {
+		n := big.NewInt(int64(len(in) / 192))
+		n.Mul(n, params.Bn256PairingPerPointGas)
+		return n.Add(n, params.Bn256PairingBaseGas)
+	}, bn256PairingFunc}
+
+	return contracts
+}
+
+func sha256Func(in []byte) ([]byte, error) {
+	return crypto.Sha256(in), nil
 }
 
-func ripemd160Func(in []byte) []byte {
-	return common.LeftPadBytes(crypto.Ripemd160(in), 32)
+func ripemd160Func(in []byte) ([]byte, error) {
+	return common.LeftPadBytes(crypto.Ripemd160(in), 32), nil
 }
 
 const ecRecoverInputLength = 128
 
-func ecrecoverFunc(in []byte) []byte {
+func ecrecoverFunc(in []byte) ([]byte, error) {
 	in = common.RightPadBytes(in, 128)
 	// "in" is (hash, v, r, s), each 32 bytes
 	// but for ecrecover we want (r, s, v)
@@ -96,7 +141,7 @@ func ecrecoverFunc(in []byte) []byte {
 	// tighter sig s values in homestead only apply to tx sigs
 	if !crypto.ValidateSignatureValues(v, r, s, false) {
 		glog.V(logger.Debug).Infof("EC RECOVER FAIL: v, r or s value invalid")
-		return nil
+		return nil, nil
 	}
 
 	// v needs to be at the end and normalized for libsecp256k1
@@ -107,13 +152,172 @@ func ecrecoverFunc(in []byte) []byte {
 	// make sure the public key is a valid one
 	if err != nil {
 		glog.V(logger.Error).Infof("EC RECOVER FAIL: ", err)
-		return nil
+		return nil, nil
 	}
 
 	// the first byte of pubkey is bitcoin heritage
-	return common.LeftPadBytes(crypto.Sha3(pubKey[1:])[12:], 32)
+	return common.LeftPadBytes(crypto.Sha3(pubKey[1:])[12:], 32), nil
+}
+
+func memCpy(in []byte) ([]byte, error) {
+	return in, nil
 }
 
-func memCpy(in []byte) []byte {
-	return in
+var (
+	big64     = big.NewInt(64)
+	big96     = big.NewInt(96)
+	big480    = big.NewInt(480)
+	big1024   = big.NewInt(1024)
+	big3072   = big.NewInt(3072)
+	big199680 = big.NewInt(199680)
+)
+
+// modExpGas calculates the gas of the modular exponentiation from the lengths
+// of its operands and the bit length of the exponent. The input is
+// (baseLen, expLen, modLen, base, exp, mod) with 32 byte lengths.
+func modExpGas(in []byte) *big.Int {
+	var (
+		baseLen = new(big.Int).SetBytes(getData(in, Zero, common.Big32))
+		expLen  = new(big.Int).SetBytes(getData(in, common.Big32, common.Big32))
+		modLen  = new(big.Int).SetBytes(getData(in, big64, common.Big32))
+	)
+	// Only the leading 32 bytes of the exponent contribute to its bit length
+	start := new(big.Int).Add(big96, baseLen)
+	expHead := new(big.Int).SetBytes(getData(in, start, common.BigMin(expLen, common.Big32)))
+
+	adjExpLen := new(big.Int)
+	if expLen.Cmp(common.Big32) > 0 {
+		adjExpLen.Sub(expLen, common.Big32)
+		adjExpLen.Lsh(adjExpLen, 3)
+	}
+	if bits := expHead.BitLen(); bits > 1 {
+		adjExpLen.Add(adjExpLen, big.NewInt(int64(bits-1)))
+	}
+	gas := modExpMultComplexity(common.BigMax(modLen, baseLen))
+	gas.Mul(gas, common.BigMax(adjExpLen, One))
+	return gas.Div(gas, params.ModExpQuadCoeffDiv)
+}
+
+// modExpMultComplexity approximates the complexity of multiplying two numbers
+// of the given byte length.
+func modExpMultComplexity(x *big.Int) *big.Int {
+	sq := new(big.Int).Mul(x, x)
+	switch {
+	case x.Cmp(big64) <= 0:
+		return sq
+	case x.Cmp(big1024) <= 0:
+		// x²/4 + 96x - 3072
+		sq.Rsh(sq, 2)
+		sq.Add(sq, new(big.Int).Mul(big96, x))
+		return sq.Sub(sq, big3072)
+	default:
+		// x²/16 + 480x - 199680
+		sq.Rsh(sq, 4)
+		sq.Add(sq, new(big.Int).Mul(big480, x))
+		return sq.Sub(sq, big199680)
+	}
+}
+
+// modExpFunc calculates base^exp % mod, returning the result padded to the
+// length of the modulus.
+func modExpFunc(in []byte) ([]byte, error) {
+	var (
+		baseLen = new(big.Int).SetBytes(getData(in, Zero, common.Big32)).Uint64()
+		expLen  = new(big.Int).SetBytes(getData(in, common.Big32, common.Big32)).Uint64()
+		modLen  = new(big.Int).SetBytes(getData(in, big64, common.Big32)).Uint64()
+	)
+	// The gas paid prevents huge lengths, except for an exponent of nothing
+	if baseLen == 0 && modLen == 0 {
+		return []byte{}, nil
+	}
+	if len(in) > 96 {
+		in = in[96:]
+	} else {
+		in = in[:0]
+	}
+	var (
+		base = new(big.Int).SetBytes(getData(in, Zero, new(big.Int).SetUint64(baseLen)))
+		exp  = new(big.Int).SetBytes(getData(in, new(big.Int).SetUint64(baseLen), new(big.Int).SetUint64(expLen)))
+		mod  = new(big.Int).SetBytes(getData(in, new(big.Int).SetUint64(baseLen+expLen), new(big.Int).SetUint64(modLen)))
+	)
+	if mod.Sign() == 0 {
+		return make([]byte, modLen), nil
+	}
+	return common.LeftPadBytes(base.Exp(base, exp, mod).Bytes(), int(modLen)), nil
+}
+
+// errBn256InvalidPoint is returned by the alt_bn128 contracts if their input
+// isn't a valid curve point, failing the call.
+var errBn256InvalidPoint = errors.New("bn256: invalid curve point")
+
+// newCurvePoint unmarshals a G1 point of alt_bn128.
+func newCurvePoint(blob []byte) (*bn256.G1, error) {
+	p, ok := new(bn256.G1).Unmarshal(blob)
+	if !ok {
+		return nil, errBn256InvalidPoint
+	}
+	return p, nil
+}
+
+// newTwistPoint unmarshals a G2 point of alt_bn128.
+func newTwistPoint(blob []byte) (*bn256.G2, error) {
+	p, ok := new(bn256.G2).Unmarshal(blob)
+	if !ok {
+		return nil, errBn256InvalidPoint
+	}
+	return p, nil
+}
+
+// bn256AddFunc adds two alt_bn128 G1 points.
+func bn256AddFunc(in []byte) ([]byte, error) {
+	in = common.RightPadBytes(in, 128)
+
+	x, err := newCurvePoint(in[:64])
+	if err != nil {
+		return nil, err
+	}
+	y, err := newCurvePoint(in[64:128])
+	if err != nil {
+		return nil, err
+	}
+	return new(bn256.G1).Add(x, y).Marshal(), nil
+}
+
+// bn256ScalarMulFunc multiplies an alt_bn128 G1 point by a scalar.
+func bn256ScalarMulFunc(in []byte) ([]byte, error) {
+	in = common.RightPadBytes(in, 96)
+
+	p, err := newCurvePoint(in[:64])
+	if err != nil {
+		return nil, err
+	}
+	return new(bn256.G1).ScalarMult(p, new(big.Int).SetBytes(in[64:96])).Marshal(), nil
+}
+
+// bn256PairingFunc checks whether the product of the pairings of a list of
+// (G1, G2) point pairs of alt_bn128 is one, returning 1 if so and 0 otherwise.
+func bn256PairingFunc(in []byte) ([]byte, error) {
+	if len(in)%192 != 0 {
+		return nil, errors.New("bn256: invalid pairing input length")
+	}
+	var (
+		cs []*bn256.G1
+		ts []*bn256.G2
+	)
+	for i := 0; i < len(in); i += 192 {
+		c, err := newCurvePoint(in[i : i+64])
+		if err != nil {
+			return nil, err
+		}
+		t, err := newTwistPoint(in[i+64 : i+192])
+		if err != nil {
+			return nil, err
+		}
+		cs = append(cs, c)
+		ts = append(ts, t)
+	}
+	if bn256.PairingCheck(cs, ts) {
+		return common.LeftPadBytes([]byte{1}, 32), nil
+	}
+	return make([]byte, 32), nil
 }
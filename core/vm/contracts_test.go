@@ -0,0 +1,180 @@
+// Copyright 2016 The go-vector Authors
+// This file is part of the go-vector library.
+//
+// The go-vector library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vector library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vector library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/vector/go-vector/common"
+)
+
+type forkRuleSet struct {
+	metropolis bool
+}
+
+func (r forkRuleSet) IsHomestead(n *big.Int) bool  { return true }
+func (r forkRuleSet) IsMetropolis(n *big.Int) bool { return r.metropolis }
+
+// precompiled returns the Metropolis precompiled contract at the given address.
+func precompiled(addr byte) *PrecompiledAccount {
+	return PrecompiledMetropolis[string(common.LeftPadBytes([]byte{addr}, 20))]
+}
+
+func TestActivePrecompiled(t *testing.T) {
+	modexp := string(common.LeftPadBytes([]byte{5}, 20))
+	if ActivePrecompiled(forkRuleSet{false}, common.Big0)[modexp] != nil {
+		t.Errorf("MODEXP active before Metropolis")
+	}
+	contracts := ActivePrecompiled(forkRuleSet{true}, common.Big0)
+	for addr := byte(1); addr <= 8; addr++ {
+		if contracts[string(common.LeftPadBytes([]byte{addr}, 20))] == nil {
+			t.Errorf("precompiled contract %d missing after Metropolis", addr)
+		}
+	}
+}
+
+var modExpTests = []struct {
+	input, output string
+	gas           int64
+}{
+	// 3^(p-1) % p for the secp256k1 field prime
+	{
+		"0000000000000000000000000000000000000000000000000000000000000001" +
+			"0000000000000000000000000000000000000000000000000000000000000020" +
+			"0000000000000000000000000000000000000000000000000000000000000020" +
+			"03" +
+			"fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2e" +
+			"fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f",
+		"0000000000000000000000000000000000000000000000000000000000000001",
+		13056,
+	},
+	// 0^(p-1) % p, with the empty base
+	{
+		"0000000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000020" +
+			"0000000000000000000000000000000000000000000000000000000000000020" +
+			"fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2e" +
+			"fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f",
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		13056,
+	},
+	// 2^3 % 5 with truncated input, the missing modulus byte is zero
+	{
+		"0000000000000000000000000000000000000000000000000000000000000001" +
+			"0000000000000000000000000000000000000000000000000000000000000001" +
+			"0000000000000000000000000000000000000000000000000000000000000002" +
+			"02" + "03" + "00",
+		"0000",
+		0,
+	},
+	// Huge exponent length without base and modulus
+	{
+		"0000000000000000000000000000000000000000000000000000000000000000" +
+			"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff" +
+			"0000000000000000000000000000000000000000000000000000000000000000",
+		"",
+		0,
+	},
+}
+
+func TestModExp(t *testing.T) {
+	contract := precompiled(5)
+	for i, tt := range modExpTests {
+		input := common.Hex2Bytes(tt.input)
+		if gas := contract.Gas(input); gas.Cmp(big.NewInt(tt.gas)) != 0 {
+			t.Errorf("test %d: gas mismatch: have %v, want %d", i, gas, tt.gas)
+		}
+		output, err := contract.Call(input)
+		if err != nil {
+			t.Errorf("test %d: call failed: %v", i, err)
+			continue
+		}
+		if !bytes.Equal(output, common.Hex2Bytes(tt.output)) {
+			t.Errorf("test %d: output mismatch: have %x, want %s", i, output, tt.output)
+		}
+	}
+}
+
+const (
+	// Generator of G1 and its double
+	bn256G1  = "0000000000000000000000000000000000000000000000000000000000000001" + "0000000000000000000000000000000000000000000000000000000000000002"
+	bn256G1x = "030644e72e131a029b85045b68181585d97816a916871ca8d3c208c16d87cfd3" + "15ed738c0e0a7c92e7845f96b2ae9c0a68a6a449e3538fc7ff3ebf7a5a18a2c4"
+
+	// Negated generator of G1
+	bn256NegG1 = "0000000000000000000000000000000000000000000000000000000000000001" + "30644e72e131a029b85045b68181585d97816a916871ca8d3c208c16d87cfd45"
+
+	// Generator of G2
+	bn256G2 = "198e9393920d483a7260bfb731fb5d25f1aa493335a9e71297e485b7aef312c2" +
+		"1800deef121f1e76426a00665e5c4479674322d4f75edadd46debd5cd992f6ed" +
+		"090689d0585ff075ec9e99ad690c3395bc4b313370b38ef355acdadcd122975b" +
+		"12c85ea5db8c6deb4aab71808dcb408fe3d1e7690c43d37b4ce6cc0166fa7daa"
+
+	bn256Zero = "0000000000000000000000000000000000000000000000000000000000000000" + "0000000000000000000000000000000000000000000000000000000000000000"
+	bn256True = "0000000000000000000000000000000000000000000000000000000000000001"
+)
+
+var bn256Tests = []struct {
+	contract      byte
+	input, output string
+	fail          bool
+}{
+	{contract: 6, input: bn256G1 + bn256G1, output: bn256G1x},
+	{contract: 6, input: bn256G1 + bn256Zero, output: bn256G1},
+	{contract: 6, input: bn256G1 + bn256NegG1, output: bn256Zero},
+	{contract: 6, input: "", output: bn256Zero},
+	{contract: 6, input: bn256G1[:126] + "03", fail: true},
+	{contract: 7, input: bn256G1 + "0000000000000000000000000000000000000000000000000000000000000002", output: bn256G1x},
+	{contract: 7, input: bn256G1 + "30644e72e131a029b85045b68181585d2833e84879b9709143e1f593f0000001", output: bn256Zero},
+	{contract: 7, input: bn256Zero + "0000000000000000000000000000000000000000000000000000000000000005", output: bn256Zero},
+	{contract: 8, input: "", output: bn256True},
+	{contract: 8, input: bn256G1 + bn256G2 + bn256NegG1 + bn256G2, output: bn256True},
+	{contract: 8, input: bn256G1 + bn256G2, output: "0000000000000000000000000000000000000000000000000000000000000000"},
+	{contract: 8, input: bn256Zero + bn256G2, output: bn256True},
+	{contract: 8, input: bn256G1 + bn256G2[:254], fail: true},
+	{contract: 8, input: bn256G1 + bn256G2[:254] + "00", fail: true},
+}
+
+func TestBn256(t *testing.T) {
+	for i, tt := range bn256Tests {
+		output, err := precompiled(tt.contract).Call(common.Hex2Bytes(tt.input))
+		if tt.fail {
+			if err == nil {
+				t.Errorf("test %d: invalid input accepted", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: call failed: %v", i, err)
+			continue
+		}
+		if !bytes.Equal(output, common.Hex2Bytes(tt.output)) {
+			t.Errorf("test %d: output mismatch: have %x, want %s", i, output, tt.output)
+		}
+	}
+}
+
+func TestBn256PairingGas(t *testing.T) {
+	contract := precompiled(8)
+	if gas := contract.Gas(nil); gas.Cmp(big.NewInt(100000)) != 0 {
+		t.Errorf("empty pairing gas mismatch: have %v, want 100000", gas)
+	}
+	if gas := contract.Gas(make([]byte, 2*192)); gas.Cmp(big.NewInt(260000)) != 0 {
+		t.Errorf("two point pairing gas mismatch: have %v, want 260000", gas)
+	}
+}
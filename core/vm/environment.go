@@ -22,12 +22,21 @@ import (
 	"github.com/vector/go-vector/common"
 )
 
+// RuleSet is an interface that defines the current rule set during the
+// execution of the EVM instructions (e.g. whether it's homestead)
+type RuleSet interface {
+	IsHomestead(*big.Int) bool
+	IsMetropolis(*big.Int) bool
+}
+
 // Environment is is required by the virtual machine to get information from
 // it's own isolated environment.
 
 // Environment is an EVM requirement and helper which allows access to outside
 // information such as states.
 type Environment interface {
+	// The current ruleset
+	RuleSet() RuleSet
 	// The state database
 	Db() Database
 	// Creates a restorable snapshot
@@ -54,10 +63,8 @@ type Environment interface {
 	Transfer(from, to Account, amount *big.Int)
 	// Adds a LOG to the state
 	AddLog(*Log)
-	// Adds a structured log to the env
-	AddStructLog(StructLog)
-	// Returns all coalesced structured logs
-	StructLogs() []StructLog
+	// The tracer capturing the execution (nil = no tracing)
+	Tracer() Tracer
 
 	// Type of the VM
 	VmType() Type
@@ -101,7 +108,7 @@ type Database interface {
 	IsDeleted(common.Address) bool
 }
 
-// StructLog is emited to the Environment each cycle and lists information about the curent internal state
+// StructLog is collected by the StructLogger each cycle and lists information about the curent internal state
 // prior to the execution of the statement.
 type StructLog struct {
 	Pc      uint64
@@ -110,7 +117,8 @@ type StructLog struct {
 	GasCost *big.Int
 	Memory  []byte
 	Stack   []*big.Int
-	Storage map[common.Hash][]byte
+	Storage map[common.Hash]common.Hash
+	Depth   int
 	Err     error
 }
 
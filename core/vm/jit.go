@@ -301,12 +301,14 @@ func CompileProgram(program *Program) (err error) {
 }
 
 // RunProgram runs the program given the enviroment and contract and returns an
-// error if the execution failed (non-consensus)
+// error if the execution failed (non-consensus). Instructions folded into
+// optimised segments (e.g. consecutive pushes) are not reported to the tracer
+// of the environment individually.
 func RunProgram(program *Program, env Environment, contract *Contract, input []byte) ([]byte, error) {
 	return runProgram(program, 0, NewMemory(), newstack(), env, contract, input)
 }
 
-func runProgram(program *Program, pcstart uint64, mem *Memory, stack *stack, env Environment, contract *Contract, input []byte) ([]byte, error) {
+func runProgram(program *Program, pcstart uint64, mem *Memory, stack *Stack, env Environment, contract *Contract, input []byte) ([]byte, error) {
 	contract.Input = input
 
 	var (
@@ -322,7 +324,7 @@ func runProgram(program *Program, pcstart uint64, mem *Memory, stack *stack, env
 		}()
 	}
 
-	homestead := params.IsHomestead(env.BlockNumber())
+	homestead := env.RuleSet().IsHomestead(env.BlockNumber())
 	for pc < uint64(len(program.instructions)) {
 		instrCount++
 
@@ -333,6 +335,11 @@ func runProgram(program *Program, pcstart uint64, mem *Memory, stack *stack, env
 
 		ret, err := instr.do(program, &pc, env, contract, mem, stack)
 		if err != nil {
+			if tracer := env.Tracer(); tracer != nil {
+				if instr, ok := instr.(instruction); ok {
+					tracer.CaptureState(env, instr.pc, instr.op, contract.Gas, new(big.Int), mem, stack, contract, env.Depth(), err)
+				}
+			}
 			return nil, err
 		}
 
@@ -360,7 +367,7 @@ func validDest(dests map[uint64]struct{}, dest *big.Int) bool {
 
 // jitCalculateGasAndSize calculates the required given the opcode and stack items calculates the new memorysize for
 // the operation. This does not reduce gas or resizes the memory.
-func jitCalculateGasAndSize(env Environment, contract *Contract, instr instruction, statedb Database, mem *Memory, stack *stack) (*big.Int, *big.Int, error) {
+func jitCalculateGasAndSize(env Environment, contract *Contract, instr instruction, statedb Database, mem *Memory, stack *Stack) (*big.Int, *big.Int, error) {
 	var (
 		gas                 = new(big.Int)
 		newMemSize *big.Int = new(big.Int)
@@ -494,7 +501,7 @@ func jitCalculateGasAndSize(env Environment, contract *Contract, instr instructi
 
 // jitBaseCheck is the same as baseCheck except it doesn't do the look up in the
 // gas table. This is done during compilation instead.
-func jitBaseCheck(instr instruction, stack *stack, gas *big.Int) error {
+func jitBaseCheck(instr instruction, stack *Stack, gas *big.Int) error {
 	err := stack.require(instr.spop)
 	if err != nil {
 		return err
@@ -162,6 +162,13 @@ func runVmBench(test vmBench, b *testing.B) {
 	}
 }
 
+type ruleSet struct {
+	hs *big.Int
+}
+
+func (r ruleSet) IsHomestead(n *big.Int) bool  { return n.Cmp(r.hs) >= 0 }
+func (r ruleSet) IsMetropolis(n *big.Int) bool { return false }
+
 type Env struct {
 	gasLimit *big.Int
 	depth    int
@@ -171,13 +178,11 @@ func NewEnv() *Env {
 	return &Env{big.NewInt(10000), 0}
 }
 
+func (self *Env) RuleSet() RuleSet       { return ruleSet{new(big.Int)} }
+
 func (self *Env) Origin() common.Address { return common.Address{} }
 func (self *Env) BlockNumber() *big.Int  { return big.NewInt(0) }
-func (self *Env) AddStructLog(log StructLog) {
-}
-func (self *Env) StructLogs() []StructLog {
-	return nil
-}
+func (self *Env) Tracer() Tracer         { return nil }
 
 //func (self *Env) PrevHash() []byte      { return self.parent }
 func (self *Env) Coinbase() common.Address { return common.Address{} }
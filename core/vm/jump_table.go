@@ -1,10 +1,6 @@
 package vm
 
-import (
-	"math/big"
-
-	"github.com/vector/go-vector/params"
-)
+import "math/big"
 
 type jumpPtr struct {
 	fn    instrFn
@@ -13,16 +9,17 @@ type jumpPtr struct {
 
 type vmJumpTable [256]jumpPtr
 
-func (jt vmJumpTable) init(blockNumber *big.Int) {
-	// when initialising a new VM execution we must first check the homestead
-	// changes.
-	if params.IsHomestead(blockNumber) {
-		jumpTable[DELEGATECALL] = jumpPtr{opDelegateCall, true}
-	} else {
-		jumpTable[DELEGATECALL] = jumpPtr{nil, false}
+// newJumpTable returns the jump table of a VM executing the given ruleset at
+// blockNumber, with all the operations introduced by forks up to it enabled.
+func newJumpTable(ruleset RuleSet, blockNumber *big.Int) vmJumpTable {
+	jt := jumpTable
+	if ruleset.IsHomestead(blockNumber) {
+		jt[DELEGATECALL] = jumpPtr{opDelegateCall, true}
 	}
+	return jt
 }
 
+// jumpTable contains the operations available since the genesis block.
 var jumpTable vmJumpTable
 
 func init() {
@@ -3,20 +3,18 @@ package vm
 import (
 	"math/big"
 	"testing"
-
-	"github.com/vector/go-vector/params"
 )
 
 func TestInit(t *testing.T) {
-	params.HomesteadBlock = big.NewInt(1)
+	ruleset := ruleSet{big.NewInt(1)}
 
-	jumpTable.init(big.NewInt(0))
+	jumpTable := newJumpTable(ruleset, big.NewInt(0))
 	if jumpTable[DELEGATECALL].valid {
 		t.Error("Expected DELEGATECALL not to be present")
 	}
 
 	for _, n := range []int64{1, 2, 100} {
-		jumpTable.init(big.NewInt(n))
+		jumpTable := newJumpTable(ruleset, big.NewInt(n))
 		if !jumpTable[DELEGATECALL].valid {
 			t.Error("Expected DELEGATECALL to be present for block", n)
 		}
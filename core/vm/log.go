@@ -36,6 +36,10 @@ type Log struct {
 	TxIndex     uint
 	BlockHash   common.Hash
 	Index       uint
+
+	// Removed is set when the log was reverted due to a chain reorganisation.
+	// It is not stored, you must check it when receiving logs through a filter.
+	Removed bool
 }
 
 func NewLog(address common.Address, topics []common.Hash, data []byte, number uint64) *Log {
@@ -69,3 +73,49 @@ type Logs []*Log
 // content of a log, as opposed to only the consensus fields originally (by hiding
 // the rlp interface methods).
 type LogForStorage Log
+
+// storedLog is the storage layout of a log, excluding the fields that only make
+// sense while delivering logs to filters.
+type storedLog struct {
+	Address     common.Address
+	Topics      []common.Hash
+	Data        []byte
+	BlockNumber uint64
+	TxHash      common.Hash
+	TxIndex     uint
+	BlockHash   common.Hash
+	Index       uint
+}
+
+// EncodeRLP implements rlp.Encoder.
+func (l *LogForStorage) EncodeRLP(w io.Writer) error {
+	return rlp.Encode(w, &storedLog{
+		Address:     l.Address,
+		Topics:      l.Topics,
+		Data:        l.Data,
+		BlockNumber: l.BlockNumber,
+		TxHash:      l.TxHash,
+		TxIndex:     l.TxIndex,
+		BlockHash:   l.BlockHash,
+		Index:       l.Index,
+	})
+}
+
+// DecodeRLP implements rlp.Decoder.
+func (l *LogForStorage) DecodeRLP(s *rlp.Stream) error {
+	var log storedLog
+	if err := s.Decode(&log); err != nil {
+		return err
+	}
+	*l = LogForStorage{
+		Address:     log.Address,
+		Topics:      log.Topics,
+		Data:        log.Data,
+		BlockNumber: log.BlockNumber,
+		TxHash:      log.TxHash,
+		TxIndex:     log.TxIndex,
+		BlockHash:   log.BlockHash,
+		Index:       log.Index,
+	}
+	return nil
+}
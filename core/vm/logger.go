@@ -18,12 +18,149 @@ package vm
 
 import (
 	"fmt"
+	"math/big"
 	"os"
+	"time"
 	"unicode"
 
 	"github.com/vector/go-vector/common"
 )
 
+// Tracer is used to collect execution traces from the EVM. The callbacks are
+// invoked synchronously from the executing goroutine, so implementations must
+// copy any VM data structure (memory, stack, big ints) they want to retain.
+type Tracer interface {
+	// CaptureStart is called once before the outermost message call or contract
+	// creation of the execution.
+	CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas, value *big.Int)
+	// CaptureState is called before each opcode is executed, with the gas left
+	// and the cost already deducted for the opcode, and once more with the error
+	// if the execution of the opcode failed. The cost is zero (never nil) if the
+	// opcode failed before its cost was known, e.g. on a stack underflow. The
+	// storage of the contract can be accessed through env.Db().
+	CaptureState(env Environment, pc uint64, op OpCode, gas, cost *big.Int, memory *Memory, stack *Stack, contract *Contract, depth int, err error)
+	// CaptureEnter is called before a nested CALL, CALLCODE, DELEGATECALL or
+	// CREATE is executed, even if it is rejected before any code is run.
+	CaptureEnter(typ OpCode, from common.Address, to common.Address, input []byte, gas, value *big.Int)
+	// CaptureExit is called after a nested call or creation returned.
+	CaptureExit(output []byte, gasUsed *big.Int, err error)
+	// CaptureEnd is called once after the outermost call or creation returned.
+	CaptureEnd(output []byte, gasUsed *big.Int, t time.Duration, err error)
+}
+
+// LogConfig are the configuration options of the structured logger.
+type LogConfig struct {
+	DisableMemory  bool // Disables the capture of the memory
+	DisableStack   bool // Disables the capture of the stack
+	DisableStorage bool // Disables the capture of the accessed storage slots
+}
+
+// StructLogger is a Tracer collecting a StructLog for every executed opcode,
+// tracking the storage slots read and written by each contract along the way.
+type StructLogger struct {
+	config  LogConfig
+	logs    []StructLog
+	storage map[common.Address]map[common.Hash]common.Hash
+
+	output []byte // Return value of the outermost call
+	err    error  // Error of the outermost call
+}
+
+// NewStructLogger returns a new structured logger capturing everything unless
+// disabled in the given config (nil = capture everything).
+func NewStructLogger(config *LogConfig) *StructLogger {
+	logger := &StructLogger{
+		storage: make(map[common.Address]map[common.Hash]common.Hash),
+	}
+	if config != nil {
+		logger.config = *config
+	}
+	return logger
+}
+
+// CaptureStart implements Tracer, it's a noop for the structured logger.
+func (l *StructLogger) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas, value *big.Int) {
+}
+
+// CaptureState implements Tracer, appending a snapshot of the VM state to the
+// collected logs.
+func (l *StructLogger) CaptureState(env Environment, pc uint64, op OpCode, gas, cost *big.Int, memory *Memory, stack *Stack, contract *Contract, depth int, err error) {
+	var mem []byte
+	if !l.config.DisableMemory {
+		mem = make([]byte, len(memory.Data()))
+		copy(mem, memory.Data())
+	}
+	var stck []*big.Int
+	if !l.config.DisableStack {
+		stck = make([]*big.Int, len(stack.Data()))
+		for i, item := range stack.Data() {
+			stck[i] = new(big.Int).Set(item)
+		}
+	}
+	// Track the storage slots accessed by the contract
+	var storage map[common.Hash]common.Hash
+	if !l.config.DisableStorage {
+		address := contract.Address()
+		if l.storage[address] == nil {
+			l.storage[address] = make(map[common.Hash]common.Hash)
+		}
+		if err == nil {
+			switch {
+			case op == SLOAD && stack.len() >= 1:
+				key := common.BigToHash(stack.data[stack.len()-1])
+				l.storage[address][key] = env.Db().GetState(address, key)
+
+			case op == SSTORE && stack.len() >= 2:
+				key := common.BigToHash(stack.data[stack.len()-1])
+				l.storage[address][key] = common.BigToHash(stack.data[stack.len()-2])
+			}
+		}
+		storage = make(map[common.Hash]common.Hash, len(l.storage[address]))
+		for key, value := range l.storage[address] {
+			storage[key] = value
+		}
+	}
+	l.logs = append(l.logs, StructLog{
+		Pc:      pc,
+		Op:      op,
+		Gas:     new(big.Int).Set(gas),
+		GasCost: cost,
+		Memory:  mem,
+		Stack:   stck,
+		Storage: storage,
+		Depth:   depth,
+		Err:     err,
+	})
+}
+
+// CaptureEnter implements Tracer, it's a noop for the structured logger.
+func (l *StructLogger) CaptureEnter(typ OpCode, from common.Address, to common.Address, input []byte, gas, value *big.Int) {
+}
+
+// CaptureExit implements Tracer, it's a noop for the structured logger.
+func (l *StructLogger) CaptureExit(output []byte, gasUsed *big.Int, err error) {}
+
+// CaptureEnd implements Tracer, recording the outcome of the execution.
+func (l *StructLogger) CaptureEnd(output []byte, gasUsed *big.Int, t time.Duration, err error) {
+	l.output = common.CopyBytes(output)
+	l.err = err
+}
+
+// StructLogs returns the logs collected so far.
+func (l *StructLogger) StructLogs() []StructLog {
+	return l.logs
+}
+
+// Output returns the return value of the traced execution.
+func (l *StructLogger) Output() []byte {
+	return l.output
+}
+
+// Error returns the error of the traced execution, if it failed.
+func (l *StructLogger) Error() error {
+	return l.err
+}
+
 // StdErrFormat formats a slice of StructLogs to human readable format
 func StdErrFormat(logs []StructLog) {
 	fmt.Fprintf(os.Stderr, "VM STAT %d OPs\n", len(logs))
@@ -61,7 +198,7 @@ func StdErrFormat(logs []StructLog) {
 
 		fmt.Fprintln(os.Stderr, "STORAGE =", len(log.Storage))
 		for h, item := range log.Storage {
-			fmt.Fprintf(os.Stderr, "%x: %x\n", h, common.LeftPadBytes(item, 32))
+			fmt.Fprintf(os.Stderr, "%x: %x\n", h, item)
 		}
 		fmt.Fprintln(os.Stderr)
 	}
@@ -0,0 +1,324 @@
+// Copyright 2016 The go-vector Authors
+// This file is part of the go-vector library.
+//
+// The go-vector library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vector library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vector library. If not, see <http://www.gnu.org/licenses/>.
+
+package runtime
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"strings"
+
+	"github.com/vector/go-vector/common"
+	"github.com/vector/go-vector/core/state"
+	"github.com/vector/go-vector/core/vm"
+	"github.com/vector/go-vector/rlp"
+)
+
+// DiffGasLimit is the gas given to the code by Diff if the configuration
+// doesn't specify any, keeping random programs from looping forever.
+var DiffGasLimit = big.NewInt(1000000)
+
+// compile compiles the code for the JIT, replaceable by tests.
+var compile = func(code []byte) error {
+	return vm.CompileProgram(vm.NewProgram(code))
+}
+
+// Outcome is the observable result of executing code on one of the engines.
+type Outcome struct {
+	Ret     []byte
+	GasLeft *big.Int
+	Logs    vm.Logs
+	Err     error
+	Root    common.Hash
+	State   *state.StateDB // nil if the engine panicked
+}
+
+// Divergence describes code on which the interpreter and the JIT disagree.
+type Divergence struct {
+	Code, Input []byte
+	Field       string // first mismatching field: compile, error, return data, gas, logs or state
+
+	Interpreter, Jit *Outcome
+}
+
+// String returns a human readable report of the divergence.
+func (d *Divergence) String() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s mismatch\ncode:  %x\ninput: %x\n", d.Field, d.Code, d.Input)
+	for _, run := range []struct {
+		name string
+		out  *Outcome
+	}{{"interpreter", d.Interpreter}, {"jit", d.Jit}} {
+		fmt.Fprintf(&buf, "%s:\n  error: %v\n  return: %x\n  gas left: %v\n  logs: %d\n  root: %x\n",
+			run.name, run.out.Err, run.out.Ret, run.out.GasLeft, len(run.out.Logs), run.out.Root)
+	}
+	return buf.String()
+}
+
+// Diff executes the code with the given input on both the interpreter and the
+// JIT and compares the return data, the gas left, the logs, the failure and the
+// post state. It returns nil if the engines agree. Error messages aren't
+// compared as the engines word them differently; the configured tracer is not
+// used. As the JIT falls back to the interpreter on code it fails to compile,
+// a compilation failure of the code is reported as a divergence too.
+func Diff(code, input []byte, cfg *Config) *Divergence {
+	if cfg == nil {
+		cfg = new(Config)
+	}
+	c := *cfg
+	if c.GasLimit == nil {
+		c.GasLimit = DiffGasLimit
+	}
+	c.Debug, c.Tracer = false, nil
+	setDefaults(&c)
+
+	d := &Divergence{
+		Code:        code,
+		Input:       input,
+		Interpreter: execute(code, input, c, false),
+	}
+	if err := compile(code); err != nil {
+		d.Jit = &Outcome{GasLeft: new(big.Int).Set(c.GasLimit), Err: fmt.Errorf("compilation failed: %v", err)}
+		d.Field = "compile"
+		return d
+	}
+	d.Jit = execute(code, input, c, true)
+	if d.Field = compare(d.Interpreter, d.Jit); d.Field == "" {
+		return nil
+	}
+	return d
+}
+
+// execute runs the code on the selected engine, turning panics into errors so
+// that a crashing engine shows up as a divergence.
+func execute(code, input []byte, cfg Config, jit bool) (out *Outcome) {
+	cfg.DisableJit = !jit
+	cfg.GasLimit = new(big.Int).Set(cfg.GasLimit)
+
+	out = &Outcome{GasLeft: cfg.GasLimit}
+	defer func() {
+		if r := recover(); r != nil {
+			out.Err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	ret, statedb, err := Execute(code, input, &cfg)
+
+	out.Ret, out.Err, out.State = ret, err, statedb
+	out.Logs = statedb.Logs()
+	out.Root = statedb.IntermediateRoot()
+	return out
+}
+
+// compare returns the first field in which the outcomes differ, or an empty
+// string if they are equal.
+func compare(a, b *Outcome) string {
+	switch {
+	case (a.Err == nil) != (b.Err == nil) || (a.State == nil) != (b.State == nil):
+		return "error"
+	case !bytes.Equal(a.Ret, b.Ret):
+		return "return data"
+	case a.GasLeft.Cmp(b.GasLeft) != 0:
+		return "gas"
+	}
+	alogs, _ := rlp.EncodeToBytes(a.Logs)
+	blogs, _ := rlp.EncodeToBytes(b.Logs)
+	if !bytes.Equal(alogs, blogs) {
+		return "logs"
+	}
+	if a.Root != b.Root {
+		return "state"
+	}
+	return ""
+}
+
+// Minimise shrinks the code and the input of the divergence as long as the
+// engines keep disagreeing on the same field. Whole instructions are removed
+// from the code so that push data stays attached to its opcode, and static
+// jumps are relocated to follow their destination.
+func Minimise(d *Divergence, cfg *Config) *Divergence {
+	diverges := func(code, input []byte) bool {
+		if n := Diff(code, input, cfg); n != nil && n.Field == d.Field {
+			d = n
+			return true
+		}
+		return false
+	}
+	ops := instructions(d.Code)
+	kept := shrink(len(ops), func(kept []int) bool {
+		return diverges(assemble(ops, kept), d.Input)
+	})
+	code := assemble(ops, kept)
+
+	input := d.Input
+	shrink(len(input), func(kept []int) bool {
+		data := make([]byte, len(kept))
+		for i, j := range kept {
+			data[i] = input[j]
+		}
+		return diverges(code, data)
+	})
+	return d
+}
+
+// shrink removes chunks from the n units as long as keep holds for the indices
+// of the remaining ones, halving the chunk size down to single units. It
+// returns the indices of the units kept.
+func shrink(n int, keep func(kept []int) bool) []int {
+	units := make([]int, n)
+	for i := range units {
+		units[i] = i
+	}
+	for size := n; size > 0; size /= 2 {
+		for i := 0; i < len(units); {
+			end := i + size
+			if end > len(units) {
+				end = len(units)
+			}
+			candidate := append(append([]int{}, units[:i]...), units[end:]...)
+			if keep(candidate) {
+				units = candidate
+			} else {
+				i += size
+			}
+		}
+	}
+	return units
+}
+
+// instructions splits the code into instructions, each with its push data.
+func instructions(code []byte) [][]byte {
+	var ops [][]byte
+	for pc := 0; pc < len(code); {
+		end := pc + 1
+		if op := vm.OpCode(code[pc]); op.IsPush() {
+			end += int(op - vm.PUSH1 + 1)
+		}
+		if end > len(code) {
+			end = len(code)
+		}
+		ops = append(ops, code[pc:end])
+		pc = end
+	}
+	return ops
+}
+
+// assemble joins the kept instructions into code. Pushes directly followed by
+// a jump and pointing to a kept instruction are rewritten to its new position.
+func assemble(ops [][]byte, kept []int) []byte {
+	var (
+		oldpc = make([]int, len(ops))
+		newpc = make(map[int]int)
+		pc    int
+	)
+	for i := 1; i < len(ops); i++ {
+		oldpc[i] = oldpc[i-1] + len(ops[i-1])
+	}
+	for _, i := range kept {
+		newpc[oldpc[i]] = pc
+		pc += len(ops[i])
+	}
+	code := make([]byte, 0, pc)
+	for k, i := range kept {
+		op := append([]byte{}, ops[i]...)
+		if k+1 < len(kept) && len(op) > 1 && vm.OpCode(op[0]).IsPush() {
+			if next := vm.OpCode(ops[kept[k+1]][0]); next == vm.JUMP || next == vm.JUMPI {
+				if dest := new(big.Int).SetBytes(op[1:]); dest.BitLen() < 32 {
+					if pc, ok := newpc[int(dest.Int64())]; ok {
+						copy(op[1:], common.LeftPadBytes(big.NewInt(int64(pc)).Bytes(), len(op)-1))
+					}
+				}
+			}
+		}
+		code = append(code, op...)
+	}
+	return code
+}
+
+var (
+	// bodyOps are the opcodes random programs are built from, i.e. the
+	// defined ones except the pseudo opcodes and those ending the execution.
+	bodyOps []vm.OpCode
+
+	// exitOps are the opcodes ending random programs.
+	exitOps = []vm.OpCode{vm.STOP, vm.RETURN, vm.SUICIDE}
+)
+
+func init() {
+	for op := vm.OpCode(0); op < vm.PUSH; op++ {
+		if strings.HasPrefix(op.String(), "Missing opcode") {
+			continue
+		}
+		if op != vm.STOP {
+			bodyOps = append(bodyOps, op)
+		}
+	}
+	for op := vm.CREATE; op <= vm.DELEGATECALL; op++ {
+		if op != vm.RETURN {
+			bodyOps = append(bodyOps, op)
+		}
+	}
+}
+
+// RandomCode generates a program of the given number of instructions made of
+// defined opcodes and ending with a STOP, RETURN or SUICIDE. The stack is
+// seeded with pushes up front and before each instruction so that few programs
+// fail on a stack underflow. Half of the pushes are small values, making in
+// range memory offsets likely, and half of the jumps target a JUMPDEST.
+func RandomCode(r *rand.Rand, n int) []byte {
+	var code []byte
+	push := func(op vm.OpCode) {
+		data := make([]byte, op-vm.PUSH1+1)
+		if r.Intn(2) == 0 {
+			r.Read(data)
+		} else {
+			data[len(data)-1] = byte(r.Intn(4 * n))
+		}
+		code = append(append(code, byte(op)), data...)
+	}
+	for i := 0; i < 17; i++ {
+		push(vm.PUSH1)
+	}
+	var dests, jumps []int
+	for i := 0; i < n; i++ {
+		for j := r.Intn(4); j > 0; j-- {
+			push(vm.PUSH1)
+		}
+		switch op := bodyOps[r.Intn(len(bodyOps))]; {
+		case op.IsPush():
+			push(op)
+		case (op == vm.JUMP || op == vm.JUMPI) && r.Intn(2) == 0:
+			jumps = append(jumps, len(code)+1)
+			code = append(code, byte(vm.PUSH2), 0, 0, byte(op))
+		default:
+			if op == vm.JUMPDEST {
+				dests = append(dests, len(code))
+			}
+			code = append(code, byte(op))
+		}
+	}
+	code = append(code, byte(exitOps[r.Intn(len(exitOps))]))
+
+	// Point the reserved jumps to the generated destinations
+	for _, pos := range jumps {
+		if len(dests) > 0 {
+			dest := dests[r.Intn(len(dests))]
+			code[pos], code[pos+1] = byte(dest>>8), byte(dest)
+		}
+	}
+	return code
+}
@@ -0,0 +1,119 @@
+// Copyright 2016 The go-vector Authors
+// This file is part of the go-vector library.
+//
+// The go-vector library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vector library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vector library. If not, see <http://www.gnu.org/licenses/>.
+
+package runtime
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/vector/go-vector/core/vm"
+)
+
+// diffCorpus are programs exercising storage, logs, memory, jumps and calls.
+var diffCorpus = [][]byte{
+	// Store the call data size, log the first word of call data and return it
+	{
+		byte(vm.CALLDATASIZE), byte(vm.PUSH1), 0, byte(vm.SSTORE),
+		byte(vm.PUSH1), 0, byte(vm.CALLDATALOAD), byte(vm.PUSH1), 0, byte(vm.MSTORE),
+		byte(vm.PUSH1), 7, byte(vm.PUSH1), 32, byte(vm.PUSH1), 0, byte(vm.LOG1),
+		byte(vm.PUSH1), 32, byte(vm.PUSH1), 0, byte(vm.RETURN),
+	},
+	// Count down from 10 in a loop
+	{
+		byte(vm.PUSH1), 10, byte(vm.JUMPDEST), byte(vm.PUSH1), 1, byte(vm.SWAP1), byte(vm.SUB),
+		byte(vm.DUP1), byte(vm.PUSH1), 2, byte(vm.JUMPI), byte(vm.STOP),
+	},
+	// Jump to an invalid destination
+	{byte(vm.PUSH1), 3, byte(vm.JUMP), byte(vm.STOP)},
+	// Call the identity precompile and return its output
+	{
+		byte(vm.PUSH1), 0xaa, byte(vm.PUSH1), 0, byte(vm.MSTORE8),
+		byte(vm.PUSH1), 1, byte(vm.PUSH1), 32, byte(vm.PUSH1), 1, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0,
+		byte(vm.PUSH1), 4, byte(vm.GAS), byte(vm.CALL), byte(vm.POP),
+		byte(vm.PUSH1), 1, byte(vm.PUSH1), 32, byte(vm.RETURN),
+	},
+}
+
+// Tests that the interpreter and the JIT agree on the corpus and on random
+// programs.
+func TestDiff(t *testing.T) {
+	for i, code := range diffCorpus {
+		if d := Diff(code, []byte{1, 2, 3}, nil); d != nil {
+			t.Errorf("corpus %d: engines diverge: %v", i, Minimise(d, nil))
+		}
+	}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		code := RandomCode(r, 1+r.Intn(64))
+		if d := Diff(code, nil, nil); d != nil {
+			t.Errorf("random program %d: engines diverge: %v", i, Minimise(d, nil))
+		}
+	}
+}
+
+// Tests that code the JIT fails to compile is reported as a divergence instead of
+// being compared against the interpreter fallback.
+func TestDiffCompileFailure(t *testing.T) {
+	defer func(orig func([]byte) error) { compile = orig }(compile)
+	compile = func(code []byte) error {
+		if bytes.Contains(code, []byte{byte(vm.MSIZE)}) {
+			return errors.New("unsupported")
+		}
+		return nil
+	}
+	code := []byte{byte(vm.PUSH1), 1, byte(vm.MSIZE), byte(vm.ADD), byte(vm.STOP)}
+
+	d := Diff(code, nil, nil)
+	if d == nil {
+		t.Fatalf("compilation failure not reported")
+	}
+	if d.Field != "compile" || d.Jit.Err == nil {
+		t.Fatalf("divergence mismatch: have %q (jit error %v), want compile", d.Field, d.Jit.Err)
+	}
+	if min := Minimise(d, nil); !bytes.Equal(min.Code, []byte{byte(vm.MSIZE)}) {
+		t.Errorf("minimised code mismatch: have %x, want %x", min.Code, []byte{byte(vm.MSIZE)})
+	}
+}
+
+// Tests that shrinking only keeps the instructions needed for the property to
+// hold and that static jumps follow their destination.
+func TestShrink(t *testing.T) {
+	code := []byte{
+		byte(vm.PUSH1), 1, byte(vm.ADDRESS), byte(vm.PUSH2), byte(vm.MSIZE), byte(vm.POP),
+		byte(vm.PUSH1), 12, byte(vm.JUMP), byte(vm.MSIZE), byte(vm.POP), byte(vm.STOP), byte(vm.JUMPDEST),
+	}
+	ops := instructions(code)
+	if len(ops) != 9 {
+		t.Fatalf("instruction count mismatch: have %d, want 9", len(ops))
+	}
+	// Keep programs successfully jumping, which needs the destination relocated
+	kept := shrink(len(ops), func(kept []int) bool {
+		code := assemble(ops, kept)
+		if !bytes.Contains(code, []byte{byte(vm.JUMP)}) {
+			return false
+		}
+		_, _, err := Execute(code, nil, &Config{GasLimit: big.NewInt(100000), DisableJit: true})
+		return err == nil
+	})
+	want := []byte{byte(vm.PUSH1), 3, byte(vm.JUMP), byte(vm.JUMPDEST)}
+	if min := assemble(ops, kept); !bytes.Equal(min, want) {
+		t.Errorf("shrunk code mismatch: have %x, want %x", min, want)
+	}
+}
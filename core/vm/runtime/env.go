@@ -27,8 +27,9 @@ import (
 
 // Env is a basic runtime environment required for running the EVM.
 type Env struct {
-	depth int
-	state *state.StateDB
+	ruleSet vm.RuleSet
+	depth   int
+	state   *state.StateDB
 
 	origin   common.Address
 	coinbase common.Address
@@ -38,7 +39,7 @@ type Env struct {
 	difficulty *big.Int
 	gasLimit   *big.Int
 
-	logs []vm.StructLog
+	tracer vm.Tracer
 
 	getHashFn func(uint64) common.Hash
 }
@@ -46,24 +47,20 @@ type Env struct {
 // NewEnv returns a new vm.Environment
 func NewEnv(cfg *Config, state *state.StateDB) vm.Environment {
 	return &Env{
+		ruleSet:    cfg.RuleSet,
 		state:      state,
 		origin:     cfg.Origin,
 		coinbase:   cfg.Coinbase,
 		number:     cfg.BlockNumber,
 		time:       cfg.Time,
 		difficulty: cfg.Difficulty,
-		gasLimit:   cfg.GasLimit,
+		gasLimit:   new(big.Int).Set(cfg.GasLimit),
+		tracer:     cfg.Tracer,
+		getHashFn:  cfg.GetHashFn,
 	}
 }
 
-func (self *Env) StructLogs() []vm.StructLog {
-	return self.logs
-}
-
-func (self *Env) AddStructLog(log vm.StructLog) {
-	self.logs = append(self.logs, log)
-}
-
+func (self *Env) RuleSet() vm.RuleSet      { return self.ruleSet }
 func (self *Env) Origin() common.Address   { return self.origin }
 func (self *Env) BlockNumber() *big.Int    { return self.number }
 func (self *Env) Coinbase() common.Address { return self.coinbase }
@@ -72,6 +69,7 @@ func (self *Env) Difficulty() *big.Int     { return self.difficulty }
 func (self *Env) Db() vm.Database          { return self.state }
 func (self *Env) GasLimit() *big.Int       { return self.gasLimit }
 func (self *Env) VmType() vm.Type          { return vm.StdVmTy }
+func (self *Env) Tracer() vm.Tracer        { return self.tracer }
 func (self *Env) GetHash(n uint64) common.Hash {
 	return self.getHashFn(n)
 }
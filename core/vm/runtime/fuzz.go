@@ -0,0 +1,39 @@
+// Copyright 2016 The go-vector Authors
+// This file is part of the go-vector library.
+//
+// The go-vector library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vector library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vector library. If not, see <http://www.gnu.org/licenses/>.
+
+// +build gofuzz
+
+package runtime
+
+import "math/big"
+
+// Fuzz is the go-fuzz entry point running the data as code on both the
+// interpreter and the JIT, crashing with the minimised report if they diverge.
+// Programs executing successfully are given priority in the corpus.
+//
+//	go-fuzz-build github.com/vector/go-vector/core/vm/runtime
+//	go-fuzz -bin=runtime-fuzz.zip -workdir=fuzz
+func Fuzz(data []byte) int {
+	d := Diff(data, nil, nil)
+	if d != nil {
+		panic(Minimise(d, nil).String())
+	}
+	// Diff agreed, checking one engine is enough
+	if _, _, err := Execute(data, nil, &Config{GasLimit: new(big.Int).Set(DiffGasLimit), DisableJit: true}); err != nil {
+		return 0
+	}
+	return 1
+}
@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"github.com/vector/go-vector/common"
+	"github.com/vector/go-vector/core"
 	"github.com/vector/go-vector/core/state"
 	"github.com/vector/go-vector/core/vm"
 	"github.com/vector/go-vector/crypto"
@@ -30,6 +31,7 @@ import (
 // Config is a basic type specifing certain configuration flags for running
 // the EVM.
 type Config struct {
+	RuleSet     vm.RuleSet
 	Difficulty  *big.Int
 	Origin      common.Address
 	Coinbase    common.Address
@@ -39,13 +41,17 @@ type Config struct {
 	GasPrice    *big.Int
 	Value       *big.Int
 	DisableJit  bool // "disable" so it's enabled by default
-	Debug       bool
+	Debug       bool // dumps a structured log of the execution to stderr
+	Tracer      vm.Tracer
 
 	GetHashFn func(n uint64) common.Hash
 }
 
 // sets defaults on the config
 func setDefaults(cfg *Config) {
+	if cfg.RuleSet == nil {
+		cfg.RuleSet = core.MainNetChainConfig
+	}
 	if cfg.Difficulty == nil {
 		cfg.Difficulty = new(big.Int)
 	}
@@ -64,6 +70,9 @@ func setDefaults(cfg *Config) {
 	if cfg.BlockNumber == nil {
 		cfg.BlockNumber = new(big.Int)
 	}
+	if cfg.Debug && cfg.Tracer == nil {
+		cfg.Tracer = vm.NewStructLogger(nil)
+	}
 	if cfg.GetHashFn == nil {
 		cfg.GetHashFn = func(n uint64) common.Hash {
 			return common.BytesToHash(crypto.Sha3([]byte(new(big.Int).SetUint64(n).String())))
@@ -114,8 +123,8 @@ func Execute(code, input []byte, cfg *Config) ([]byte, *state.StateDB, error) {
 		cfg.Value,
 	)
 
-	if cfg.Debug {
-		vm.StdErrFormat(vmenv.StructLogs())
+	if logger, ok := cfg.Tracer.(*vm.StructLogger); ok && cfg.Debug {
+		vm.StdErrFormat(logger.StructLogs())
 	}
 	return ret, statedb, err
 }
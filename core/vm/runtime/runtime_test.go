@@ -17,11 +17,14 @@
 package runtime
 
 import (
+	"math/big"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/vector/go-vector/accounts/abi"
 	"github.com/vector/go-vector/common"
+	"github.com/vector/go-vector/core"
 	"github.com/vector/go-vector/core/vm"
 )
 
@@ -86,6 +89,165 @@ func TestRestoreDefaults(t *testing.T) {
 	}
 }
 
+// countingTracer is a vm.Tracer counting the callbacks received.
+type countingTracer struct {
+	starts, states, enters, exits, ends int
+	enterOps                            []vm.OpCode
+}
+
+func (t *countingTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas, value *big.Int) {
+	t.starts++
+}
+func (t *countingTracer) CaptureState(env vm.Environment, pc uint64, op vm.OpCode, gas, cost *big.Int, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) {
+	t.states++
+}
+func (t *countingTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas, value *big.Int) {
+	t.enters++
+	t.enterOps = append(t.enterOps, typ)
+}
+func (t *countingTracer) CaptureExit(output []byte, gasUsed *big.Int, err error) { t.exits++ }
+func (t *countingTracer) CaptureEnd(output []byte, gasUsed *big.Int, d time.Duration, err error) {
+	t.ends++
+}
+
+// Tests that the tracer of the environment is notified of the outermost call,
+// the nested ones and each executed opcode, both in the interpreter and the JIT.
+func TestTracer(t *testing.T) {
+	// Call the identity precompile and stop
+	code := []byte{
+		byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0,
+		byte(vm.PUSH1), 4, byte(vm.PUSH2), 0xff, 0xff, byte(vm.CALL), byte(vm.STOP),
+	}
+	for _, disableJit := range []bool{true, false} {
+		tracer := new(countingTracer)
+		if _, _, err := Execute(code, nil, &Config{DisableJit: disableJit, Tracer: tracer}); err != nil {
+			t.Fatalf("jit disabled %v: execution failed: %v", disableJit, err)
+		}
+		if tracer.starts != 1 || tracer.ends != 1 {
+			t.Errorf("jit disabled %v: start/end mismatch: have %d/%d, want 1/1", disableJit, tracer.starts, tracer.ends)
+		}
+		if tracer.enters != 1 || tracer.exits != 1 || tracer.enterOps[0] != vm.CALL {
+			t.Errorf("jit disabled %v: enter/exit mismatch: have %d/%d %v, want 1/1 [CALL]", disableJit, tracer.enters, tracer.exits, tracer.enterOps)
+		}
+		if tracer.states == 0 {
+			t.Errorf("jit disabled %v: no states captured", disableJit)
+		}
+		if disableJit && tracer.states != 9 {
+			t.Errorf("interpreter state count mismatch: have %d, want %d", tracer.states, 9)
+		}
+	}
+}
+
+// costTracer records the costs reported for failing opcodes.
+type costTracer struct {
+	countingTracer
+	faults []*big.Int
+}
+
+func (t *costTracer) CaptureState(env vm.Environment, pc uint64, op vm.OpCode, gas, cost *big.Int, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) {
+	if err != nil {
+		t.faults = append(t.faults, cost)
+	}
+}
+
+// Tests that both engines report a zero cost, not nil, for opcodes failing
+// before their cost is known.
+func TestTracerFaultCost(t *testing.T) {
+	for _, disableJit := range []bool{true, false} {
+		tracer := new(costTracer)
+		if _, _, err := Execute([]byte{byte(vm.ADD)}, nil, &Config{DisableJit: disableJit, Tracer: tracer}); err == nil {
+			t.Fatalf("jit disabled %v: stack underflow not reported", disableJit)
+		}
+		if len(tracer.faults) != 1 || tracer.faults[0] == nil || tracer.faults[0].Sign() != 0 {
+			t.Errorf("jit disabled %v: fault cost mismatch: have %v, want [0]", disableJit, tracer.faults)
+		}
+	}
+}
+
+// Tests that the struct logger tracks the storage slots written by a contract.
+func TestStructLoggerStorage(t *testing.T) {
+	code := []byte{
+		byte(vm.PUSH1), 1, byte(vm.PUSH1), 0, byte(vm.SSTORE),
+		byte(vm.PUSH1), 0, byte(vm.SLOAD), byte(vm.STOP),
+	}
+	logger := vm.NewStructLogger(nil)
+	if _, _, err := Execute(code, nil, &Config{DisableJit: true, Tracer: logger}); err != nil {
+		t.Fatalf("execution failed: %v", err)
+	}
+	logs := logger.StructLogs()
+	if len(logs) != 6 {
+		t.Fatalf("log count mismatch: have %d, want %d", len(logs), 6)
+	}
+	if len(logs[2].Storage) != 1 || logs[2].Storage[common.Hash{}] != common.BigToHash(big.NewInt(1)) {
+		t.Errorf("storage mismatch after SSTORE: have %v", logs[2].Storage)
+	}
+	if logs[5].Depth != 1 {
+		t.Errorf("depth mismatch: have %d, want %d", logs[5].Depth, 1)
+	}
+}
+
+// Tests that the call tracer records the nested calls made by a contract.
+func TestCallTracer(t *testing.T) {
+	// Call the identity precompile with the word 1 and stop
+	code := []byte{
+		byte(vm.PUSH1), 1, byte(vm.PUSH1), 0, byte(vm.MSTORE),
+		byte(vm.PUSH1), 32, byte(vm.PUSH1), 0, byte(vm.PUSH1), 32, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0,
+		byte(vm.PUSH1), 4, byte(vm.PUSH2), 0xff, 0xff, byte(vm.CALL), byte(vm.STOP),
+	}
+	tracer := vm.NewCallTracer()
+	if _, _, err := Execute(code, nil, &Config{Tracer: tracer}); err != nil {
+		t.Fatalf("execution failed: %v", err)
+	}
+	root := tracer.Result()
+	if root == nil || root.Type != vm.CALL || root.To != common.StringToAddress("contract") {
+		t.Fatalf("outermost frame mismatch: have %+v", root)
+	}
+	if len(root.Calls) != 1 {
+		t.Fatalf("nested call count mismatch: have %d, want %d", len(root.Calls), 1)
+	}
+	call := root.Calls[0]
+	if call.Type != vm.CALL || call.From != root.To || call.To != common.BytesToAddress([]byte{4}) {
+		t.Errorf("nested frame mismatch: have %+v", call)
+	}
+	if want := common.LeftPadBytes([]byte{1}, 32); string(call.Input) != string(want) || string(call.Output) != string(want) {
+		t.Errorf("nested frame data mismatch: have %x -> %x, want %x -> %x", call.Input, call.Output, want, want)
+	}
+	if call.GasUsed.Sign() <= 0 || call.GasUsed.Cmp(root.GasUsed) >= 0 {
+		t.Errorf("nested gas usage mismatch: have %v, outermost %v", call.GasUsed, root.GasUsed)
+	}
+}
+
+// Tests that the alt_bn128 precompiled contracts are only available once the
+// Metropolis fork of the chain configuration is active.
+func TestMetropolisPrecompiled(t *testing.T) {
+	// Pass the input to the curve addition precompile and return its output
+	code := []byte{
+		byte(vm.CALLDATASIZE), byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.CALLDATACOPY),
+		byte(vm.PUSH1), 64, byte(vm.PUSH1), 0, byte(vm.CALLDATASIZE), byte(vm.PUSH1), 0, byte(vm.PUSH1), 0,
+		byte(vm.PUSH1), 6, byte(vm.PUSH2), 0xff, 0xff, byte(vm.CALL), byte(vm.POP),
+		byte(vm.PUSH1), 64, byte(vm.PUSH1), 0, byte(vm.RETURN),
+	}
+	// The generator of G1 added to itself
+	input := common.Hex2Bytes("0000000000000000000000000000000000000000000000000000000000000001" + "0000000000000000000000000000000000000000000000000000000000000002" +
+		"0000000000000000000000000000000000000000000000000000000000000001" + "0000000000000000000000000000000000000000000000000000000000000002")
+	double := common.Hex2Bytes("030644e72e131a029b85045b68181585d97816a916871ca8d3c208c16d87cfd3" + "15ed738c0e0a7c92e7845f96b2ae9c0a68a6a449e3538fc7ff3ebf7a5a18a2c4")
+
+	ret, _, err := Execute(code, input, &Config{})
+	if err != nil {
+		t.Fatalf("execution failed: %v", err)
+	}
+	if string(ret) != string(input[:64]) {
+		t.Errorf("precompile active before Metropolis: have %x", ret)
+	}
+	metropolis := &core.ChainConfig{HomesteadBlock: new(big.Int), MetropolisBlock: new(big.Int)}
+	if ret, _, err = Execute(code, input, &Config{RuleSet: metropolis}); err != nil {
+		t.Fatalf("execution failed: %v", err)
+	}
+	if string(ret) != string(double) {
+		t.Errorf("curve addition mismatch: have %x, want %x", ret, double)
+	}
+}
+
 func BenchmarkCall(b *testing.B) {
 	var definition = `[{"constant":true,"inputs":[],"name":"seller","outputs":[{"name":"","type":"address"}],"type":"function"},{"constant":false,"inputs":[],"name":"abort","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"value","outputs":[{"name":"","type":"uint256"}],"type":"function"},{"constant":false,"inputs":[],"name":"refund","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"buyer","outputs":[{"name":"","type":"address"}],"type":"function"},{"constant":false,"inputs":[],"name":"confirmReceived","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"state","outputs":[{"name":"","type":"uint8"}],"type":"function"},{"constant":false,"inputs":[],"name":"confirmPurchase","outputs":[],"type":"function"},{"inputs":[],"type":"constructor"},{"anonymous":false,"inputs":[],"name":"Aborted","type":"event"},{"anonymous":false,"inputs":[],"name":"PurchaseConfirmed","type":"event"},{"anonymous":false,"inputs":[],"name":"ItemReceived","type":"event"},{"anonymous":false,"inputs":[],"name":"Refunded","type":"event"}]`
 
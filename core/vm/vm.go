@@ -30,15 +30,17 @@ import (
 
 // Vm is an EVM and implements VirtualMachine
 type Vm struct {
-	env Environment
+	env       Environment
+	jumpTable vmJumpTable
 }
 
 // New returns a new Vm
 func New(env Environment) *Vm {
 	// init the jump table. Also prepares the homestead changes
-	jumpTable.init(env.BlockNumber())
-
-	return &Vm{env: env}
+	return &Vm{
+		env:       env,
+		jumpTable: newJumpTable(env.RuleSet(), env.BlockNumber()),
+	}
 }
 
 // Run loops and evaluates the contract's code with the given input data
@@ -47,7 +49,7 @@ func (self *Vm) Run(contract *Contract, input []byte) (ret []byte, err error) {
 	defer self.env.SetDepth(self.env.Depth() - 1)
 
 	if contract.CodeAddr != nil {
-		if p := Precompiled[contract.CodeAddr.Str()]; p != nil {
+		if p := ActivePrecompiled(self.env.RuleSet(), self.env.BlockNumber())[contract.CodeAddr.Str()]; p != nil {
 			return self.RunPrecompiled(p, input, contract)
 		}
 	}
@@ -169,7 +171,7 @@ func (self *Vm) Run(contract *Contract, input []byte) (ret []byte, err error) {
 		mem.Resize(newMemSize.Uint64())
 		// Add a log message
 		self.log(pc, op, contract.Gas, cost, mem, stack, contract, nil)
-		if opPtr := jumpTable[op]; opPtr.valid {
+		if opPtr := self.jumpTable[op]; opPtr.valid {
 			if opPtr.fn != nil {
 				opPtr.fn(instruction{}, &pc, self.env, contract, mem, stack)
 			} else {
@@ -216,7 +218,7 @@ func (self *Vm) Run(contract *Contract, input []byte) (ret []byte, err error) {
 
 // calculateGasAndSize calculates the required given the opcode and stack items calculates the new memorysize for
 // the operation. This does not reduce gas or resizes the memory.
-func calculateGasAndSize(env Environment, contract *Contract, caller ContractRef, op OpCode, statedb Database, mem *Memory, stack *stack) (*big.Int, *big.Int, error) {
+func calculateGasAndSize(env Environment, contract *Contract, caller ContractRef, op OpCode, statedb Database, mem *Memory, stack *Stack) (*big.Int, *big.Int, error) {
 	var (
 		gas                 = new(big.Int)
 		newMemSize *big.Int = new(big.Int)
@@ -351,35 +353,23 @@ func calculateGasAndSize(env Environment, contract *Contract, caller ContractRef
 
 // RunPrecompile runs and evaluate the output of a precompiled contract defined in contracts.go
 func (self *Vm) RunPrecompiled(p *PrecompiledAccount, input []byte, contract *Contract) (ret []byte, err error) {
-	gas := p.Gas(len(input))
+	gas := p.Gas(input)
 	if contract.UseGas(gas) {
-		ret = p.Call(input)
-
-		return ret, nil
+		return p.Call(input)
 	} else {
 		return nil, OutOfGasError
 	}
 }
 
-// log emits a log event to the environment for each opcode encountered. This is not to be confused with the
-// LOG* opcode.
-func (self *Vm) log(pc uint64, op OpCode, gas, cost *big.Int, memory *Memory, stack *stack, contract *Contract, err error) {
-	if Debug {
-		mem := make([]byte, len(memory.Data()))
-		copy(mem, memory.Data())
-
-		stck := make([]*big.Int, len(stack.Data()))
-		for i, item := range stack.Data() {
-			stck[i] = new(big.Int).Set(item)
+// log reports the state of the VM to the tracer of the environment (if any) for
+// each opcode encountered. This is not to be confused with the LOG* opcode.
+func (self *Vm) log(pc uint64, op OpCode, gas, cost *big.Int, memory *Memory, stack *Stack, contract *Contract, err error) {
+	if tracer := self.env.Tracer(); tracer != nil {
+		// Faults before the gas calculation leave the cost unset
+		if cost == nil {
+			cost = new(big.Int)
 		}
-		storage := make(map[common.Hash][]byte)
-		/*
-			object := contract.self.(*state.StateObject)
-			object.EachStorage(func(k, v []byte) {
-				storage[common.BytesToHash(k)] = v
-			})
-		*/
-		self.env.AddStructLog(StructLog{pc, op, new(big.Int).Set(gas), cost, mem, stck, storage, err})
+		tracer.CaptureState(self.env, pc, op, gas, cost, memory, stack, contract, self.env.Depth(), err)
 	}
 }
 
@@ -170,7 +170,7 @@ func (self *JitVm) Run(me, caller ContextRef, code []byte, value, gas, price *bi
 	self.env.SetDepth(self.env.Depth() + 1)
 
 	// TODO: Move it to Env.Call() or sth
-	if Precompiled[string(me.Address())] != nil {
+	if ActivePrecompiled(self.env.RuleSet(), self.env.BlockNumber())[string(me.Address())] != nil {
 		// if it's address of precopiled contract
 		// fallback to standard VM
 		stdVm := New(self.env)
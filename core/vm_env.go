@@ -26,26 +26,29 @@ import (
 )
 
 type VMEnv struct {
+	config *ChainConfig
 	state  *state.StateDB
 	header *types.Header
 	msg    Message
 	depth  int
 	chain  *BlockChain
 	typ    vm.Type
-	// structured logging
-	logs []vm.StructLog
+	tracer vm.Tracer // tracer capturing the execution (nil = no tracing)
 }
 
-func NewEnv(state *state.StateDB, chain *BlockChain, msg Message, header *types.Header) *VMEnv {
+func NewEnv(state *state.StateDB, config *ChainConfig, chain *BlockChain, msg Message, header *types.Header, tracer vm.Tracer) *VMEnv {
 	return &VMEnv{
+		config: config,
 		chain:  chain,
 		state:  state,
 		header: header,
 		msg:    msg,
 		typ:    vm.StdVmTy,
+		tracer: tracer,
 	}
 }
 
+func (self *VMEnv) RuleSet() vm.RuleSet      { return self.config }
 func (self *VMEnv) Origin() common.Address   { f, _ := self.msg.From(); return f }
 func (self *VMEnv) BlockNumber() *big.Int    { return self.header.Number }
 func (self *VMEnv) Coinbase() common.Address { return self.header.Coinbase }
@@ -58,7 +61,15 @@ func (self *VMEnv) Depth() int               { return self.depth }
 func (self *VMEnv) SetDepth(i int)           { self.depth = i }
 func (self *VMEnv) VmType() vm.Type          { return self.typ }
 func (self *VMEnv) SetVmType(t vm.Type)      { self.typ = t }
+func (self *VMEnv) Tracer() vm.Tracer        { return self.tracer }
 func (self *VMEnv) GetHash(n uint64) common.Hash {
+	// Without a chain (offline state transitions) only the parent is known
+	if self.chain == nil {
+		if self.header.Number.Uint64() == n+1 {
+			return self.header.ParentHash
+		}
+		return common.Hash{}
+	}
 	for block := self.chain.GetBlock(self.header.ParentHash); block != nil; block = self.chain.GetBlock(block.ParentHash()) {
 		if block.NumberU64() == n {
 			return block.Hash()
@@ -101,11 +112,3 @@ func (self *VMEnv) DelegateCall(me vm.ContractRef, addr common.Address, data []b
 func (self *VMEnv) Create(me vm.ContractRef, data []byte, gas, price, value *big.Int) ([]byte, common.Address, error) {
 	return Create(self, me, data, gas, price, value)
 }
-
-func (self *VMEnv) StructLogs() []vm.StructLog {
-	return self.logs
-}
-
-func (self *VMEnv) AddStructLog(log vm.StructLog) {
-	self.logs = append(self.logs, log)
-}
@@ -0,0 +1,330 @@
+// Copyright 2016 The go-vector Authors
+// This file is part of the go-vector library.
+//
+// The go-vector library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vector library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vector library. If not, see <http://www.gnu.org/licenses/>.
+
+package jsre
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/vector/go-vector/common"
+	"github.com/vector/go-vector/core/vm"
+	"github.com/robertkrimen/otto"
+)
+
+// errExecutionTimeout is raised within the JavaScript interpreter when a
+// tracer runs longer than permitted.
+var errExecutionTimeout = errors.New("execution timeout")
+
+/*
+Tracer is a vm.Tracer evaluating a JavaScript object on every executed opcode.
+The object must define the functions
+
+- step(log, db), called before each opcode is executed
+- result(), called when the trace is done, returning the JSON result
+
+and may define fault(log, db), called instead of step when an opcode fails.
+
+The log object describes the current step through the fields pc, gas, cost,
+depth and err (only set for faults) and the objects
+
+- op: toNumber(), toString(), isPush()
+- stack: length(), peek(n) with n = 0 being the top of the stack
+- memory: length(), slice(start, end), getUint(offset)
+- contract: getCaller(), getAddress(), getValue(), getInput()
+
+while the db object gives access to the state through getBalance(addr),
+getNonce(addr), getCode(addr), getState(addr, hash) and exists(addr). Numbers
+not fitting a JavaScript number are returned as BigNumber instances, binary
+data as hex strings.
+*/
+type Tracer struct {
+	js     *otto.Otto
+	object *otto.Object // The user's tracer object
+	step   otto.Value   // The step function of the tracer object
+	fault  otto.Value   // The fault function of the tracer object, if any
+	result otto.Value   // The result function of the tracer object
+
+	log *otto.Object // The log object passed to step and fault
+	db  *otto.Object // The db object passed to step and fault
+
+	env      vm.Environment // Environment of the step being traced
+	op       vm.OpCode      // Opcode of the step being traced
+	memory   *vm.Memory     // Memory of the step being traced
+	stack    *vm.Stack      // Stack of the step being traced
+	contract *vm.Contract   // Contract of the step being traced
+
+	timeout time.Duration // Maximum time the tracer may run
+	timer   *time.Timer   // Timer interrupting the interpreter on timeout
+	once    sync.Once     // Ensures the timer is only started once
+	err     error         // First error raised by the tracer, if any
+}
+
+// NewTracer compiles the given JavaScript tracer object (e.g. "{step: ...}")
+// into a tracer, whose evaluation is aborted once it ran longer than timeout.
+// The timeout must be positive, tracers can't run unlimited.
+func NewTracer(code string, timeout time.Duration) (*Tracer, error) {
+	if timeout <= 0 {
+		return nil, fmt.Errorf("invalid tracer timeout %v", timeout)
+	}
+	js := otto.New()
+	js.Interrupt = make(chan func(), 1)
+
+	if _, err := js.Run(BigNumber_JS); err != nil {
+		return nil, fmt.Errorf("failed to load bignumber.js: %v", err)
+	}
+	object, err := js.Object("(" + code + ")")
+	if err != nil {
+		return nil, err
+	}
+	tracer := &Tracer{
+		js:      js,
+		object:  object,
+		timeout: timeout,
+	}
+	if tracer.step, err = object.Get("step"); err != nil || !tracer.step.IsFunction() {
+		return nil, errors.New("trace object must expose a function step()")
+	}
+	if tracer.result, err = object.Get("result"); err != nil || !tracer.result.IsFunction() {
+		return nil, errors.New("trace object must expose a function result()")
+	}
+	if tracer.fault, err = object.Get("fault"); err != nil || !tracer.fault.IsFunction() {
+		tracer.fault = otto.UndefinedValue()
+	}
+	if err := tracer.bind(); err != nil {
+		return nil, err
+	}
+	return tracer, nil
+}
+
+// bind creates the log and db objects, whose accessors read the step being
+// traced.
+func (t *Tracer) bind() error {
+	log, _ := t.js.Object("({op: {}, stack: {}, memory: {}, contract: {}})")
+	db, _ := t.js.Object("({})")
+
+	get := func(object *otto.Object, name string) *otto.Object {
+		value, _ := object.Get(name)
+		return value.Object()
+	}
+	methods := map[*otto.Object]map[string]func(otto.FunctionCall) otto.Value{
+		get(log, "op"): {
+			"toNumber": func(call otto.FunctionCall) otto.Value { return t.value(int(t.op)) },
+			"toString": func(call otto.FunctionCall) otto.Value { return t.value(t.op.String()) },
+			"isPush":   func(call otto.FunctionCall) otto.Value { return t.value(t.op.IsPush()) },
+		},
+		get(log, "stack"): {
+			"length": func(call otto.FunctionCall) otto.Value { return t.value(len(t.stack.Data())) },
+			"peek":   t.peekStack,
+		},
+		get(log, "memory"): {
+			"length":  func(call otto.FunctionCall) otto.Value { return t.value(t.memory.Len()) },
+			"slice":   t.sliceMemory,
+			"getUint": t.getMemoryUint,
+		},
+		get(log, "contract"): {
+			"getCaller":  func(call otto.FunctionCall) otto.Value { return t.value(t.contract.Caller().Hex()) },
+			"getAddress": func(call otto.FunctionCall) otto.Value { return t.value(t.contract.Address().Hex()) },
+			"getValue":   func(call otto.FunctionCall) otto.Value { return t.bigNumber(t.contract.Value()) },
+			"getInput":   func(call otto.FunctionCall) otto.Value { return t.value(fmt.Sprintf("0x%x", t.contract.Input)) },
+		},
+		db: {
+			"getBalance": func(call otto.FunctionCall) otto.Value {
+				return t.bigNumber(t.env.Db().GetBalance(t.address(call, 0)))
+			},
+			"getNonce": func(call otto.FunctionCall) otto.Value {
+				return t.value(t.env.Db().GetNonce(t.address(call, 0)))
+			},
+			"getCode": func(call otto.FunctionCall) otto.Value {
+				return t.value(fmt.Sprintf("0x%x", t.env.Db().GetCode(t.address(call, 0))))
+			},
+			"getState": func(call otto.FunctionCall) otto.Value {
+				value := t.env.Db().GetState(t.address(call, 0), common.HexToHash(call.Argument(1).String()))
+				return t.value(value.Hex())
+			},
+			"exists": func(call otto.FunctionCall) otto.Value {
+				return t.value(t.env.Db().Exist(t.address(call, 0)))
+			},
+		},
+	}
+	for object, funcs := range methods {
+		for name, fn := range funcs {
+			if err := object.Set(name, fn); err != nil {
+				return err
+			}
+		}
+	}
+	t.log, t.db = log, db
+	return nil
+}
+
+// peekStack implements log.stack.peek(n), returning the n-th stack item
+// counted from the top.
+func (t *Tracer) peekStack(call otto.FunctionCall) otto.Value {
+	n, err := call.Argument(0).ToInteger()
+	data := t.stack.Data()
+	if err != nil || n < 0 || n >= int64(len(data)) {
+		throw(fmt.Sprintf("stack index %v out of bounds (length %d)", call.Argument(0), len(data)))
+	}
+	return t.bigNumber(data[len(data)-1-int(n)])
+}
+
+// sliceMemory implements log.memory.slice(start, end), returning the memory
+// contents in the given range as a hex string.
+func (t *Tracer) sliceMemory(call otto.FunctionCall) otto.Value {
+	start, err1 := call.Argument(0).ToInteger()
+	end, err2 := call.Argument(1).ToInteger()
+	data := t.memory.Data()
+	if err1 != nil || err2 != nil || start < 0 || start > end || end > int64(len(data)) {
+		throw(fmt.Sprintf("memory range [%v, %v) out of bounds (length %d)", call.Argument(0), call.Argument(1), len(data)))
+	}
+	return t.value(fmt.Sprintf("0x%x", data[start:end]))
+}
+
+// getMemoryUint implements log.memory.getUint(offset), returning the 32 byte
+// word at the given memory offset.
+func (t *Tracer) getMemoryUint(call otto.FunctionCall) otto.Value {
+	offset, err := call.Argument(0).ToInteger()
+	data := t.memory.Data()
+	if err != nil || offset < 0 || offset+32 > int64(len(data)) {
+		throw(fmt.Sprintf("memory word at %v out of bounds (length %d)", call.Argument(0), len(data)))
+	}
+	return t.bigNumber(new(big.Int).SetBytes(data[offset : offset+32]))
+}
+
+// address parses the n-th argument of a call as an account address.
+func (t *Tracer) address(call otto.FunctionCall, n int) common.Address {
+	arg := call.Argument(n)
+	if !arg.IsString() {
+		throw(fmt.Sprintf("address argument %v is not a string", arg))
+	}
+	return common.HexToAddress(arg.String())
+}
+
+// value converts a Go value into a JavaScript one.
+func (t *Tracer) value(v interface{}) otto.Value {
+	value, err := t.js.ToValue(v)
+	if err != nil {
+		throw(err.Error())
+	}
+	return value
+}
+
+// bigNumber converts a big integer into a JavaScript BigNumber.
+func (t *Tracer) bigNumber(v *big.Int) otto.Value {
+	value, err := t.js.Call("new BigNumber", nil, v.String())
+	if err != nil {
+		throw(err.Error())
+	}
+	return value
+}
+
+// throw raises a JavaScript exception from within a native function.
+func throw(msg string) {
+	value, _ := otto.ToValue(msg)
+	panic(value)
+}
+
+// start arms the execution timeout of the tracer.
+func (t *Tracer) start() {
+	t.once.Do(func() {
+		t.timer = time.AfterFunc(t.timeout, func() {
+			t.js.Interrupt <- func() { panic(errExecutionTimeout) }
+		})
+	})
+}
+
+// call invokes a function of the tracer object, recording the first error it
+// raises. Once an error was recorded the tracer isn't evaluated any more.
+func (t *Tracer) call(fn otto.Value, args ...interface{}) (result otto.Value) {
+	if t.err != nil {
+		return otto.UndefinedValue()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			if r == errExecutionTimeout {
+				t.err = errExecutionTimeout
+			} else {
+				t.err = fmt.Errorf("%v", r)
+			}
+		}
+	}()
+	result, err := fn.Call(t.object.Value(), args...)
+	if err != nil {
+		t.err = err
+	}
+	return result
+}
+
+// CaptureStart implements vm.Tracer, starting the execution timeout.
+func (t *Tracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas, value *big.Int) {
+	t.start()
+}
+
+// CaptureState implements vm.Tracer, evaluating the step function (or the
+// fault function for failing opcodes) of the tracer object.
+func (t *Tracer) CaptureState(env vm.Environment, pc uint64, op vm.OpCode, gas, cost *big.Int, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) {
+	if t.err != nil {
+		return
+	}
+	t.env, t.op, t.memory, t.stack, t.contract = env, op, memory, stack, contract
+
+	// The cost is unknown if the opcode failed before it was computed
+	if cost == nil {
+		cost = new(big.Int)
+	}
+	t.log.Set("pc", pc)
+	t.log.Set("gas", gas.Uint64())
+	t.log.Set("cost", cost.Uint64())
+	t.log.Set("depth", depth)
+
+	if err == nil {
+		t.log.Set("err", otto.UndefinedValue())
+		t.call(t.step, t.log, t.db)
+	} else if t.fault.IsFunction() {
+		t.log.Set("err", err.Error())
+		t.call(t.fault, t.log, t.db)
+	}
+}
+
+// CaptureEnter implements vm.Tracer, it's a noop for JavaScript tracers.
+func (t *Tracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas, value *big.Int) {
+}
+
+// CaptureExit implements vm.Tracer, it's a noop for JavaScript tracers.
+func (t *Tracer) CaptureExit(output []byte, gasUsed *big.Int, err error) {
+}
+
+// CaptureEnd implements vm.Tracer, it's a noop for JavaScript tracers.
+func (t *Tracer) CaptureEnd(output []byte, gasUsed *big.Int, d time.Duration, err error) {
+}
+
+// Result evaluates the result function of the tracer object, returning its
+// outcome converted into Go values, or the first error raised by the tracer.
+func (t *Tracer) Result() (interface{}, error) {
+	t.start()
+	if t.timer != nil {
+		defer t.timer.Stop()
+	}
+	result := t.call(t.result)
+	if t.err != nil {
+		return nil, t.err
+	}
+	return result.Export()
+}
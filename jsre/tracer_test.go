@@ -0,0 +1,150 @@
+// Copyright 2016 The go-vector Authors
+// This file is part of the go-vector library.
+//
+// The go-vector library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vector library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vector library. If not, see <http://www.gnu.org/licenses/>.
+
+package jsre
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/vector/go-vector/core/vm"
+	"github.com/vector/go-vector/core/vm/runtime"
+)
+
+// Stores 0x2a at memory offset 0 and returns it.
+var tracerTestCode = []byte{
+	byte(vm.PUSH1), 0x2a, byte(vm.PUSH1), 0, byte(vm.MSTORE),
+	byte(vm.PUSH1), 32, byte(vm.PUSH1), 0, byte(vm.RETURN),
+}
+
+// runTracer executes the test code with a JavaScript tracer, returning its result.
+func runTracer(code string, timeout time.Duration) (interface{}, error) {
+	tracer, err := NewTracer(code, timeout)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := runtime.Execute(tracerTestCode, nil, &runtime.Config{DisableJit: true, Tracer: tracer}); err != nil {
+		return nil, err
+	}
+	return tracer.Result()
+}
+
+func TestTracerOpcodes(t *testing.T) {
+	result, err := runTracer(`{
+		ops: [],
+		step: function(log, db) { this.ops.push(log.op.toString() + "@" + log.pc); },
+		result: function() { return this.ops.join(","); }
+	}`, time.Second)
+	if err != nil {
+		t.Fatalf("trace failed: %v", err)
+	}
+	if want := "PUSH1@0,PUSH1@2,MSTORE@4,PUSH1@5,PUSH1@7,RETURN@9"; result != want {
+		t.Errorf("result mismatch: have %v, want %v", result, want)
+	}
+}
+
+func TestTracerAccessors(t *testing.T) {
+	result, err := runTracer(`{
+		res: {},
+		step: function(log, db) {
+			if (log.op.toString() == "MSTORE") {
+				this.res.value = log.stack.peek(1).toString();
+				this.res.offset = log.stack.peek(0).toNumber();
+				this.res.push = log.op.isPush();
+			}
+			if (log.op.toString() == "RETURN") {
+				this.res.word = log.memory.getUint(0).toString(16);
+				this.res.slice = log.memory.slice(31, 32);
+				this.res.exists = db.exists(log.contract.getAddress());
+				this.res.depth = log.depth;
+			}
+		},
+		result: function() { return this.res; }
+	}`, time.Second)
+	if err != nil {
+		t.Fatalf("trace failed: %v", err)
+	}
+	have, _ := json.Marshal(result)
+	if want := `{"depth":1,"exists":true,"offset":0,"push":false,"slice":"0x2a","value":"42","word":"2a"}`; string(have) != want {
+		t.Errorf("result mismatch:\nhave %s\nwant %s", have, want)
+	}
+}
+
+func TestTracerErrors(t *testing.T) {
+	if _, err := NewTracer(`{step: function() {}}`, time.Second); err == nil {
+		t.Errorf("tracer without result function accepted")
+	}
+	if _, err := NewTracer(`{step: 1, result: function() {}}`, time.Second); err == nil {
+		t.Errorf("tracer with non-function step accepted")
+	}
+	for _, timeout := range []time.Duration{0, -time.Second} {
+		if _, err := NewTracer(`{step: function() {}, result: function() {}}`, timeout); err == nil {
+			t.Errorf("tracer with timeout %v accepted", timeout)
+		}
+	}
+	if _, err := runTracer(`{
+		step: function(log) { log.stack.peek(1024); },
+		result: function() { return true; }
+	}`, time.Second); err == nil {
+		t.Errorf("out of bounds stack access succeeded")
+	}
+	if _, err := runTracer(`{
+		step: function(log) { for (var i = 0; ; i++) { this.steps = i; } },
+		result: function() { return true; }
+	}`, 10*time.Millisecond); err != errExecutionTimeout {
+		t.Errorf("error mismatch: have %v, want %v", err, errExecutionTimeout)
+	}
+}
+
+// Tests that faulting opcodes are reported to the fault function, even if they
+// failed before their cost was computed.
+func TestTracerFault(t *testing.T) {
+	code := `{
+		faults: [],
+		step: function(log, db) {},
+		fault: function(log, db) { this.faults.push(log.op.toString() + "@" + log.pc + ":" + log.cost + ":" + log.err); },
+		result: function() { return this.faults.join(","); }
+	}`
+	for _, disableJit := range []bool{true, false} {
+		tracer, err := NewTracer(code, time.Second)
+		if err != nil {
+			t.Fatalf("failed to create tracer: %v", err)
+		}
+		// Stack underflow on the very first opcode
+		if _, _, err := runtime.Execute([]byte{byte(vm.ADD)}, nil, &runtime.Config{DisableJit: disableJit, Tracer: tracer}); err == nil {
+			t.Fatalf("jit disabled %v: stack underflow not reported", disableJit)
+		}
+		result, err := tracer.Result()
+		if err != nil {
+			t.Fatalf("jit disabled %v: trace failed: %v", disableJit, err)
+		}
+		if want := "ADD@0:0:stack underflow (0 <=> 2)"; result != want {
+			t.Errorf("jit disabled %v: result mismatch: have %v, want %v", disableJit, result, want)
+		}
+	}
+	// Tracers must cope with engines not knowing the cost at all
+	tracer, err := NewTracer(code, time.Second)
+	if err != nil {
+		t.Fatalf("failed to create tracer: %v", err)
+	}
+	tracer.CaptureState(nil, 3, vm.ADD, big.NewInt(100), nil, nil, nil, nil, 1, errors.New("fault"))
+	if result, err := tracer.Result(); err != nil || result != "ADD@3:0:fault" {
+		t.Errorf("nil cost result mismatch: have %v (%v), want ADD@3:0:fault", result, err)
+	}
+}
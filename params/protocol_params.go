@@ -71,3 +71,11 @@ var (
 	MemoryGas            = big.NewInt(3)      // Times the address of the (highest referenced byte in memory + 1). NOTE: referencing happens on read, write and in instructions such as RETURN and CALL.
 	TxDataNonZeroGas     = big.NewInt(68)     // Per byte of data attached to a transaction that is not equal to zero. NOTE: Not payable on data of calls between transactions.
 )
+
+var (
+	ModExpQuadCoeffDiv      = big.NewInt(20)     // Divisor of the quadratic particle of the MODEXP precompile's gas.
+	Bn256AddGas             = big.NewInt(500)    // Gas needed for an elliptic curve addition.
+	Bn256ScalarMulGas       = big.NewInt(40000)  // Gas needed for an elliptic curve scalar multiplication.
+	Bn256PairingBaseGas     = big.NewInt(100000) // Base price for an elliptic curve pairing check.
+	Bn256PairingPerPointGas = big.NewInt(80000)  // Per-point price for an elliptic curve pairing check.
+)
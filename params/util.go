@@ -19,15 +19,6 @@ package params
 import "math/big"
 
 var (
-	TestNetHomesteadBlock = big.NewInt(1)    // testnet homestead block
-	MainNetHomesteadBlock = big.NewInt(1)   // mainnet homestead block
-	HomesteadBlock        = MainNetHomesteadBlock // homestead block used to check against
+	TestNetHomesteadBlock = big.NewInt(1) // testnet homestead block
+	MainNetHomesteadBlock = big.NewInt(1) // mainnet homestead block
 )
-
-func IsHomestead(blockNumber *big.Int) bool {
-	// for unit tests TODO: flip to true after homestead is live
-	if blockNumber == nil {
-		return false
-	}
-	return blockNumber.Cmp(HomesteadBlock) >= 0
-}
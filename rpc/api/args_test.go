@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"math/big"
 	"testing"
+	"time"
 
 	"github.com/vector/go-vector/rpc/shared"
 )
@@ -2647,3 +2648,66 @@ func TestDataArgsInvalid(t *testing.T) {
 		t.Error(str)
 	}
 }
+
+func TestTraceTransactionArgs(t *testing.T) {
+	input := `["0xd5f1812548be429cbdc6376b29611fc49e06f1359758c4ceaaa3b393e2239f9c", {"disableMemory": true, "disableStorage": true, "tracer": "callTracer"}]`
+	expected := new(TraceTransactionArgs)
+	expected.Hash = "0xd5f1812548be429cbdc6376b29611fc49e06f1359758c4ceaaa3b393e2239f9c"
+	expected.Options.LogConfig.DisableMemory = true
+	expected.Options.LogConfig.DisableStorage = true
+	expected.Options.Tracer = "callTracer"
+
+	args := new(TraceTransactionArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Fatal(err)
+	}
+	if *args != *expected {
+		t.Errorf("args mismatch: have %+v, want %+v", args, expected)
+	}
+}
+
+func TestTraceTransactionArgsInvalidOption(t *testing.T) {
+	input := `["0xd5f1812548be429cbdc6376b29611fc49e06f1359758c4ceaaa3b393e2239f9c", {"disableStack": 1}]`
+
+	args := new(TraceTransactionArgs)
+	str := ExpectInvalidTypeError(json.Unmarshal([]byte(input), args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestTraceTransactionArgsTimeout(t *testing.T) {
+	input := `["0xd5f1812548be429cbdc6376b29611fc49e06f1359758c4ceaaa3b393e2239f9c", {"tracer": "{step: function() {}, result: function() {}}", "timeout": "1.5s"}]`
+
+	args := new(TraceTransactionArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.Options.Timeout != 1500*time.Millisecond {
+		t.Errorf("timeout mismatch: have %v, want %v", args.Options.Timeout, 1500*time.Millisecond)
+	}
+	input = `["0xd5f1812548be429cbdc6376b29611fc49e06f1359758c4ceaaa3b393e2239f9c", {"timeout": "1000h"}]`
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.Options.Timeout != maxTraceTimeout {
+		t.Errorf("timeout mismatch: have %v, want %v", args.Options.Timeout, maxTraceTimeout)
+	}
+	for _, timeout := range []string{"soon", "0s", "-1s"} {
+		input = `["0xd5f1812548be429cbdc6376b29611fc49e06f1359758c4ceaaa3b393e2239f9c", {"timeout": "` + timeout + `"}]`
+		str := ExpectValidationError(json.Unmarshal([]byte(input), new(TraceTransactionArgs)))
+		if len(str) > 0 {
+			t.Errorf("timeout %s: %s", timeout, str)
+		}
+	}
+}
+
+func TestTraceBlockByNumberArgsEmpty(t *testing.T) {
+	input := `[]`
+
+	args := new(TraceBlockByNumberArgs)
+	str := ExpectInsufficientParamsError(json.Unmarshal([]byte(input), args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
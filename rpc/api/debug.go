@@ -22,9 +22,12 @@ import (
 	"time"
 
 	"github.com/vector/vecash"
+	"github.com/vector/go-vector/common"
 	"github.com/vector/go-vector/core"
 	"github.com/vector/go-vector/core/state"
+	"github.com/vector/go-vector/core/types"
 	"github.com/vector/go-vector/core/vm"
+	"github.com/vector/go-vector/jsre"
 	"github.com/vector/go-vector/vec"
 	"github.com/vector/go-vector/rlp"
 	"github.com/vector/go-vector/rpc/codec"
@@ -35,6 +38,14 @@ import (
 
 const (
 	DebugApiVersion = "1.0"
+
+	// defaultTraceTimeout is the run time limit of JavaScript tracers if the
+	// request doesn't specify one.
+	defaultTraceTimeout = 5 * time.Second
+
+	// maxTraceTimeout is the run time limit of JavaScript tracers that requests
+	// can't raise.
+	maxTraceTimeout = time.Minute
 )
 
 var (
@@ -42,11 +53,16 @@ var (
 	DebugMapping = map[string]debughandler{
 		"debug_dumpBlock":    (*debugApi).DumpBlock,
 		"debug_getBlockRlp":  (*debugApi).GetBlockRlp,
+		"debug_getBadBlocks": (*debugApi).GetBadBlocks,
 		"debug_printBlock":   (*debugApi).PrintBlock,
 		"debug_processBlock": (*debugApi).ProcessBlock,
 		"debug_seedHash":     (*debugApi).SeedHash,
 		"debug_setHead":      (*debugApi).SetHead,
 		"debug_metrics":      (*debugApi).Metrics,
+
+		"debug_traceTransaction":   (*debugApi).TraceTransaction,
+		"debug_traceBlockByNumber": (*debugApi).TraceBlockByNumber,
+		"debug_traceBlockByHash":   (*debugApi).TraceBlockByHash,
 	}
 )
 
@@ -142,6 +158,29 @@ func (self *debugApi) GetBlockRlp(req *shared.Request) (interface{}, error) {
 	return fmt.Sprintf("%x", encoded), err
 }
 
+// GetBadBlocks returns the blocks most recently rejected during import, along
+// with the peer they originated from and the reason of their rejection.
+func (self *debugApi) GetBadBlocks(req *shared.Request) (interface{}, error) {
+	bad := core.GetBadBlocks(self.vector.ChainDb())
+
+	results := make([]map[string]interface{}, 0, len(bad))
+	for _, entry := range bad {
+		encoded, err := rlp.EncodeToBytes(entry.Block)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, map[string]interface{}{
+			"hash":   entry.Block.Hash().Hex(),
+			"number": entry.Block.NumberU64(),
+			"origin": entry.Origin,
+			"error":  entry.Error,
+			"time":   entry.Time,
+			"rlp":    fmt.Sprintf("0x%x", encoded),
+		})
+	}
+	return results, nil
+}
+
 func (self *debugApi) SetHead(req *shared.Request) (interface{}, error) {
 	args := new(BlockNumArg)
 	if err := self.codec.Decode(req.Params, &args); err != nil {
@@ -173,7 +212,7 @@ func (self *debugApi) ProcessBlock(req *shared.Request) (interface{}, error) {
 		processor  = blockchain.Processor()
 	)
 
-	err := core.ValidateHeader(blockchain.AuxValidator(), block.Header(), blockchain.GetHeader(block.ParentHash()), true, false)
+	err := core.ValidateHeader(blockchain.Config(), blockchain.AuxValidator(), block.Header(), blockchain.GetHeader(block.ParentHash()), true, false)
 	if err != nil {
 		return false, err
 	}
@@ -193,6 +232,179 @@ func (self *debugApi) ProcessBlock(req *shared.Request) (interface{}, error) {
 	return true, nil
 }
 
+// TraceTransaction re-executes a canonical transaction on top of the state it
+// was originally executed on, returning the struct log of every executed opcode
+// or the result of the requested built-in or JavaScript tracer.
+func (self *debugApi) TraceTransaction(req *shared.Request) (interface{}, error) {
+	args := new(TraceTransactionArgs)
+	if err := self.codec.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+	hash := common.HexToHash(args.Hash)
+
+	tracer, err := newTracer(args.Options)
+	if err != nil {
+		return nil, err
+	}
+	if err := self.vector.BlockChain().TraceTransaction(hash, tracer); err != nil {
+		return nil, err
+	}
+	return newTraceResult(core.GetReceipt(self.vector.ChainDb(), hash), tracer)
+}
+
+// TraceBlockByNumber re-executes all the transactions of a canonical block,
+// returning the struct logs of each of them.
+func (self *debugApi) TraceBlockByNumber(req *shared.Request) (interface{}, error) {
+	args := new(TraceBlockByNumberArgs)
+	if err := self.codec.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+	block := self.xvec.EthBlockByNumber(args.BlockNumber)
+	if block == nil {
+		return nil, fmt.Errorf("block #%d not found", args.BlockNumber)
+	}
+	return self.traceBlock(block, args.Options)
+}
+
+// TraceBlockByHash re-executes all the transactions of a block, returning the
+// struct logs of each of them.
+func (self *debugApi) TraceBlockByHash(req *shared.Request) (interface{}, error) {
+	args := new(TraceBlockByHashArgs)
+	if err := self.codec.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+	block := self.xvec.EthBlockByHash(args.BlockHash)
+	if block == nil {
+		return nil, fmt.Errorf("block %s not found", args.BlockHash)
+	}
+	return self.traceBlock(block, args.Options)
+}
+
+// traceBlock re-executes all the transactions of a block with the tracer
+// requested by the options attached to each of them.
+func (self *debugApi) traceBlock(block *types.Block, options TraceOptions) (interface{}, error) {
+	if _, err := newTracer(options); err != nil {
+		return nil, err
+	}
+	tracers := make([]vm.Tracer, len(block.Transactions()))
+	err := self.vector.BlockChain().TraceBlock(block, func(i int, tx *types.Transaction) vm.Tracer {
+		tracers[i], _ = newTracer(options)
+		return tracers[i]
+	})
+	if err != nil {
+		return nil, err
+	}
+	receipts := core.GetBlockReceipts(self.vector.ChainDb(), block.Hash())
+
+	results := make([]map[string]interface{}, len(tracers))
+	for i, tracer := range tracers {
+		var receipt *types.Receipt
+		if i < len(receipts) {
+			receipt = receipts[i]
+		}
+		results[i] = map[string]interface{}{
+			"txHash": block.Transactions()[i].Hash().Hex(),
+		}
+		if result, err := newTraceResult(receipt, tracer); err != nil {
+			results[i]["error"] = err.Error()
+		} else {
+			results[i]["result"] = result
+		}
+	}
+	return results, nil
+}
+
+// newTracer creates the tracer requested by the options of a tracing request.
+// Tracers not known by name are compiled as JavaScript tracer objects.
+func newTracer(options TraceOptions) (vm.Tracer, error) {
+	switch options.Tracer {
+	case "":
+		return vm.NewStructLogger(&options.LogConfig), nil
+	case "callTracer":
+		return vm.NewCallTracer(), nil
+	case "prestateTracer":
+		return state.NewPrestateTracer(options.DiffMode), nil
+	default:
+		timeout := options.Timeout
+		if timeout == 0 {
+			timeout = defaultTraceTimeout
+		}
+		tracer, err := jsre.NewTracer(options.Tracer, timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tracer: %v", err)
+		}
+		return tracer, nil
+	}
+}
+
+// newTraceResult formats the outcome of a traced transaction.
+func newTraceResult(receipt *types.Receipt, tracer vm.Tracer) (interface{}, error) {
+	switch tracer := tracer.(type) {
+	case *vm.CallTracer:
+		return tracer.Result(), nil
+	case *state.PrestateTracer:
+		return tracer.Result(), nil
+	case *vm.StructLogger:
+		return newStructLogResult(receipt, tracer), nil
+	case *jsre.Tracer:
+		return tracer.Result()
+	default:
+		panic(fmt.Sprintf("unsupported tracer %T", tracer))
+	}
+}
+
+// newStructLogResult formats the outcome of a traced transaction along with the
+// struct logs of its execution.
+func newStructLogResult(receipt *types.Receipt, logger *vm.StructLogger) map[string]interface{} {
+	logs := make([]map[string]interface{}, len(logger.StructLogs()))
+	for i, log := range logger.StructLogs() {
+		logs[i] = map[string]interface{}{
+			"pc":      log.Pc,
+			"op":      log.Op.String(),
+			"gas":     log.Gas,
+			"gasCost": log.GasCost,
+			"depth":   log.Depth,
+		}
+		if log.Err != nil {
+			logs[i]["error"] = log.Err.Error()
+		}
+		if log.Stack != nil {
+			stack := make([]string, len(log.Stack))
+			for j, item := range log.Stack {
+				stack[j] = fmt.Sprintf("%x", common.LeftPadBytes(item.Bytes(), 32))
+			}
+			logs[i]["stack"] = stack
+		}
+		if log.Memory != nil {
+			memory := make([]string, 0, (len(log.Memory)+31)/32)
+			for j := 0; j < len(log.Memory); j += 32 {
+				end := j + 32
+				if end > len(log.Memory) {
+					end = len(log.Memory)
+				}
+				memory = append(memory, fmt.Sprintf("%x", log.Memory[j:end]))
+			}
+			logs[i]["memory"] = memory
+		}
+		if log.Storage != nil {
+			storage := make(map[string]string, len(log.Storage))
+			for key, value := range log.Storage {
+				storage[fmt.Sprintf("%x", key)] = fmt.Sprintf("%x", value)
+			}
+			logs[i]["storage"] = storage
+		}
+	}
+	result := map[string]interface{}{
+		"failed":      logger.Error() != nil,
+		"returnValue": fmt.Sprintf("%x", logger.Output()),
+		"structLogs":  logs,
+	}
+	if receipt != nil {
+		result["gas"] = receipt.GasUsed
+	}
+	return result
+}
+
 func (self *debugApi) SeedHash(req *shared.Request) (interface{}, error) {
 	args := new(BlockNumArg)
 	if err := self.codec.Decode(req.Params, &args); err != nil {
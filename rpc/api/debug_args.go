@@ -21,7 +21,9 @@ import (
 	"fmt"
 	"math/big"
 	"reflect"
+	"time"
 
+	"github.com/vector/go-vector/core/vm"
 	"github.com/vector/go-vector/rpc/shared"
 )
 
@@ -85,3 +87,133 @@ func (args *MetricsArgs) UnmarshalJSON(b []byte) (err error) {
 	}
 	return nil
 }
+
+// TraceOptions are the options of the tracing requests.
+type TraceOptions struct {
+	LogConfig vm.LogConfig  // Configuration of the default struct logger
+	Tracer    string        // Built-in tracer name (e.g. callTracer) or JavaScript tracer code
+	Timeout   time.Duration // Maximum run time of a JavaScript tracer (0 = default, capped to maxTraceTimeout)
+	DiffMode  bool          // Whether the prestate tracer reports the state changes instead
+}
+
+type TraceTransactionArgs struct {
+	Hash    string
+	Options TraceOptions
+}
+
+func (args *TraceTransactionArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return shared.NewDecodeParamError(err.Error())
+	}
+	if len(obj) < 1 {
+		return shared.NewInsufficientParamsError(len(obj), 1)
+	}
+	hash, ok := obj[0].(string)
+	if !ok {
+		return shared.NewInvalidTypeError("hash", "not a string")
+	}
+	args.Hash = hash
+
+	if len(obj) > 1 {
+		return parseTraceOptions(obj[1], &args.Options)
+	}
+	return nil
+}
+
+type TraceBlockByNumberArgs struct {
+	BlockNumber int64
+	Options     TraceOptions
+}
+
+func (args *TraceBlockByNumberArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return shared.NewDecodeParamError(err.Error())
+	}
+	if len(obj) < 1 {
+		return shared.NewInsufficientParamsError(len(obj), 1)
+	}
+	if err := blockHeight(obj[0], &args.BlockNumber); err != nil {
+		return err
+	}
+	if len(obj) > 1 {
+		return parseTraceOptions(obj[1], &args.Options)
+	}
+	return nil
+}
+
+type TraceBlockByHashArgs struct {
+	BlockHash string
+	Options   TraceOptions
+}
+
+func (args *TraceBlockByHashArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return shared.NewDecodeParamError(err.Error())
+	}
+	if len(obj) < 1 {
+		return shared.NewInsufficientParamsError(len(obj), 1)
+	}
+	hash, ok := obj[0].(string)
+	if !ok {
+		return shared.NewInvalidTypeError("blockHash", "not a string")
+	}
+	args.BlockHash = hash
+
+	if len(obj) > 1 {
+		return parseTraceOptions(obj[1], &args.Options)
+	}
+	return nil
+}
+
+// parseTraceOptions parses the optional options object of the tracing requests.
+func parseTraceOptions(raw interface{}, options *TraceOptions) error {
+	if raw == nil {
+		return nil
+	}
+	opts, ok := raw.(map[string]interface{})
+	if !ok {
+		return shared.NewInvalidTypeError("options", "not an object")
+	}
+	flags := map[string]*bool{
+		"disableMemory":  &options.LogConfig.DisableMemory,
+		"disableStack":   &options.LogConfig.DisableStack,
+		"disableStorage": &options.LogConfig.DisableStorage,
+		"diffMode":       &options.DiffMode,
+	}
+	for name, value := range opts {
+		switch name {
+		case "tracer":
+			if options.Tracer, ok = value.(string); !ok {
+				return shared.NewInvalidTypeError(name, "not a string")
+			}
+			continue
+		case "timeout":
+			timeout, ok := value.(string)
+			if !ok {
+				return shared.NewInvalidTypeError(name, "not a string")
+			}
+			var err error
+			if options.Timeout, err = time.ParseDuration(timeout); err != nil {
+				return shared.NewValidationError(name, err.Error())
+			}
+			if options.Timeout <= 0 {
+				return shared.NewValidationError(name, "must be positive")
+			}
+			if options.Timeout > maxTraceTimeout {
+				options.Timeout = maxTraceTimeout
+			}
+			continue
+		}
+		flag, ok := flags[name]
+		if !ok {
+			return shared.NewValidationError(name, "unknown trace option")
+		}
+		if *flag, ok = value.(bool); !ok {
+			return shared.NewInvalidTypeError(name, "not a bool")
+		}
+	}
+	return nil
+}
@@ -17,6 +17,10 @@
 package api
 
 import (
+	"fmt"
+
+	"github.com/vector/go-vector/common"
+	"github.com/vector/go-vector/core/types"
 	"github.com/vector/go-vector/vec"
 	"github.com/vector/go-vector/rpc/codec"
 	"github.com/vector/go-vector/rpc/shared"
@@ -30,7 +34,9 @@ const (
 var (
 	// mapping between methods and handlers
 	txpoolMapping = map[string]txpoolhandler{
-		"txpool_status": (*txPoolApi).Status,
+		"txpool_status":  (*txPoolApi).Status,
+		"txpool_content": (*txPoolApi).Content,
+		"txpool_inspect": (*txPoolApi).Inspect,
 	}
 )
 
@@ -84,9 +90,64 @@ func (self *txPoolApi) ApiVersion() string {
 }
 
 func (self *txPoolApi) Status(req *shared.Request) (interface{}, error) {
-	pending, queue := self.vector.TxPool().Stats()
+	pool := self.vector.TxPool()
+	pending, queue := pool.Stats()
+	limits := pool.Config()
 	return map[string]int{
-		"pending": pending,
-		"queued":  queue,
+		"pending":      pending,
+		"queued":       queue,
+		"accountSlots": int(limits.AccountSlots),
+		"globalSlots":  int(limits.GlobalSlots),
+		"accountQueue": int(limits.AccountQueue),
+		"globalQueue":  int(limits.GlobalQueue),
+		"priceBump":    int(limits.PriceBump),
+	}, nil
+}
+
+// Content returns the pending and queued transactions of the pool, grouped by
+// sender address and nonce.
+func (self *txPoolApi) Content(req *shared.Request) (interface{}, error) {
+	pending, queued := self.vector.TxPool().Content()
+
+	format := func(accounts map[common.Address]types.Transactions) map[string]map[string]*TransactionRes {
+		content := make(map[string]map[string]*TransactionRes)
+		for addr, txs := range accounts {
+			dump := make(map[string]*TransactionRes)
+			for _, tx := range txs {
+				dump[fmt.Sprintf("%d", tx.Nonce())] = NewTransactionRes(tx)
+			}
+			content[addr.Hex()] = dump
+		}
+		return content
+	}
+	return map[string]map[string]map[string]*TransactionRes{
+		"pending": format(pending),
+		"queued":  format(queued),
+	}, nil
+}
+
+// Inspect returns a textual summary of the pending and queued transactions of
+// the pool, grouped by sender address and nonce, for quickly spotting stuck ones.
+func (self *txPoolApi) Inspect(req *shared.Request) (interface{}, error) {
+	pending, queued := self.vector.TxPool().Content()
+
+	format := func(accounts map[common.Address]types.Transactions) map[string]map[string]string {
+		content := make(map[string]map[string]string)
+		for addr, txs := range accounts {
+			dump := make(map[string]string)
+			for _, tx := range txs {
+				to := "contract creation"
+				if tx.To() != nil {
+					to = tx.To().Hex()
+				}
+				dump[fmt.Sprintf("%d", tx.Nonce())] = fmt.Sprintf("%s: %v wei + %v gas × %v wei", to, tx.Value(), tx.Gas(), tx.GasPrice())
+			}
+			content[addr.Hex()] = dump
+		}
+		return content
+	}
+	return map[string]map[string]map[string]string{
+		"pending": format(pending),
+		"queued":  format(queued),
 	}, nil
 }
@@ -27,6 +27,14 @@ web3._extend({
 		new web3._extend.Property({
 			name: 'status',
 			getter: 'txpool_status'
+		}),
+		new web3._extend.Property({
+			name: 'content',
+			getter: 'txpool_content'
+		}),
+		new web3._extend.Property({
+			name: 'inspect',
+			getter: 'txpool_inspect'
 		})
 	]
 });
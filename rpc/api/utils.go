@@ -63,12 +63,16 @@ var (
 		},
 		"debug": []string{
 			"dumpBlock",
+			"getBadBlocks",
 			"getBlockRlp",
 			"metrics",
 			"printBlock",
 			"processBlock",
 			"seedHash",
 			"setHead",
+			"traceBlockByHash",
+			"traceBlockByNumber",
+			"traceTransaction",
 		},
 		"vec": []string{
 			"accounts",
@@ -96,6 +100,7 @@ var (
 			"getTransaction",
 			"getTransactionCount",
 			"getTransactionFromBlock",
+			"getTransactionsByAddress",
 			"getTransactionReceipt",
 			"getUncle",
 			"hashrate",
@@ -137,6 +142,8 @@ var (
 			"filter",
 		},
 		"txpool": []string{
+			"content",
+			"inspect",
 			"status",
 		},
 		"web3": []string{
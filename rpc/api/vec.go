@@ -82,6 +82,7 @@ var (
 		"eth_getBlockByHash":                      (*vecApi).GetBlockByHash,
 		"eth_getBlockByNumber":                    (*vecApi).GetBlockByNumber,
 		"eth_getTransactionByHash":                (*vecApi).GetTransactionByHash,
+		"eth_getTransactionsByAddress":            (*vecApi).GetTransactionsByAddress,
 		"eth_getTransactionByBlockNumberAndIndex": (*vecApi).GetTransactionByBlockNumberAndIndex,
 		"eth_getTransactionByBlockHashAndIndex":   (*vecApi).GetTransactionByBlockHashAndIndex,
 		"eth_getUncleByBlockHashAndIndex":         (*vecApi).GetUncleByBlockHashAndIndex,
@@ -464,6 +465,28 @@ func (self *vecApi) GetTransactionByHash(req *shared.Request) (interface{}, erro
 	return nil, nil
 }
 
+func (self *vecApi) GetTransactionsByAddress(req *shared.Request) (interface{}, error) {
+	args := new(AddrTxArgs)
+	if err := self.codec.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+
+	hashes := self.xvec.EthTransactionsByAddress(args.Address, args.Offset, args.Count)
+	txs := make([]*TransactionRes, 0, len(hashes))
+	for _, hash := range hashes {
+		tx, bhash, bnum, txi := self.xvec.EthTransactionByHash(hash.Hex())
+		if tx == nil || (bhash == common.Hash{}) {
+			continue
+		}
+		v := NewTransactionRes(tx)
+		v.BlockHash = newHexData(bhash)
+		v.BlockNumber = newHexNum(bnum)
+		v.TxIndex = newHexNum(txi)
+		txs = append(txs, v)
+	}
+	return txs, nil
+}
+
 func (self *vecApi) GetTransactionByBlockHashAndIndex(req *shared.Request) (interface{}, error) {
 	args := new(HashIndexArgs)
 	if err := self.codec.Decode(req.Params, &args); err != nil {
@@ -657,17 +680,12 @@ func (self *vecApi) Resend(req *shared.Request) (interface{}, error) {
 		return nil, shared.NewDecodeParamError(err.Error())
 	}
 
-	from := common.HexToAddress(args.Tx.From)
-
-	pending := self.vector.TxPool().GetTransactions()
-	for _, p := range pending {
-		if pFrom, err := p.FromFrontier(); err == nil && pFrom == from && p.SigHash() == args.Tx.tx.SigHash() {
-			self.vector.TxPool().RemoveTx(common.HexToHash(args.Tx.Hash))
-			return self.xvec.Transact(args.Tx.From, args.Tx.To, args.Tx.Nonce, args.Tx.Value, args.GasLimit, args.GasPrice, args.Tx.Data)
-		}
+	// Resubmit the transaction with the same nonce, the pool replaces the
+	// original one if the new gas price is sufficiently higher
+	if self.vector.TxPool().GetTransaction(common.HexToHash(args.Tx.Hash)) == nil {
+		return nil, fmt.Errorf("Transaction %s not found", args.Tx.Hash)
 	}
-
-	return nil, fmt.Errorf("Transaction %s not found", args.Tx.Hash)
+	return self.xvec.Transact(args.Tx.From, args.Tx.To, args.Tx.Nonce, args.Tx.Value, args.GasLimit, args.GasPrice, args.Tx.Data)
 }
 
 func (self *vecApi) PendingTransactions(req *shared.Request) (interface{}, error) {
@@ -66,6 +66,59 @@ func (args *GetBalanceArgs) UnmarshalJSON(b []byte) (err error) {
 	return nil
 }
 
+// maxAddrTxCount is the maximum number of transactions returned by a single
+// eth_getTransactionsByAddress call.
+const maxAddrTxCount = 1000
+
+type AddrTxArgs struct {
+	Address string
+	Offset  int
+	Count   int
+}
+
+func (args *AddrTxArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return shared.NewDecodeParamError(err.Error())
+	}
+
+	if len(obj) < 1 {
+		return shared.NewInsufficientParamsError(len(obj), 1)
+	}
+
+	addstr, ok := obj[0].(string)
+	if !ok {
+		return shared.NewInvalidTypeError("address", "not a string")
+	}
+	args.Address = addstr
+
+	args.Offset = 0
+	if len(obj) > 1 {
+		num, err := numString(obj[1])
+		if err != nil {
+			return shared.NewInvalidTypeError("offset", "not a number or string")
+		}
+		if num.Sign() < 0 {
+			return shared.NewValidationError("offset", "must not be negative")
+		}
+		args.Offset = int(num.Int64())
+	}
+
+	args.Count = maxAddrTxCount
+	if len(obj) > 2 {
+		num, err := numString(obj[2])
+		if err != nil {
+			return shared.NewInvalidTypeError("count", "not a number or string")
+		}
+		if num.Sign() <= 0 || num.Cmp(big.NewInt(maxAddrTxCount)) > 0 {
+			return shared.NewValidationError("count", fmt.Sprintf("must be between 1 and %d", maxAddrTxCount))
+		}
+		args.Count = int(num.Int64())
+	}
+
+	return nil
+}
+
 type GetStorageArgs struct {
 	Address     string
 	BlockNumber int64
@@ -843,6 +896,7 @@ type LogRes struct {
 	BlockHash        *hexdata   `json:"blockHash"`
 	TransactionHash  *hexdata   `json:"transactionHash"`
 	TransactionIndex *hexnum    `json:"transactionIndex"`
+	Removed          bool       `json:"removed"`
 }
 
 func NewLogRes(log *vm.Log) LogRes {
@@ -858,6 +912,7 @@ func NewLogRes(log *vm.Log) LogRes {
 	l.TransactionHash = newHexData(log.TxHash)
 	l.TransactionIndex = newHexNum(log.TxIndex)
 	l.BlockHash = newHexData(log.BlockHash)
+	l.Removed = log.Removed
 
 	return l
 }
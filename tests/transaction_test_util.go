@@ -27,7 +27,6 @@ import (
 	"github.com/vector/go-vector/common"
 	"github.com/vector/go-vector/core/types"
 	"github.com/vector/go-vector/logger/glog"
-	"github.com/vector/go-vector/params"
 	"github.com/vector/go-vector/rlp"
 )
 
@@ -51,6 +50,10 @@ type TransactionTest struct {
 	Transaction TtTransaction
 }
 
+// transactionTestRuleSet is the fork schedule the transaction tests were
+// generated with.
+var transactionTestRuleSet = RuleSet{HomesteadBlock: big.NewInt(900000)}
+
 func RunTransactionTestsWithReader(r io.Reader, skipTests []string) error {
 	skipTest := make(map[string]bool, len(skipTests))
 	for _, name := range skipTests {
@@ -69,7 +72,7 @@ func RunTransactionTestsWithReader(r io.Reader, skipTests []string) error {
 			return nil
 		}
 		// test the block
-		if err := runTransactionTest(test); err != nil {
+		if err := runTransactionTest(transactionTestRuleSet, test); err != nil {
 			return err
 		}
 		glog.Infoln("Transaction test passed: ", name)
@@ -91,8 +94,6 @@ func RunTransactionTests(file string, skipTests []string) error {
 }
 
 func runTransactionTests(tests map[string]TransactionTest, skipTests []string) error {
-	params.HomesteadBlock = big.NewInt(900000)
-
 	skipTest := make(map[string]bool, len(skipTests))
 	for _, name := range skipTests {
 		skipTest[name] = true
@@ -106,7 +107,7 @@ func runTransactionTests(tests map[string]TransactionTest, skipTests []string) e
 		}
 
 		// test the block
-		if err := runTransactionTest(test); err != nil {
+		if err := runTransactionTest(transactionTestRuleSet, test); err != nil {
 			return fmt.Errorf("%s: %v", name, err)
 		}
 		glog.Infoln("Transaction test passed: ", name)
@@ -115,7 +116,7 @@ func runTransactionTests(tests map[string]TransactionTest, skipTests []string) e
 	return nil
 }
 
-func runTransactionTest(txTest TransactionTest) (err error) {
+func runTransactionTest(ruleSet RuleSet, txTest TransactionTest) (err error) {
 	tx := new(types.Transaction)
 	err = rlp.DecodeBytes(mustConvertBytes(txTest.Rlp), tx)
 
@@ -129,7 +130,7 @@ func runTransactionTest(txTest TransactionTest) (err error) {
 		}
 	}
 
-	validationError := verifyTxFields(txTest, tx)
+	validationError := verifyTxFields(ruleSet, txTest, tx)
 	if txTest.Sender == "" {
 		if validationError != nil {
 			// RLP decoding works but validation should fail (test OK)
@@ -153,7 +154,7 @@ func runTransactionTest(txTest TransactionTest) (err error) {
 	return errors.New("Should not happen: verify RLP decoding and field validation")
 }
 
-func verifyTxFields(txTest TransactionTest, decodedTx *types.Transaction) (err error) {
+func verifyTxFields(ruleSet RuleSet, txTest TransactionTest, decodedTx *types.Transaction) (err error) {
 	defer func() {
 		if recovered := recover(); recovered != nil {
 			buf := make([]byte, 64<<10)
@@ -166,7 +167,7 @@ func verifyTxFields(txTest TransactionTest, decodedTx *types.Transaction) (err e
 		decodedSender common.Address
 	)
 
-	if params.IsHomestead(common.String2Big(txTest.Blocknumber)) {
+	if ruleSet.IsHomestead(common.String2Big(txTest.Blocknumber)) {
 		decodedSender, err = decodedTx.From()
 	} else {
 		decodedSender, err = decodedTx.FromFrontier()
@@ -124,7 +124,23 @@ type VmTest struct {
 	PostStateRoot string
 }
 
+// RuleSet implements vm.RuleSet and will act as the chain configuration of the
+// tests, with the fork schedule under which they were generated.
+type RuleSet struct {
+	HomesteadBlock  *big.Int
+	MetropolisBlock *big.Int // nil = no fork
+}
+
+func (r RuleSet) IsHomestead(n *big.Int) bool {
+	return n.Cmp(r.HomesteadBlock) >= 0
+}
+
+func (r RuleSet) IsMetropolis(n *big.Int) bool {
+	return r.MetropolisBlock != nil && n.Cmp(r.MetropolisBlock) >= 0
+}
+
 type Env struct {
+	ruleSet      RuleSet
 	depth        int
 	state        *state.StateDB
 	skipTransfer bool
@@ -140,27 +156,24 @@ type Env struct {
 	difficulty *big.Int
 	gasLimit   *big.Int
 
-	logs []vm.StructLog
+	tracer vm.Tracer
 
 	vmTest bool
 }
 
-func NewEnv(state *state.StateDB) *Env {
+func NewEnv(ruleSet RuleSet, state *state.StateDB) *Env {
 	return &Env{
-		state: state,
+		ruleSet: ruleSet,
+		state:   state,
 	}
 }
 
-func (self *Env) StructLogs() []vm.StructLog {
-	return self.logs
-}
-
-func (self *Env) AddStructLog(log vm.StructLog) {
-	self.logs = append(self.logs, log)
+func (self *Env) Tracer() vm.Tracer {
+	return self.tracer
 }
 
-func NewEnvFromMap(state *state.StateDB, envValues map[string]string, exeValues map[string]string) *Env {
-	env := NewEnv(state)
+func NewEnvFromMap(ruleSet RuleSet, state *state.StateDB, envValues map[string]string, exeValues map[string]string) *Env {
+	env := NewEnv(ruleSet, state)
 
 	env.origin = common.HexToAddress(exeValues["caller"])
 	env.parent = common.HexToHash(envValues["previousHash"])
@@ -174,6 +187,7 @@ func NewEnvFromMap(state *state.StateDB, envValues map[string]string, exeValues
 	return env
 }
 
+func (self *Env) RuleSet() vm.RuleSet      { return self.ruleSet }
 func (self *Env) Origin() common.Address   { return self.origin }
 func (self *Env) BlockNumber() *big.Int    { return self.number }
 func (self *Env) Coinbase() common.Address { return self.coinbase }
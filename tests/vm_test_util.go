@@ -29,6 +29,7 @@ import (
 	"github.com/vector/go-vector/core/vm"
 	"github.com/vector/go-vector/vecdb"
 	"github.com/vector/go-vector/logger/glog"
+	"github.com/vector/go-vector/params"
 )
 
 func RunVmTestWithReader(r io.Reader, skipTests []string) error {
@@ -248,7 +249,7 @@ func RunVm(state *state.StateDB, env, exec map[string]string) ([]byte, vm.Logs,
 
 	caller := state.GetOrNewStateObject(from)
 
-	vmenv := NewEnvFromMap(state, env, exec)
+	vmenv := NewEnvFromMap(RuleSet{HomesteadBlock: params.MainNetHomesteadBlock}, state, env, exec)
 	vmenv.vmTest = true
 	vmenv.skipTransfer = true
 	vmenv.initial = true
@@ -0,0 +1,243 @@
+// Copyright 2016 The go-vector Authors
+// This file is part of the go-vector library.
+//
+// The go-vector library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vector library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vector library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"sync"
+
+	"github.com/vector/go-vector/common"
+	"github.com/vector/go-vector/vecdb"
+)
+
+// LeafCallback is called for every leaf value of the nodes inserted into a
+// NodeCache, returning the roots of any other tries the leaf references (e.g.
+// the storage trie of an account), which are kept alive along with the node.
+type LeafCallback func(leaf []byte) []common.Hash
+
+// NodeCache is a reference counted, in-memory write cache for trie nodes atop a
+// persistent database. Nodes inserted through it stay in memory until they are
+// either flushed to disk as part of a committed trie, or discarded when no trie
+// references them any more, so short lived state never reaches the database.
+//
+// All other database operations are passed through to the persistent store.
+// Reads check the cached nodes first. NodeCache is safe for concurrent use.
+type NodeCache struct {
+	vecdb.Database
+
+	onleaf LeafCallback
+	nodes  map[common.Hash]*cachedNode
+	size   common.StorageSize // Storage size of the cached nodes
+	lock   sync.RWMutex
+}
+
+// cachedNode is a trie node held in memory along with the cached children it
+// references. Children are tracked by object, not hash, so that a node flushed
+// and later reinserted is never released by a stale parent.
+type cachedNode struct {
+	hash     common.Hash
+	blob     []byte
+	refs     int           // Number of cached parents and external references
+	children []*cachedNode // Children cached at insertion time
+	flushed  bool          // Whether the node was persisted and left the cache
+}
+
+// NewNodeCache creates a trie node cache atop db. The optional onleaf callback
+// is used to track references from leaf values to other tries.
+func NewNodeCache(db vecdb.Database, onleaf LeafCallback) *NodeCache {
+	return &NodeCache{
+		Database: db,
+		onleaf:   onleaf,
+		nodes:    make(map[common.Hash]*cachedNode),
+	}
+}
+
+// Get retrieves a node from the cache, or any key from the persistent store.
+func (c *NodeCache) Get(key []byte) ([]byte, error) {
+	if len(key) == hashLen {
+		c.lock.RLock()
+		node := c.nodes[common.BytesToHash(key)]
+		c.lock.RUnlock()
+
+		if node != nil {
+			return node.blob, nil
+		}
+	}
+	return c.Database.Get(key)
+}
+
+// Nodes returns a database writer inserting trie nodes into the cache instead
+// of the persistent store. It is meant to be passed to Trie.CommitTo.
+func (c *NodeCache) Nodes() DatabaseWriter {
+	return nodeCacheWriter{c}
+}
+
+// nodeCacheWriter adapts the cache's node insertion to DatabaseWriter.
+type nodeCacheWriter struct {
+	cache *NodeCache
+}
+
+func (w nodeCacheWriter) Put(key, value []byte) error {
+	w.cache.insert(common.BytesToHash(key), value)
+	return nil
+}
+
+// insert adds a trie node to the cache, referencing all its cached children.
+// Nodes already present are left untouched.
+func (c *NodeCache) insert(hash common.Hash, blob []byte) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if _, ok := c.nodes[hash]; ok {
+		return
+	}
+	node := &cachedNode{hash: hash, blob: common.CopyBytes(blob)}
+	if n, err := decodeNode(blob); err == nil {
+		for _, child := range c.references(n, nil) {
+			if cached := c.nodes[child]; cached != nil {
+				cached.refs++
+				node.children = append(node.children, cached)
+			}
+		}
+	}
+	c.nodes[hash] = node
+	c.size += common.StorageSize(hashLen + len(blob))
+}
+
+// references gathers the hashes of all the nodes and tries referenced by n.
+func (c *NodeCache) references(n node, hashes []common.Hash) []common.Hash {
+	switch n := n.(type) {
+	case shortNode:
+		return c.references(n.Val, hashes)
+	case fullNode:
+		for _, child := range n {
+			hashes = c.references(child, hashes)
+		}
+	case hashNode:
+		hashes = append(hashes, common.BytesToHash(n))
+	case valueNode:
+		if c.onleaf != nil {
+			hashes = append(hashes, c.onleaf(n)...)
+		}
+	}
+	return hashes
+}
+
+// Reference marks a cached trie root as being in use, preventing it and all its
+// cached descendants from being discarded.
+func (c *NodeCache) Reference(root common.Hash) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if node := c.nodes[root]; node != nil {
+		node.refs++
+	}
+}
+
+// Dereference releases a reference to a cached trie root, discarding all the
+// cached nodes of the trie not referenced by anything else.
+func (c *NodeCache) Dereference(root common.Hash) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if node := c.nodes[root]; node != nil {
+		c.dereference(node)
+	}
+}
+
+// Discard releases a cached trie root that nothing references, e.g. the state
+// of a block that failed to be written, along with all its cached nodes not
+// shared with other tries. Referenced or unknown roots are left untouched.
+func (c *NodeCache) Discard(root common.Hash) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if node := c.nodes[root]; node != nil && node.refs == 0 {
+		c.dereference(node)
+	}
+}
+
+func (c *NodeCache) dereference(node *cachedNode) {
+	if node.refs > 0 {
+		node.refs--
+	}
+	if node.refs > 0 || node.flushed {
+		return
+	}
+	delete(c.nodes, node.hash)
+	c.size -= common.StorageSize(hashLen + len(node.blob))
+
+	for _, child := range node.children {
+		c.dereference(child)
+	}
+}
+
+// Flush writes the trie with the given root, including all its cached nodes, to
+// the persistent store and removes them from the cache.
+func (c *NodeCache) Flush(root common.Hash) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	node := c.nodes[root]
+	if node == nil {
+		return nil
+	}
+	batch := c.Database.NewBatch()
+
+	var flushed []*cachedNode
+	err := c.flush(node, batch, &flushed)
+	if err == nil {
+		err = batch.Write()
+	}
+	if err != nil {
+		for _, node := range flushed {
+			node.flushed = false
+		}
+		return err
+	}
+	// Only drop the nodes from memory once they are safely on disk
+	for _, node := range flushed {
+		delete(c.nodes, node.hash)
+		c.size -= common.StorageSize(hashLen + len(node.blob))
+	}
+	return nil
+}
+
+// flush adds a node and all its not yet flushed descendants to the batch, the
+// children always preceding their parents.
+func (c *NodeCache) flush(node *cachedNode, batch vecdb.Batch, flushed *[]*cachedNode) error {
+	if node.flushed || c.nodes[node.hash] != node {
+		return nil
+	}
+	for _, child := range node.children {
+		if err := c.flush(child, batch, flushed); err != nil {
+			return err
+		}
+	}
+	// Mark the node early to avoid writing shared subtries twice
+	node.flushed = true
+	*flushed = append(*flushed, node)
+
+	return batch.Put(node.hash[:], node.blob)
+}
+
+// Size returns the storage size of the nodes held in the cache.
+func (c *NodeCache) Size() common.StorageSize {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.size
+}
@@ -0,0 +1,173 @@
+// Copyright 2016 The go-vector Authors
+// This file is part of the go-vector library.
+//
+// The go-vector library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vector library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vector library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/vector/go-vector/common"
+	"github.com/vector/go-vector/vecdb"
+)
+
+// makeCachedTries commits a sequence of tries into a node cache, each one
+// derived from the previous by updating a few keys, returning their roots.
+func makeCachedTries(t *testing.T, cache *NodeCache, count int) []common.Hash {
+	trie, _ := New(common.Hash{}, cache)
+	for i := 0; i < 256; i++ {
+		trie.Update([]byte(fmt.Sprintf("key-%d", i)), []byte(fmt.Sprintf("value-%d-%032d", i, 0)))
+	}
+	var roots []common.Hash
+	for i := 0; i < count; i++ {
+		for j := 0; j < 4; j++ {
+			trie.Update([]byte(fmt.Sprintf("key-%d", (i*4+j)%256)), []byte(fmt.Sprintf("value-%d-%032d", j, i+1)))
+		}
+		root, err := trie.CommitTo(cache.Nodes())
+		if err != nil {
+			t.Fatalf("failed to commit trie %d: %v", i, err)
+		}
+		cache.Reference(root)
+		roots = append(roots, root)
+	}
+	return roots
+}
+
+// Tests that nodes committed into the cache stay out of the database, and that
+// releasing tries only discards the nodes not shared with retained ones.
+func TestNodeCacheDereference(t *testing.T) {
+	db, _ := vecdb.NewMemDatabase()
+	cache := NewNodeCache(db, nil)
+
+	roots := makeCachedTries(t, cache, 8)
+	if len(db.Keys()) != 0 {
+		t.Fatalf("database written before flush: %d entries", len(db.Keys()))
+	}
+	for _, root := range roots[:7] {
+		cache.Dereference(root)
+	}
+	for i, root := range roots {
+		if _, err := New(root, cache); (err == nil) != (i == 7) {
+			t.Errorf("trie %d: availability mismatch: have %v, want %v", i, err == nil, i == 7)
+		}
+	}
+	// The retained trie must still be fully readable
+	trie, _ := New(roots[7], cache)
+	for i := 0; i < 256; i++ {
+		if value := trie.Get([]byte(fmt.Sprintf("key-%d", i))); len(value) == 0 {
+			t.Errorf("retained trie: key %d missing", i)
+		}
+	}
+	cache.Dereference(roots[7])
+	if size := cache.Size(); size != 0 {
+		t.Errorf("cache not empty after releasing all tries: %v", size)
+	}
+}
+
+// Tests that flushing a trie persists all its nodes, leaving the other cached
+// tries intact.
+func TestNodeCacheFlush(t *testing.T) {
+	db, _ := vecdb.NewMemDatabase()
+	cache := NewNodeCache(db, nil)
+
+	roots := makeCachedTries(t, cache, 8)
+	if err := cache.Flush(roots[3]); err != nil {
+		t.Fatalf("failed to flush trie: %v", err)
+	}
+	for _, root := range roots {
+		cache.Dereference(root)
+	}
+	if size := cache.Size(); size != 0 {
+		t.Errorf("cache not empty after releasing all tries: %v", size)
+	}
+	// Only the flushed trie must be available, entirely from the database
+	for i, root := range roots {
+		trie, err := New(root, db)
+		if (err == nil) != (i == 3) {
+			t.Errorf("trie %d: availability mismatch: have %v, want %v", i, err == nil, i == 3)
+			continue
+		}
+		if err == nil {
+			for j := 0; j < 256; j++ {
+				if value := trie.Get([]byte(fmt.Sprintf("key-%d", j))); len(value) == 0 {
+					t.Errorf("trie %d: key %d missing", i, j)
+				}
+			}
+		}
+	}
+}
+
+// Tests that tries referenced from leaves are kept alive along with them.
+func TestNodeCacheLeafReferences(t *testing.T) {
+	db, _ := vecdb.NewMemDatabase()
+
+	var sub common.Hash
+	cache := NewNodeCache(db, func(leaf []byte) []common.Hash {
+		if string(leaf) == "subtrie" {
+			return []common.Hash{sub}
+		}
+		return nil
+	})
+	subtrie, _ := New(common.Hash{}, cache)
+	for i := 0; i < 16; i++ {
+		subtrie.Update([]byte(fmt.Sprintf("sub-%d", i)), []byte(fmt.Sprintf("%032d", i)))
+	}
+	sub, _ = subtrie.CommitTo(cache.Nodes())
+
+	trie, _ := New(common.Hash{}, cache)
+	trie.Update([]byte("account"), []byte("subtrie"))
+	root, _ := trie.CommitTo(cache.Nodes())
+	cache.Reference(root)
+
+	if _, err := New(sub, cache); err != nil {
+		t.Fatalf("referenced subtrie discarded: %v", err)
+	}
+	if err := cache.Flush(root); err != nil {
+		t.Fatalf("failed to flush trie: %v", err)
+	}
+	if _, err := New(sub, db); err != nil {
+		t.Fatalf("referenced subtrie not flushed: %v", err)
+	}
+}
+
+// Tests that discarding a trie nobody references releases its own nodes, while
+// referenced tries are left untouched.
+func TestNodeCacheDiscard(t *testing.T) {
+	db, _ := vecdb.NewMemDatabase()
+	cache := NewNodeCache(db, nil)
+
+	roots := makeCachedTries(t, cache, 1)
+	size := cache.Size()
+
+	// Commit a derived trie without referencing it, as a failed block import would
+	trie, _ := New(roots[0], cache)
+	trie.Update([]byte("key-0"), []byte("discarded"))
+	root, err := trie.CommitTo(cache.Nodes())
+	if err != nil {
+		t.Fatalf("failed to commit trie: %v", err)
+	}
+	if cache.Size() == size {
+		t.Fatalf("derived trie not cached")
+	}
+	cache.Discard(roots[0])
+	cache.Discard(root)
+	if have := cache.Size(); have != size {
+		t.Errorf("cache size mismatch after discard: have %v, want %v", have, size)
+	}
+	if _, err := New(roots[0], cache); err != nil {
+		t.Errorf("referenced trie discarded: %v", err)
+	}
+}
@@ -90,13 +90,19 @@ type Config struct {
 	Name         string
 	NetworkId    int
 	GenesisFile  string
-	GenesisBlock *types.Block // used by block tests
+	GenesisBlock *types.Block      // used by block tests
+	ChainConfig  *core.ChainConfig // chain configuration (nil = stored with the genesis or network default)
 	FastSync     bool
 	Olympic      bool
 
 	BlockChainVersion  int
 	SkipBcVersionCheck bool // e.g. blockchain export
 	DatabaseCache      int
+	AncientDepth       uint64 // Blocks below the head to keep out of the ancient store (0 = disabled)
+	Pruning            bool   // Whether to keep only the recent states, discarding stale ones (opt-in)
+	AddrTxIndex        bool   // Whether to index canonical transactions by sender and recipient
+
+	TxPool core.TxPoolConfig // Capacity limits of the transaction pool
 
 	DataDir   string
 	LogFile   string
@@ -270,7 +276,7 @@ func New(config *Config) (*Vector, error) {
 	logger.New(config.DataDir, config.LogFile, config.Verbosity)
 
 	// Let the database take 3/4 of the max open files (TODO figure out a way to get the actual limit of the open files)
-	const dbCount = 3
+	const dbCount = 2
 	vecdb.OpenFileLimit = 128 / (dbCount + 1)
 
 	newdb := config.NewDB
@@ -289,22 +295,25 @@ func New(config *Config) (*Vector, error) {
 	if db, ok := chainDb.(*vecdb.LDBDatabase); ok {
 		db.Meter("vec/db/chaindata/")
 	}
+	if chainDb, err = core.OpenAncientDatabase(chainDb, filepath.Join(config.DataDir, "ancient"), config.AncientDepth); err != nil {
+		return nil, fmt.Errorf("ancient store err: %v", err)
+	}
 	if err := upgradeChainDatabase(chainDb); err != nil {
 		return nil, err
 	}
 	if err := addMipmapBloomBins(chainDb); err != nil {
 		return nil, err
 	}
-
-	dappDb, err := newdb(filepath.Join(config.DataDir, "dapp"))
-	if err != nil {
-		if errno, ok := err.(syscall.Errno); ok && datadirInUseErrnos[uint(errno)] {
-			err = fmt.Errorf("%v (check if another instance of gvec is already running with the same data directory '%s')", err, config.DataDir)
-		}
-		return nil, fmt.Errorf("dapp db err: %v", err)
+	// Keep recent state tries in memory if pruning, discarding the stale ones
+	if config.Pruning {
+		chainDb = state.NewNodeCache(chainDb)
 	}
-	if db, ok := dappDb.(*vecdb.LDBDatabase); ok {
-		db.Meter("vec/db/dapp/")
+
+	// The dapp database lives as a separate table within the chain database,
+	// sharing its keyspace with the unprefixed chain data
+	dappDb := vecdb.NewTable(chainDb, core.DappTablePrefix)
+	if err := migrateDappDatabase(filepath.Join(config.DataDir, "dapp"), dappDb); err != nil {
+		return nil, fmt.Errorf("dapp db migration err: %v", err)
 	}
 
 	nodeDb := filepath.Join(config.DataDir, "nodes")
@@ -391,15 +400,33 @@ func New(config *Config) (*Vector, error) {
 	} else {
 		vec.pow = vecash.New()
 	}
+	// Resolve the fork rules of the chain, preferring any stored with the genesis
+	chainConfig := config.ChainConfig
+	if chainConfig == nil {
+		chainConfig = core.MainNetChainConfig
+		if config.TestNet {
+			chainConfig = core.TestNetChainConfig
+		}
+		if chainConfig, err = core.LoadChainConfig(chainDb, chainConfig); err != nil {
+			return nil, err
+		}
+	}
 	//genesis := core.GenesisBlock(uint64(config.GenesisNonce), stateDb)
-	vec.blockchain, err = core.NewBlockChain(chainDb, vec.pow, vec.EventMux())
+	vec.blockchain, err = core.NewBlockChain(chainDb, chainConfig, vec.pow, vec.EventMux())
 	if err != nil {
 		if err == core.ErrNoGenesis {
 			return nil, fmt.Errorf(`Genesis block not found. Please supply a genesis block with the "--genesis /path/to/file" argument`)
 		}
 		return nil, err
 	}
-	newPool := core.NewTxPool(vec.EventMux(), vec.blockchain.State, vec.blockchain.GasLimit)
+	vec.blockchain.SetAddrTxIndexing(config.AddrTxIndex)
+
+	// Keep the journal of local transactions in the data directory
+	poolConfig := config.TxPool
+	if poolConfig.Journal != "" && !filepath.IsAbs(poolConfig.Journal) {
+		poolConfig.Journal = filepath.Join(config.DataDir, poolConfig.Journal)
+	}
+	newPool := core.NewTxPool(chainConfig, poolConfig, vec.EventMux(), chainDb, vec.blockchain.State, vec.blockchain.GasLimit)
 	vec.txPool = newPool
 
 	if vec.protocolManager, err = NewProtocolManager(config.FastSync, config.NetworkId, vec.eventMux, vec.txPool, vec.pow, vec.blockchain, chainDb); err != nil {
@@ -554,8 +581,8 @@ func (s *Vector) Stop() {
 	}
 	s.StopAutoDAG()
 
-	s.chainDb.Close()
 	s.dappDb.Close()
+	s.chainDb.Close()
 	close(s.shutdownChan)
 }
 
@@ -681,49 +708,86 @@ func upgradeChainDatabase(db vecdb.Database) error {
 	// At least some of the database is still the old format, upgrade (skip the head block!)
 	glog.V(logger.Info).Info("Old database detected, upgrading...")
 
-	if db, ok := db.(*vecdb.LDBDatabase); ok {
-		blockPrefix := []byte("block-hash-")
-		for it := db.NewIterator(); it.Next(); {
-			// Skip anything other than a combined block
-			if !bytes.HasPrefix(it.Key(), blockPrefix) {
-				continue
-			}
-			// Skip the head block (merge last to signal upgrade completion)
-			if bytes.HasSuffix(it.Key(), head.Bytes()) {
-				continue
-			}
-			// Load the block, split and serialize (order!)
-			block := core.GetBlockByHashOld(db, common.BytesToHash(bytes.TrimPrefix(it.Key(), blockPrefix)))
+	blockPrefix := []byte("block-hash-")
+	it := db.NewIterator(blockPrefix, nil)
+	defer it.Release()
 
-			if err := core.WriteTd(db, block.Hash(), block.DeprecatedTd()); err != nil {
-				return err
-			}
-			if err := core.WriteBody(db, block.Hash(), &types.Body{block.Transactions(), block.Uncles()}); err != nil {
-				return err
-			}
-			if err := core.WriteHeader(db, block.Header()); err != nil {
-				return err
-			}
-			if err := db.Delete(it.Key()); err != nil {
-				return err
-			}
+	for it.Next() {
+		// Skip the head block (merge last to signal upgrade completion)
+		if bytes.HasSuffix(it.Key(), head.Bytes()) {
+			continue
 		}
-		// Lastly, upgrade the head block, disabling the upgrade mechanism
-		current := core.GetBlockByHashOld(db, head)
+		// Load the block, split and serialize (order!)
+		block := core.GetBlockByHashOld(db, common.BytesToHash(bytes.TrimPrefix(it.Key(), blockPrefix)))
 
-		if err := core.WriteTd(db, current.Hash(), current.DeprecatedTd()); err != nil {
+		if err := core.WriteTd(db, block.Hash(), block.DeprecatedTd()); err != nil {
 			return err
 		}
-		if err := core.WriteBody(db, current.Hash(), &types.Body{current.Transactions(), current.Uncles()}); err != nil {
+		if err := core.WriteBody(db, block.Hash(), &types.Body{block.Transactions(), block.Uncles()}); err != nil {
 			return err
 		}
-		if err := core.WriteHeader(db, current.Header()); err != nil {
+		if err := core.WriteHeader(db, block.Header()); err != nil {
 			return err
 		}
+		if err := db.Delete(it.Key()); err != nil {
+			return err
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	// Lastly, upgrade the head block, disabling the upgrade mechanism
+	current := core.GetBlockByHashOld(db, head)
+
+	if err := core.WriteTd(db, current.Hash(), current.DeprecatedTd()); err != nil {
+		return err
+	}
+	if err := core.WriteBody(db, current.Hash(), &types.Body{current.Transactions(), current.Uncles()}); err != nil {
+		return err
+	}
+	if err := core.WriteHeader(db, current.Header()); err != nil {
+		return err
 	}
 	return nil
 }
 
+// migrateDappDatabase moves the content of a standalone dapp database left over
+// by previous versions into its table within the chain database, removing the
+// old database afterwards.
+func migrateDappDatabase(path string, dappDb vecdb.Database) error {
+	// Short circuit if there's no legacy dapp database to migrate
+	if !common.FileExist(path) {
+		return nil
+	}
+	glog.V(logger.Info).Infof("Legacy dapp database detected, migrating %s...", path)
+
+	db, err := vecdb.NewLDBDatabase(path, 0)
+	if err != nil {
+		return err
+	}
+	batch := dappDb.NewBatch()
+
+	it := db.NewIterator(nil, nil)
+	for it.Next() {
+		if err := batch.Put(it.Key(), it.Value()); err != nil {
+			it.Release()
+			db.Close()
+			return err
+		}
+	}
+	it.Release()
+	err = it.Error()
+	db.Close()
+
+	if err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	return os.RemoveAll(path)
+}
+
 func addMipmapBloomBins(db vecdb.Database) (err error) {
 	const mipmapVersion uint = 2
 
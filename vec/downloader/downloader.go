@@ -362,7 +362,7 @@ func (d *Downloader) syncWithPeer(p *peer, hash common.Hash, td *big.Int) (err e
 		if d.syncInitHook != nil {
 			d.syncInitHook(origin, latest)
 		}
-		return d.spawnSync(
+		return d.spawnSync(p.id,
 			func() error { return d.fetchHashes61(p, td, origin+1) },
 			func() error { return d.fetchBlocks61(origin + 1) },
 		)
@@ -412,7 +412,7 @@ func (d *Downloader) syncWithPeer(p *peer, hash common.Hash, td *big.Int) (err e
 		if d.syncInitHook != nil {
 			d.syncInitHook(origin, latest)
 		}
-		return d.spawnSync(
+		return d.spawnSync(p.id,
 			func() error { return d.fetchHeaders(p, td, origin+1) }, // Headers are always retrieved
 			func() error { return d.fetchBodies(origin + 1) },       // Bodies are retrieved during normal and fast sync
 			func() error { return d.fetchReceipts(origin + 1) },     // Receipts are retrieved during fast sync
@@ -427,12 +427,13 @@ func (d *Downloader) syncWithPeer(p *peer, hash common.Hash, td *big.Int) (err e
 }
 
 // spawnSync runs d.process and all given fetcher functions to completion in
-// separate goroutines, returning the first error that appears.
-func (d *Downloader) spawnSync(fetchers ...func() error) error {
+// separate goroutines, returning the first error that appears. The origin is
+// the identifier of the peer the synchronisation is being done against.
+func (d *Downloader) spawnSync(origin string, fetchers ...func() error) error {
 	var wg sync.WaitGroup
 	errc := make(chan error, len(fetchers)+1)
 	wg.Add(len(fetchers) + 1)
-	go func() { defer wg.Done(); errc <- d.process() }()
+	go func() { defer wg.Done(); errc <- d.process(origin) }()
 	for _, fn := range fetchers {
 		fn := fn
 		go func() { defer wg.Done(); errc <- fn() }()
@@ -1512,8 +1513,9 @@ func (d *Downloader) fetchParts(errCancel error, deliveryCh chan dataPack, deliv
 }
 
 // process takes fetch results from the queue and tries to import them into the
-// chain. The type of import operation will depend on the result contents.
-func (d *Downloader) process() error {
+// chain. The type of import operation will depend on the result contents. The
+// assembled blocks are attributed to the origin peer of the synchronisation.
+func (d *Downloader) process(origin string) error {
 	pivot := d.queue.FastSyncPivot()
 	for {
 		results := d.queue.WaitResults()
@@ -1542,9 +1544,13 @@ func (d *Downloader) process() error {
 			for _, result := range results[:items] {
 				switch {
 				case d.mode == FullSync:
-					blocks = append(blocks, types.NewBlockWithHeader(result.Header).WithBody(result.Transactions, result.Uncles))
+					block := types.NewBlockWithHeader(result.Header).WithBody(result.Transactions, result.Uncles)
+					block.ReceivedFrom = origin
+					blocks = append(blocks, block)
 				case d.mode == FastSync:
-					blocks = append(blocks, types.NewBlockWithHeader(result.Header).WithBody(result.Transactions, result.Uncles))
+					block := types.NewBlockWithHeader(result.Header).WithBody(result.Transactions, result.Uncles)
+					block.ReceivedFrom = origin
+					blocks = append(blocks, block)
 					if result.Header.Number.Uint64() <= pivot {
 						receipts = append(receipts, result.Receipts)
 					}
@@ -738,6 +738,7 @@ func (f *Fetcher) enqueue(peer string, block *types.Block) {
 // the phase states accordingly.
 func (f *Fetcher) insert(peer string, block *types.Block) {
 	hash := block.Hash()
+	block.ReceivedFrom = peer
 
 	// Run the import on a new thread
 	glog.V(logger.Debug).Infof("Peer %s: importing block #%d [%x…]", peer, block.NumberU64(), hash[:4])
@@ -48,6 +48,7 @@ func NewFilterSystem(mux *event.TypeMux) *FilterSystem {
 		//core.PendingBlockEvent{},
 		core.ChainEvent{},
 		core.TxPreEvent{},
+		core.RemovedLogsEvent{},
 		vm.Logs(nil),
 	)
 	go fs.filterLoop()
@@ -122,6 +123,18 @@ func (fs *FilterSystem) filterLoop() {
 				}
 			}
 			fs.filterMu.RUnlock()
+
+		case core.RemovedLogsEvent:
+			fs.filterMu.RLock()
+			for id, filter := range fs.filters {
+				if filter.LogsCallback != nil && fs.created[id].Before(event.Time) {
+					msgs := filter.FilterLogs(ev.Logs)
+					if len(msgs) > 0 {
+						filter.LogsCallback(msgs)
+					}
+				}
+			}
+			fs.filterMu.RUnlock()
 		}
 	}
 }
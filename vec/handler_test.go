@@ -442,11 +442,13 @@ func testGetNodeData(t *testing.T, protocol int) {
 
 	// Fetch for now the entire chain db
 	hashes := []common.Hash{}
-	for _, key := range pm.chaindb.(*vecdb.MemDatabase).Keys() {
-		if len(key) == len(common.Hash{}) {
+	it := pm.chaindb.NewIterator(nil, nil)
+	for it.Next() {
+		if key := it.Key(); len(key) == len(common.Hash{}) {
 			hashes = append(hashes, common.BytesToHash(key))
 		}
 	}
+	it.Release()
 	p2p.Send(peer.app, 0x0d, hashes)
 	msg, err := peer.app.ReadMsg()
 	if err != nil {
@@ -34,9 +34,9 @@ func newTestProtocolManager(fastSync bool, blocks int, generator func(int, *core
 		pow           = new(core.FakePow)
 		db, _         = vecdb.NewMemDatabase()
 		genesis       = core.WriteGenesisBlockForTesting(db, core.GenesisAccount{testBankAddress, testBankFunds})
-		blockchain, _ = core.NewBlockChain(db, pow, evmux)
+		blockchain, _ = core.NewBlockChain(db, core.MainNetChainConfig, pow, evmux)
 	)
-	chain, _ := core.GenerateChain(genesis, db, blocks, generator)
+	chain, _ := core.GenerateChain(core.MainNetChainConfig, genesis, db, blocks, generator)
 	if _, err := blockchain.InsertChain(chain); err != nil {
 		panic(err)
 	}
@@ -23,13 +23,14 @@ import (
 	"sync"
 	"time"
 
+	"github.com/vector/go-vector/common"
 	"github.com/vector/go-vector/logger"
 	"github.com/vector/go-vector/logger/glog"
 	"github.com/vector/go-vector/metrics"
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/errors"
-	"github.com/syndtr/goleveldb/leveldb/iterator"
 	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
 
 	gometrics "github.com/rcrowley/go-metrics"
 )
@@ -37,10 +38,10 @@ import (
 var OpenFileLimit = 64
 
 // cacheRatio specifies how the total alloted cache is distributed between the
-// various system databases.
+// various system databases. Logical databases sharing the chain database via
+// tables draw from its allowance.
 var cacheRatio = map[string]float64{
-	"dapp":      2.0 / 13.0,
-	"chaindata": 11.0 / 13.0,
+	"chaindata": 1.0,
 }
 
 type LDBDatabase struct {
@@ -136,8 +137,10 @@ func (self *LDBDatabase) Delete(key []byte) error {
 	return self.db.Delete(key, nil)
 }
 
-func (self *LDBDatabase) NewIterator() iterator.Iterator {
-	return self.db.NewIterator(nil, nil)
+// NewIterator returns an iterator over the keys with the given prefix, starting
+// at prefix+start.
+func (self *LDBDatabase) NewIterator(prefix []byte, start []byte) Iterator {
+	return self.db.NewIterator(bytesPrefixRange(prefix, start), nil)
 }
 
 func (self *LDBDatabase) Close() {
@@ -162,6 +165,13 @@ func (self *LDBDatabase) Close() {
 	}
 }
 
+// Compact flattens the underlying data store for the given key range. A nil
+// start is treated as a key before all keys in the data store; a nil limit is
+// treated as a key after all keys in the data store.
+func (self *LDBDatabase) Compact(start []byte, limit []byte) error {
+	return self.db.CompactRange(util.Range{Start: start, Limit: limit})
+}
+
 func (self *LDBDatabase) LDB() *leveldb.DB {
 	return self.db
 }
@@ -264,6 +274,14 @@ func (self *LDBDatabase) meter(refresh time.Duration) {
 	}
 }
 
+// bytesPrefixRange returns the key range covering all keys with the given
+// prefix, starting at the key prefix+start.
+func bytesPrefixRange(prefix, start []byte) *util.Range {
+	r := util.BytesPrefix(prefix)
+	r.Start = append(common.CopyBytes(prefix), start...)
+	return r
+}
+
 // TODO: remove this stuff and expose leveldb directly
 
 func (db *LDBDatabase) NewBatch() Batch {
@@ -22,9 +22,37 @@ type Database interface {
 	Delete(key []byte) error
 	Close()
 	NewBatch() Batch
+
+	// NewIterator creates an iterator over the subset of database content with
+	// the given key prefix, starting at the key prefix+start. Keys are visited
+	// in ascending byte-wise order. Either argument may be nil.
+	NewIterator(prefix []byte, start []byte) Iterator
 }
 
 type Batch interface {
 	Put(key, value []byte) error
 	Write() error
 }
+
+// Iterator iterates over a database's key/value pairs in ascending key order.
+//
+// The iterator must be released after use by calling Release. Key and Value
+// return slices that are only valid until the next call to Next.
+type Iterator interface {
+	// Next moves the iterator to the next key/value pair. It returns false
+	// when the iterator is exhausted.
+	Next() bool
+
+	// Error returns any accumulated error. Exhausting all the key/value pairs
+	// is not considered to be an error.
+	Error() error
+
+	// Key returns the key of the current key/value pair, or nil if done.
+	Key() []byte
+
+	// Value returns the value of the current key/value pair, or nil if done.
+	Value() []byte
+
+	// Release releases associated resources.
+	Release()
+}
@@ -0,0 +1,87 @@
+// Copyright 2016 The go-vector Authors
+// This file is part of the go-vector library.
+//
+// The go-vector library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vector library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vector library. If not, see <http://www.gnu.org/licenses/>.
+
+package vecdb
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// Tests that prefix and start bounded iteration works on the memory database.
+func TestMemoryIterator(t *testing.T) {
+	db, _ := NewMemDatabase()
+	testIterator(t, db)
+}
+
+// Tests that prefix and start bounded iteration works on the LevelDB database.
+func TestLDBIterator(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vecdb-iterator")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := NewLDBDatabase(dir, 0)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	testIterator(t, db)
+}
+
+func testIterator(t *testing.T, db Database) {
+	for _, key := range []string{"a", "b-1", "b-2", "b-3", "b\xff", "c-1", "c-2"} {
+		if err := db.Put([]byte(key), []byte("v"+key)); err != nil {
+			t.Fatalf("failed to insert %q: %v", key, err)
+		}
+	}
+	tests := []struct {
+		prefix string
+		start  string
+		keys   []string
+	}{
+		{"", "", []string{"a", "b-1", "b-2", "b-3", "b\xff", "c-1", "c-2"}},
+		{"b", "", []string{"b-1", "b-2", "b-3", "b\xff"}},
+		{"b-", "", []string{"b-1", "b-2", "b-3"}},
+		{"b-", "2", []string{"b-2", "b-3"}},
+		{"b-", "4", nil},
+		{"", "c", []string{"c-1", "c-2"}},
+		{"d", "", nil},
+	}
+	for i, tt := range tests {
+		it := db.NewIterator([]byte(tt.prefix), []byte(tt.start))
+
+		var keys []string
+		for it.Next() {
+			if want := "v" + string(it.Key()); string(it.Value()) != want {
+				t.Errorf("test %d: value mismatch for %q: have %q, want %q", i, it.Key(), it.Value(), want)
+			}
+			keys = append(keys, string(it.Key()))
+		}
+		if err := it.Error(); err != nil {
+			t.Errorf("test %d: iteration failed: %v", i, err)
+		}
+		it.Release()
+
+		if !reflect.DeepEqual(keys, tt.keys) {
+			t.Errorf("test %d: key mismatch: have %q, want %q", i, keys, tt.keys)
+		}
+	}
+}
@@ -19,6 +19,8 @@ package vecdb
 import (
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/vector/go-vector/common"
@@ -74,6 +76,32 @@ func (db *MemDatabase) Keys() [][]byte {
 	return keys
 }
 
+// NewIterator returns an iterator over a snapshot of the keys with the given
+// prefix, starting at prefix+start. Later modifications of the database are
+// not reflected by the iterator.
+func (db *MemDatabase) NewIterator(prefix []byte, start []byte) Iterator {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	var (
+		pr   = string(prefix)
+		st   = string(append(common.CopyBytes(prefix), start...))
+		keys = make([]string, 0)
+	)
+	for key := range db.db {
+		if strings.HasPrefix(key, pr) && key >= st {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		values[i] = db.db[key]
+	}
+	return &memIterator{keys: keys, values: values, index: -1}
+}
+
 /*
 func (db *MemDatabase) GetKeys() []*common.Key {
 	data, _ := db.Get([]byte("KeyRing"))
@@ -144,3 +172,40 @@ func (b *memBatch) Write() error {
 	}
 	return nil
 }
+
+// memIterator walks a sorted snapshot of the memory database content.
+type memIterator struct {
+	keys   []string
+	values [][]byte
+	index  int
+}
+
+func (it *memIterator) Next() bool {
+	if it.index >= len(it.keys) {
+		return false
+	}
+	it.index++
+	return it.index < len(it.keys)
+}
+
+func (it *memIterator) Error() error {
+	return nil
+}
+
+func (it *memIterator) Key() []byte {
+	if it.index < 0 || it.index >= len(it.keys) {
+		return nil
+	}
+	return []byte(it.keys[it.index])
+}
+
+func (it *memIterator) Value() []byte {
+	if it.index < 0 || it.index >= len(it.keys) {
+		return nil
+	}
+	return it.values[it.index]
+}
+
+func (it *memIterator) Release() {
+	it.keys, it.values = nil, nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2016 The go-vector Authors
+// This file is part of the go-vector library.
+//
+// The go-vector library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vector library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vector library. If not, see <http://www.gnu.org/licenses/>.
+
+package vecdb
+
+// table is a logical database sharing the storage of an underlying database,
+// with all of its keys namespaced by a fixed prefix.
+type table struct {
+	db     Database
+	prefix string
+}
+
+// NewTable returns a Database object that prefixes all keys with a given
+// string before accessing the underlying database. Closing the table does
+// not close the underlying database, that remains the owner's duty.
+func NewTable(db Database, prefix string) Database {
+	return &table{
+		db:     db,
+		prefix: prefix,
+	}
+}
+
+func (dt *table) Put(key []byte, value []byte) error {
+	return dt.db.Put(append([]byte(dt.prefix), key...), value)
+}
+
+func (dt *table) Get(key []byte) ([]byte, error) {
+	return dt.db.Get(append([]byte(dt.prefix), key...))
+}
+
+func (dt *table) Delete(key []byte) error {
+	return dt.db.Delete(append([]byte(dt.prefix), key...))
+}
+
+func (dt *table) Close() {
+	// Do nothing; don't close the underlying DB.
+}
+
+func (dt *table) NewBatch() Batch {
+	return &tableBatch{dt.db.NewBatch(), dt.prefix}
+}
+
+// NewIterator returns an iterator over the table keys with the given prefix,
+// starting at prefix+start. The table prefix is stripped from the returned keys.
+func (dt *table) NewIterator(prefix []byte, start []byte) Iterator {
+	return &tableIterator{
+		it:     dt.db.NewIterator(append([]byte(dt.prefix), prefix...), start),
+		prefix: len(dt.prefix),
+	}
+}
+
+// tableBatch is a write batch on a table, prefixing all keys before queueing
+// them into a batch of the underlying database.
+type tableBatch struct {
+	batch  Batch
+	prefix string
+}
+
+func (tb *tableBatch) Put(key, value []byte) error {
+	return tb.batch.Put(append([]byte(tb.prefix), key...), value)
+}
+
+func (tb *tableBatch) Write() error {
+	return tb.batch.Write()
+}
+
+// tableIterator wraps an iterator of the underlying database, hiding the
+// table prefix from the iterated keys.
+type tableIterator struct {
+	it     Iterator
+	prefix int
+}
+
+func (ti *tableIterator) Next() bool    { return ti.it.Next() }
+func (ti *tableIterator) Error() error  { return ti.it.Error() }
+func (ti *tableIterator) Value() []byte { return ti.it.Value() }
+func (ti *tableIterator) Release()      { ti.it.Release() }
+
+func (ti *tableIterator) Key() []byte {
+	key := ti.it.Key()
+	if key == nil {
+		return nil
+	}
+	return key[ti.prefix:]
+}
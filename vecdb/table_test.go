@@ -0,0 +1,80 @@
+// Copyright 2016 The go-vector Authors
+// This file is part of the go-vector library.
+//
+// The go-vector library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vector library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vector library. If not, see <http://www.gnu.org/licenses/>.
+
+package vecdb
+
+import (
+	"bytes"
+	"testing"
+)
+
+// Tests that tables sharing a database see only their own keys.
+func TestTableIsolation(t *testing.T) {
+	db, _ := NewMemDatabase()
+	db.Put([]byte("b-1"), []byte("raw"))
+
+	first, second := NewTable(db, "1/"), NewTable(db, "2/")
+	first.Put([]byte("b-1"), []byte("first"))
+	second.Put([]byte("b-1"), []byte("second"))
+
+	for i, tt := range []struct {
+		db   Database
+		want string
+	}{{db, "raw"}, {first, "first"}, {second, "second"}} {
+		if val, err := tt.db.Get([]byte("b-1")); err != nil || string(val) != tt.want {
+			t.Errorf("db %d: value mismatch: have %q/%v, want %q", i, val, err, tt.want)
+		}
+	}
+	if val, err := db.Get([]byte("1/b-1")); err != nil || string(val) != "first" {
+		t.Errorf("underlying value mismatch: have %q/%v, want %q", val, err, "first")
+	}
+	first.Delete([]byte("b-1"))
+	if _, err := first.Get([]byte("b-1")); err == nil {
+		t.Errorf("deleted key still present")
+	}
+	if _, err := second.Get([]byte("b-1")); err != nil {
+		t.Errorf("key deleted from foreign table: %v", err)
+	}
+}
+
+// Tests that table batches are prefixed and flushed into the underlying database.
+func TestTableBatch(t *testing.T) {
+	db, _ := NewMemDatabase()
+	tbl := NewTable(db, "t/")
+
+	batch := tbl.NewBatch()
+	batch.Put([]byte("a"), []byte("1"))
+	batch.Put([]byte("b"), []byte("2"))
+	if _, err := tbl.Get([]byte("a")); err == nil {
+		t.Fatalf("batch content visible before write")
+	}
+	if err := batch.Write(); err != nil {
+		t.Fatalf("failed to write batch: %v", err)
+	}
+	if val, _ := db.Get([]byte("t/b")); !bytes.Equal(val, []byte("2")) {
+		t.Errorf("underlying value mismatch: have %q, want %q", val, "2")
+	}
+}
+
+// Tests that iteration over tables is confined to the table and hides its prefix.
+func TestTableIterator(t *testing.T) {
+	db, _ := NewMemDatabase()
+	db.Put([]byte("a"), []byte("raw"))
+	db.Put([]byte("u/b-1"), []byte("raw"))
+	db.Put([]byte("u0"), []byte("raw"))
+
+	testIterator(t, NewTable(db, "t/"))
+}
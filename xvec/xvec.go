@@ -329,6 +329,12 @@ func (self *XEth) EthTransactionByHash(hash string) (*types.Transaction, common.
 	return self.backend.TxPool().GetTransaction(common.HexToHash(hash)), common.Hash{}, 0, 0
 }
 
+// EthTransactionsByAddress returns the hashes of the canonical transactions sent
+// from or to the given address, as recorded by the address transaction index.
+func (self *XEth) EthTransactionsByAddress(addr string, offset, count int) []common.Hash {
+	return core.GetAddrTransactions(self.backend.ChainDb(), common.HexToAddress(addr), offset, count)
+}
+
 func (self *XEth) BlockByNumber(num int64) *Block {
 	return NewBlock(self.getBlockByHeight(num))
 }
@@ -832,7 +838,11 @@ func (self *XEth) Call(fromStr, toStr, valueStr, gasStr, gasPriceStr, dataStr st
 	}
 
 	header := self.CurrentBlock().Header()
-	vmenv := core.NewEnv(statedb, self.backend.BlockChain(), msg, header)
+	var tracer vm.Tracer
+	if vm.Debug {
+		tracer = vm.NewStructLogger(nil)
+	}
+	vmenv := core.NewEnv(statedb, self.backend.BlockChain().Config(), self.backend.BlockChain(), msg, header, tracer)
 	gp := new(core.GasPool).AddGas(common.MaxBig)
 	res, gas, err := core.ApplyMessage(vmenv, msg, gp)
 	return common.ToHex(res), gas.String(), err